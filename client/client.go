@@ -3,9 +3,11 @@ package client
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 
 	"github.com/wojtechnology/glacier/core"
@@ -28,35 +30,34 @@ const (
 	INPUT_FLAG_ADMIN InputFlag = 1 << iota
 	INPUT_FLAG_WRITER
 	INPUT_FLAG_ROW_WRITER
+	// Adds a ColWriterInput for every column of the transaction, so it can only be used for
+	// transactions that only write to columns that the client is a col writer of
+	INPUT_FLAG_COL_WRITER
 )
 
 func NewClient(url string, priv *ecdsa.PrivateKey) *Client {
 	return &Client{url: url, me: core.NewNode(priv)}
 }
 
-func (c *Client) CreateTable(tableName []byte, outputs []map[string][]byte) error {
+func (c *Client) CreateTable(tableName []byte, outputs []map[string][]byte) (core.Hash, error) {
 	coreOutputs, err := outputsFromMaps(outputs)
 	if err != nil {
-		return err
+		return core.Hash{}, err
 	}
 	tx := &core.Transaction{
 		Type:      core.TRANSACTION_TYPE_CREATE_TABLE,
 		TableName: tableName,
 		Outputs:   coreOutputs,
 	}
-	err = c.postTransaction(tx)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.postTransaction(tx)
 }
 
 func (c *Client) UpdateTable(tableName []byte, outputs []map[string][]byte,
-	inputFlag InputFlag) error {
+	inputFlag InputFlag) (core.Hash, error) {
 
 	coreOutputs, err := outputsFromMaps(outputs)
 	if err != nil {
-		return err
+		return core.Hash{}, err
 	}
 	tx := &core.Transaction{
 		Type:      core.TRANSACTION_TYPE_UPDATE_TABLE,
@@ -65,22 +66,85 @@ func (c *Client) UpdateTable(tableName []byte, outputs []map[string][]byte,
 	}
 	err = c.populateAndSignInputs(tx, inputFlag)
 	if err != nil {
-		return err
+		return core.Hash{}, err
 	}
-	err = c.postTransaction(tx)
+	return c.postTransaction(tx)
+}
+
+// Creates a table that has been dropped again. generation is the generation of the new table,
+// one more than the generation that was dropped.
+func (c *Client) RecreateTable(tableName []byte, generation int64,
+	outputs []map[string][]byte) (core.Hash, error) {
+
+	coreOutputs, err := outputsFromMaps(outputs)
 	if err != nil {
-		return err
+		return core.Hash{}, err
+	}
+	tx := &core.Transaction{
+		Type:      core.TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: tableName,
+		Outputs: append(coreOutputs, &core.TableGenerationOutput{
+			TableNameMixin: &core.TableNameMixin{Table: tableName},
+			Generation:     big.NewInt(generation),
+		}),
 	}
+	return c.postTransaction(tx)
+}
 
-	return nil
+// Drops the given generation of the table. The generation of a table is kept in its metadata.
+func (c *Client) DropTable(tableName []byte, generation int64,
+	inputFlag InputFlag) (core.Hash, error) {
+
+	tx := &core.Transaction{
+		Type:      core.TRANSACTION_TYPE_DROP_TABLE,
+		TableName: tableName,
+		Outputs: []core.Output{
+			&core.TableDroppedOutput{
+				TableNameMixin: &core.TableNameMixin{Table: tableName},
+				Generation:     big.NewInt(generation),
+			},
+		},
+	}
+	err := c.populateAndSignInputs(tx, inputFlag)
+	if err != nil {
+		return core.Hash{}, err
+	}
+	return c.postTransaction(tx)
+}
+
+// Declares the types of the columns of the table. Schemas replace the schemas that their columns
+// had before, cells that were written before are not checked again.
+func (c *Client) SetColSchemas(tableName []byte, schemas []*core.ColSchema,
+	inputFlag InputFlag) (core.Hash, error) {
+
+	coreOutputs := make([]core.Output, len(schemas))
+	for i, schema := range schemas {
+		coreOutputs[i] = &core.ColSchemaOutput{
+			TableNameMixin: &core.TableNameMixin{Table: tableName},
+			ColName:        schema.ColId,
+			ColType:        schema.Type,
+			MaxLength:      schema.MaxLength,
+			Nullable:       schema.Nullable,
+		}
+	}
+	tx := &core.Transaction{
+		Type:      core.TRANSACTION_TYPE_UPDATE_TABLE,
+		TableName: tableName,
+		Outputs:   coreOutputs,
+	}
+	err := c.populateAndSignInputs(tx, inputFlag)
+	if err != nil {
+		return core.Hash{}, err
+	}
+	return c.postTransaction(tx)
 }
 
 func (c *Client) PutCells(tableName, rowId []byte, cols map[string]*core.Cell,
-	outputs []map[string][]byte, inputFlag InputFlag) error {
+	outputs []map[string][]byte, inputFlag InputFlag) (core.Hash, error) {
 
 	coreOutputs, err := outputsFromMaps(outputs)
 	if err != nil {
-		return err
+		return core.Hash{}, err
 	}
 	tx := &core.Transaction{
 		Type:      core.TRANSACTION_TYPE_PUT_CELLS,
@@ -91,14 +155,75 @@ func (c *Client) PutCells(tableName, rowId []byte, cols map[string]*core.Cell,
 	}
 	err = c.populateAndSignInputs(tx, inputFlag)
 	if err != nil {
-		return err
+		return core.Hash{}, err
 	}
-	err = c.postTransaction(tx)
+	return c.postTransaction(tx)
+}
+
+// Deletes all versions of the given columns in the row. Cells that are written to the columns
+// after the delete is applied are visible again.
+func (c *Client) DeleteCells(tableName, rowId []byte, colIds [][]byte,
+	inputFlag InputFlag) (core.Hash, error) {
+
+	cols := make(map[string]*core.Cell)
+	for _, colId := range colIds {
+		cols[string(colId)] = &core.Cell{}
+	}
+	tx := &core.Transaction{
+		Type:      core.TRANSACTION_TYPE_DELETE_CELLS,
+		TableName: tableName,
+		RowId:     rowId,
+		Cols:      cols,
+	}
+	err := c.populateAndSignInputs(tx, inputFlag)
 	if err != nil {
-		return err
+		return core.Hash{}, err
 	}
+	return c.postTransaction(tx)
+}
 
-	return nil
+// Deletes all columns that the row has at the time the delete is applied.
+func (c *Client) DeleteRow(tableName, rowId []byte, inputFlag InputFlag) (core.Hash, error) {
+	tx := &core.Transaction{
+		Type:      core.TRANSACTION_TYPE_DELETE_ROW,
+		TableName: tableName,
+		RowId:     rowId,
+	}
+	err := c.populateAndSignInputs(tx, inputFlag)
+	if err != nil {
+		return core.Hash{}, err
+	}
+	return c.postTransaction(tx)
+}
+
+// Adds the node with the given public key to the federation. A quorum of the current members of the
+// federation has to sign the transaction, so the private keys of the signing members are needed.
+func (c *Client) AddNode(pubKey []byte, signers []*ecdsa.PrivateKey) (core.Hash, error) {
+	tx := &core.Transaction{
+		Type: core.TRANSACTION_TYPE_ADD_NODE,
+		Cols: map[string]*core.Cell{core.NODE_COL: &core.Cell{Data: pubKey}},
+		Outputs: []core.Output{
+			&core.NodeOutput{TableNameMixin: &core.TableNameMixin{}, PubKey: pubKey},
+		},
+	}
+	if err := signMemberInputs(tx, signers); err != nil {
+		return core.Hash{}, err
+	}
+	return c.postTransaction(tx)
+}
+
+// Removes the node with the given public key from the federation. A quorum of the current members
+// of the federation has to sign the transaction, so the private keys of the signing members are
+// needed.
+func (c *Client) RemoveNode(pubKey []byte, signers []*ecdsa.PrivateKey) (core.Hash, error) {
+	tx := &core.Transaction{
+		Type: core.TRANSACTION_TYPE_REMOVE_NODE,
+		Cols: map[string]*core.Cell{core.NODE_COL: &core.Cell{Data: pubKey}},
+	}
+	if err := signMemberInputs(tx, signers); err != nil {
+		return core.Hash{}, err
+	}
+	return c.postTransaction(tx)
 }
 
 // Populates the transaction with signed inputs according the the given `inputFlag`.
@@ -140,6 +265,19 @@ func (c *Client) populateAndSignInputs(tx *core.Transaction, inputFlag InputFlag
 		}
 		coreInputs = append(coreInputs, coreInput)
 	}
+	if inputFlag&INPUT_FLAG_COL_WRITER != 0 {
+		for colId, _ := range tx.Cols {
+			assocOutput := &core.ColWriterOutput{
+				TableNameMixin: &core.TableNameMixin{Table: tx.TableName},
+				ColName:        []byte(colId),
+				PubKey:         c.me.PubKey,
+			}
+			coreInput := &core.ColWriterInput{InputLink: core.InputLink{
+				LinksTo: core.HashOutput(assocOutput)},
+			}
+			coreInputs = append(coreInputs, coreInput)
+		}
+	}
 
 	tx.Inputs = coreInputs
 	sig, err := crypto.Sign(tx.Hash().Bytes(), c.me.PrivKey)
@@ -157,27 +295,87 @@ func (c *Client) populateAndSignInputs(tx *core.Transaction, inputFlag InputFlag
 	return nil
 }
 
+// Returns the lifecycle status of the transaction with the given hash. For transactions that were
+// dropped as invalid, the status contains the reason for the rejection.
+func (c *Client) GetTransactionStatus(txHash core.Hash) (*handler.TransactionStatusData, error) {
+	res, err := http.Get(c.url + "/transaction/" + hex.EncodeToString(txHash.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Request failed with status %s", res.Status))
+	}
+
+	status := new(handler.TransactionStatusData)
+	if err := json.NewDecoder(res.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
 // Actually makes request to server using the given `url` in the client.
 // Doesn't modify the transaction.
-func (c *Client) postTransaction(tx *core.Transaction) error {
+// Returns the hash of the transaction as reported by the server.
+func (c *Client) postTransaction(tx *core.Transaction) (core.Hash, error) {
 	td := handler.FromCoreTransaction(tx)
 	data := new(bytes.Buffer)
-	err := json.NewEncoder(data).Encode(td)
+	if err := json.NewEncoder(data).Encode(td); err != nil {
+		return core.Hash{}, err
+	}
 
 	res, err := http.Post(c.url+"/transaction/", "application/json; charset=utf-8", data)
 	if err != nil {
-		return err
+		return core.Hash{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return core.Hash{}, errors.New(fmt.Sprintf("Request failed with status %s", res.Status))
 	}
 
-	fmt.Printf("%v", res.Status)
-
-	return nil
+	var hd handler.TransactionHashData
+	if err := json.NewDecoder(res.Body).Decode(&hd); err != nil {
+		return core.Hash{}, err
+	}
+	txHash, err := hex.DecodeString(hd.Hash)
+	if err != nil {
+		return core.Hash{}, err
+	}
+	return core.BytesToHash(txHash), nil
 }
 
 // -------
 // Helpers
 // -------
 
+// Populates the transaction with a member input for every signer, then signs the transaction with
+// the private key of every signer. Works in the same two steps as `populateAndSignInputs`.
+func signMemberInputs(tx *core.Transaction, signers []*ecdsa.PrivateKey) error {
+	coreInputs := make([]core.Input, len(signers))
+	for i, signer := range signers {
+		assocOutput := &core.NodeOutput{
+			TableNameMixin: &core.TableNameMixin{},
+			PubKey:         crypto.MarshalPublicKey(&signer.PublicKey),
+		}
+		coreInputs[i] = &core.MemberInput{InputLink: core.InputLink{
+			LinksTo: core.HashOutput(assocOutput)},
+		}
+	}
+
+	tx.Inputs = coreInputs
+	for i, signer := range signers {
+		sig, err := crypto.Sign(tx.Hash().Bytes(), signer)
+		if err != nil {
+			return err
+		}
+		if err := tx.Inputs[i].FromData(sig); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var OUTPUT_NAME_MAP = map[string]core.OutputType{
 	"table_exists":     core.OUTPUT_TYPE_TABLE_EXISTS,
 	"col_allowed":      core.OUTPUT_TYPE_COL_ALLOWED,
@@ -188,6 +386,9 @@ var OUTPUT_NAME_MAP = map[string]core.OutputType{
 	"writer":           core.OUTPUT_TYPE_WRITER,
 	"all_row_writers":  core.OUTPUT_TYPE_ALL_ROW_WRITERS,
 	"row_writer":       core.OUTPUT_TYPE_ROW_WRITER,
+	"node":             core.OUTPUT_TYPE_NODE,
+	"restricted_col":   core.OUTPUT_TYPE_RESTRICTED_COL,
+	"col_writer":       core.OUTPUT_TYPE_COL_WRITER,
 }
 
 // Takes a list of maps that describe outputs and creates `core.Output` implementation objects
@@ -30,7 +30,7 @@ func main() {
 	priv := privFromFile(os.Args[2])
 	c := client.NewClient(os.Args[1], priv)
 	pub := crypto.MarshalPublicKey(&priv.PublicKey)
-	err := c.CreateTable([]byte("wtf"), []map[string][]byte{
+	_, err := c.CreateTable([]byte("wtf"), []map[string][]byte{
 		map[string][]byte{
 			"type":      []byte("table_exists"),
 			"TableName": []byte("wtf"),
@@ -41,7 +41,7 @@ func main() {
 			"PubKey":    pub,
 		},
 	})
-	// err := c.UpdateTable([]byte("wtf"),
+	// _, err := c.UpdateTable([]byte("wtf"),
 	// 	[]map[string][]byte{
 	// 		map[string][]byte{
 	// 			"type":      []byte("all_writers"),
@@ -28,4 +28,7 @@ func main() {
 	if err := ioutil.WriteFile(path, crypto.MarshalPrivateKey(priv), 0644); err != nil {
 		printError("writing private key", err)
 	}
+
+	// To be added to the federation in the config file of every node
+	fmt.Printf("Public key: %x\n", crypto.MarshalPublicKey(&priv.PublicKey))
 }
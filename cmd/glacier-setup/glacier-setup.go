@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/wojtechnology/glacier/config"
 	"github.com/wojtechnology/glacier/core"
 	"github.com/wojtechnology/glacier/logging"
 	"github.com/wojtechnology/glacier/meddb"
@@ -31,19 +32,19 @@ func writeGenesis(bc *core.Blockchain) error {
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("usage: glacier-setup <priv_key_file>")
+		fmt.Println("usage: glacier-setup <config_file>")
 		os.Exit(1)
 	}
 	logging.InitLoggers(os.Stdout, os.Stderr)
-	logging.Info("Setting up glacier on localhost...")
 
 	// Check inputs before doing anything
-	me, err := core.NewNodeFromFile(os.Args[1])
+	cfg, err := config.Load(os.Args[1])
 	if err != nil {
 		panic(err)
 	}
+	logging.Info("Setting up glacier on %v...", cfg.DBAddresses)
 
-	db, err := meddb.NewRethinkBlockchainDB([]string{"localhost"}, "prod")
+	db, err := meddb.NewRethinkBlockchainDB(cfg.DBAddresses, cfg.Database)
 	if err != nil {
 		panic(err)
 	}
@@ -54,7 +55,13 @@ func main() {
 		panic(err)
 	}
 
-	bc, err := core.InitBlockchain(me)
+	bc, err := core.InitBlockchain(cfg.Me, cfg.Federation, cfg.DBAddresses, cfg.Database)
+	if err != nil {
+		panic(err)
+	}
+
+	logging.Info("Creating state tables...")
+	err = bc.SetupState()
 	if err != nil {
 		panic(err)
 	}
@@ -63,5 +70,5 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	logging.Info("Successfully set up glacier on local host.")
+	logging.Info("Successfully set up glacier on %v.", cfg.DBAddresses)
 }
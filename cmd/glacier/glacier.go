@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/wojtechnology/glacier/config"
 	"github.com/wojtechnology/glacier/core"
 	"github.com/wojtechnology/glacier/logging"
 	"github.com/wojtechnology/glacier/loop"
@@ -11,29 +15,46 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("usage: glacier <priv_key_file>")
+		fmt.Println("usage: glacier <config_file>")
 		os.Exit(1)
 	}
 
-	path := os.Args[1]
-	me, err := core.NewNodeFromFile(path)
+	cfg, err := config.Load(os.Args[1])
 	if err != nil {
 		panic(err)
 	}
+	loop.SetConfig(cfg.Loop)
 
-	bc, err := core.InitBlockchain(me)
+	bc, err := core.InitBlockchain(cfg.Me, cfg.Federation, cfg.DBAddresses, cfg.Database)
 	if err != nil {
 		panic(err)
 	}
+	// Membership changes that were applied before the restart replace the federation of the config
+	if err := bc.LoadFederation(); err != nil {
+		panic(err)
+	}
+	assignment, err := bc.NewAssignmentStrategy(cfg.Assignment)
+	if err != nil {
+		panic(err)
+	}
+	bc.SetAssignmentStrategy(assignment)
+	bc.SetHeartbeatStaleAge(cfg.Loop.HeartbeatStaleAgeMS)
 	logging.InitLoggers(os.Stdout, os.Stderr)
 	logging.Info("Glacier is now running!")
 
-	errChannel := make(chan error)
-	go loop.IOLoop(bc, errChannel)
-	go loop.ReassignTransactionsLoop(bc, errChannel)
-	go loop.AddBlockLoop(bc, errChannel)
-	go loop.VoteOnBlocksLoop(bc, errChannel)
+	// Loops finish what they are doing on SIGINT and SIGTERM before the node stops
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logging.Info("Received %s", sig)
+		cancel()
+	}()
 
-	err = <-errChannel
-	panic(err)
+	if err := loop.NewNodeServer(bc).Run(ctx); err != nil {
+		logging.Error(err.Error())
+		os.Exit(1)
+	}
+	logging.Info("Glacier stopped")
 }
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/crypto"
+	"github.com/wojtechnology/glacier/loop"
+)
+
+// Config of a single node, built from a JSON config file that looks like this:
+//
+//	{
+//	    "priv_key_file": "priv",
+//	    "federation": ["04a1...", "04b2..."],
+//	    "db": {"addresses": ["localhost"], "database": "prod"},
+//	    "http_addr": ":8000",
+//	    "assignment": "least_backlog",
+//	    "loops": {"block_longest_wait_ms": 5000}
+//	}
+//
+// The federation contains the hex encoded public keys of all nodes, including this one.
+// priv_key_file is relative to the directory of the config file. assignment is one of
+// core.ASSIGNMENT_STRATEGIES and defaults to round robin. Loop timings that are left out keep
+// their defaults, see loop.DefaultConfig.
+type Config struct {
+	Me          *core.Node
+	Federation  []*core.Node // Only contains public keys
+	DBAddresses []string
+	Database    string
+	Assignment  string // Name of the assignment strategy
+	Loop        *loop.Config
+}
+
+type fileConfig struct {
+	PrivKeyFile string          `json:"priv_key_file"`
+	Federation  []string        `json:"federation"`
+	DB          *dbFileConfig   `json:"db"`
+	HTTPAddr    string          `json:"http_addr"`
+	Assignment  string          `json:"assignment"`
+	Loops       *loopFileConfig `json:"loops"`
+}
+
+type dbFileConfig struct {
+	Addresses []string `json:"addresses"`
+	Database  string   `json:"database"`
+}
+
+type loopFileConfig struct {
+	BlockLoopWaitMS           int64 `json:"block_loop_wait_ms"`
+	BlockMinTransactions      int   `json:"block_min_transactions"`
+	BlockLongestWaitMS        int64 `json:"block_longest_wait_ms"`
+	ReassignLoopWaitMS        int64 `json:"reassign_loop_wait_ms"`
+	ReassignStaleAgeMS        int64 `json:"reassign_stale_age_ms"`
+	PruneRejectionsLoopWaitMS int64 `json:"prune_rejections_loop_wait_ms"`
+	RejectionRetentionMS      int64 `json:"rejection_retention_ms"`
+	ForkCheckLoopWaitMS       int64 `json:"fork_check_loop_wait_ms"`
+	HeartbeatLoopWaitMS       int64 `json:"heartbeat_loop_wait_ms"`
+	HeartbeatStaleAgeMS       int64 `json:"heartbeat_stale_age_ms"`
+	RestartMinBackoffMS       int64 `json:"restart_min_backoff_ms"`
+	RestartMaxBackoffMS       int64 `json:"restart_max_backoff_ms"`
+	ShutdownTimeoutMS         int64 `json:"shutdown_timeout_ms"`
+}
+
+// Reads and validates the config file at the given path.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data, filepath.Dir(path))
+}
+
+// Parses and validates a JSON config. Relative paths are resolved from dir.
+func Parse(data []byte, dir string) (*Config, error) {
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, err
+	}
+
+	if fc.PrivKeyFile == "" {
+		return nil, &InvalidFieldError{Field: "priv_key_file", Reason: "missing"}
+	}
+	privKeyPath := fc.PrivKeyFile
+	if !filepath.IsAbs(privKeyPath) {
+		privKeyPath = filepath.Join(dir, privKeyPath)
+	}
+	me, err := core.NewNodeFromFile(privKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	federation, err := parseFederation(fc.Federation)
+	if err != nil {
+		return nil, err
+	}
+	if !containsNode(federation, me) {
+		return nil, &InvalidFieldError{
+			Field:  "federation",
+			Reason: fmt.Sprintf("missing own public key %x", me.PubKey),
+		}
+	}
+
+	if fc.DB == nil || len(fc.DB.Addresses) == 0 {
+		return nil, &InvalidFieldError{Field: "db.addresses", Reason: "missing"}
+	}
+	if fc.DB.Database == "" {
+		return nil, &InvalidFieldError{Field: "db.database", Reason: "missing"}
+	}
+
+	assignment, err := parseAssignment(fc.Assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	loopConfig, err := parseLoopConfig(fc.HTTPAddr, fc.Loops)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		Me:          me,
+		Federation:  federation,
+		DBAddresses: fc.DB.Addresses,
+		Database:    fc.DB.Database,
+		Assignment:  assignment,
+		Loop:        loopConfig,
+	}, nil
+}
+
+// -------
+// Helpers
+// -------
+
+func parseFederation(keys []string) ([]*core.Node, error) {
+	if len(keys) == 0 {
+		return nil, &InvalidFieldError{Field: "federation", Reason: "missing"}
+	}
+
+	federation := make([]*core.Node, len(keys))
+	for i, key := range keys {
+		pubKey, err := hex.DecodeString(key)
+		if err != nil {
+			return nil, &MalformedKeyError{Index: i, Key: key, Reason: "not hex encoded"}
+		}
+		pub := crypto.ParsePublicKey(pubKey)
+		if pub == nil || pub.X == nil {
+			return nil, &MalformedKeyError{Index: i, Key: key, Reason: "not a public key"}
+		}
+
+		federation[i] = &core.Node{PubKey: pubKey}
+		if containsNode(federation[:i], federation[i]) {
+			return nil, &MalformedKeyError{Index: i, Key: key, Reason: "duplicate"}
+		}
+	}
+	return federation, nil
+}
+
+func containsNode(nodes []*core.Node, node *core.Node) bool {
+	for _, other := range nodes {
+		if bytes.Equal(other.PubKey, node.PubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAssignment(name string) (string, error) {
+	if name == "" {
+		return core.ASSIGNMENT_ROUND_ROBIN, nil
+	}
+	for _, valid := range core.ASSIGNMENT_STRATEGIES {
+		if name == valid {
+			return name, nil
+		}
+	}
+	return "", &InvalidFieldError{
+		Field:  "assignment",
+		Reason: fmt.Sprintf("unknown strategy %s", name),
+	}
+}
+
+// Applies the given loop settings on top of the defaults.
+func parseLoopConfig(httpAddr string, lc *loopFileConfig) (*loop.Config, error) {
+	c := loop.DefaultConfig()
+	if httpAddr != "" {
+		c.HTTPAddr = httpAddr
+	}
+	if lc == nil {
+		return c, nil
+	}
+
+	fields := []struct {
+		name  string
+		value int64
+		dest  *int64
+	}{
+		{"loops.block_loop_wait_ms", lc.BlockLoopWaitMS, &c.BlockLoopWaitMS},
+		{"loops.block_longest_wait_ms", lc.BlockLongestWaitMS, &c.BlockLongestWaitMS},
+		{"loops.reassign_loop_wait_ms", lc.ReassignLoopWaitMS, &c.ReassignLoopWaitMS},
+		{"loops.reassign_stale_age_ms", lc.ReassignStaleAgeMS, &c.ReassignStaleAgeMS},
+		{"loops.prune_rejections_loop_wait_ms", lc.PruneRejectionsLoopWaitMS,
+			&c.PruneRejectionsLoopWaitMS},
+		{"loops.rejection_retention_ms", lc.RejectionRetentionMS, &c.RejectionRetentionMS},
+		{"loops.fork_check_loop_wait_ms", lc.ForkCheckLoopWaitMS, &c.ForkCheckLoopWaitMS},
+		{"loops.heartbeat_loop_wait_ms", lc.HeartbeatLoopWaitMS, &c.HeartbeatLoopWaitMS},
+		{"loops.heartbeat_stale_age_ms", lc.HeartbeatStaleAgeMS, &c.HeartbeatStaleAgeMS},
+		{"loops.restart_min_backoff_ms", lc.RestartMinBackoffMS, &c.RestartMinBackoffMS},
+		{"loops.restart_max_backoff_ms", lc.RestartMaxBackoffMS, &c.RestartMaxBackoffMS},
+		{"loops.shutdown_timeout_ms", lc.ShutdownTimeoutMS, &c.ShutdownTimeoutMS},
+	}
+	for _, field := range fields {
+		if field.value < 0 {
+			return nil, &InvalidFieldError{Field: field.name, Reason: "negative"}
+		}
+		if field.value > 0 {
+			*field.dest = field.value
+		}
+	}
+
+	if c.RestartMinBackoffMS > c.RestartMaxBackoffMS {
+		return nil, &InvalidFieldError{
+			Field:  "loops.restart_min_backoff_ms",
+			Reason: "greater than loops.restart_max_backoff_ms",
+		}
+	}
+
+	if lc.BlockMinTransactions < 0 {
+		return nil, &InvalidFieldError{Field: "loops.block_min_transactions", Reason: "negative"}
+	}
+	if lc.BlockMinTransactions > 0 {
+		c.BlockMinTransactions = lc.BlockMinTransactions
+	}
+
+	return c, nil
+}
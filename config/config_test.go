@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/crypto"
+	"github.com/wojtechnology/glacier/loop"
+)
+
+// Writes a new private key to a temp dir. Returns the dir and the hex encoded public key.
+func setupConfigDir(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "glacier-config")
+	assert.Nil(t, err)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "priv"), crypto.MarshalPrivateKey(priv), 0644)
+	assert.Nil(t, err)
+
+	return dir, hex.EncodeToString(crypto.MarshalPublicKey(&priv.PublicKey))
+}
+
+func newPubKey(t *testing.T) string {
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	return hex.EncodeToString(crypto.MarshalPublicKey(&priv.PublicKey))
+}
+
+func TestLoad(t *testing.T) {
+	dir, me := setupConfigDir(t)
+	defer os.RemoveAll(dir)
+	other := newPubKey(t)
+
+	data := []byte(`{
+		"priv_key_file": "priv",
+		"federation": ["` + me + `", "` + other + `"],
+		"db": {"addresses": ["db1:28015", "db2:28015"], "database": "test"},
+		"http_addr": ":9000",
+		"assignment": "consistent_hash",
+		"loops": {
+			"block_longest_wait_ms": 200,
+			"block_min_transactions": 10,
+			"heartbeat_stale_age_ms": 6000
+		}
+	}`)
+	path := filepath.Join(dir, "glacier.json")
+	assert.Nil(t, ioutil.WriteFile(path, data, 0644))
+
+	cfg, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, me, hex.EncodeToString(cfg.Me.PubKey))
+	assert.NotNil(t, cfg.Me.PrivKey)
+	assert.Equal(t, 2, len(cfg.Federation))
+	assert.Equal(t, me, hex.EncodeToString(cfg.Federation[0].PubKey))
+	assert.Equal(t, other, hex.EncodeToString(cfg.Federation[1].PubKey))
+	assert.Equal(t, []string{"db1:28015", "db2:28015"}, cfg.DBAddresses)
+	assert.Equal(t, "test", cfg.Database)
+	assert.Equal(t, core.ASSIGNMENT_CONSISTENT_HASH, cfg.Assignment)
+
+	expected := loop.DefaultConfig()
+	expected.HTTPAddr = ":9000"
+	expected.BlockLongestWaitMS = 200
+	expected.BlockMinTransactions = 10
+	expected.HeartbeatStaleAgeMS = 6000
+	assert.Equal(t, expected, cfg.Loop)
+}
+
+func TestParseDefaults(t *testing.T) {
+	dir, me := setupConfigDir(t)
+	defer os.RemoveAll(dir)
+
+	data := []byte(`{
+		"priv_key_file": "priv",
+		"federation": ["` + me + `"],
+		"db": {"addresses": ["localhost"], "database": "prod"}
+	}`)
+	cfg, err := Parse(data, dir)
+	assert.Nil(t, err)
+	assert.Equal(t, core.ASSIGNMENT_ROUND_ROBIN, cfg.Assignment)
+	assert.Equal(t, loop.DefaultConfig(), cfg.Loop)
+}
+
+func TestParseMalformedKey(t *testing.T) {
+	dir, me := setupConfigDir(t)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		key    string
+		reason string
+	}{
+		{"not hex", "not hex encoded"},
+		{"04abcd", "not a public key"},
+		{me, "duplicate"},
+	}
+	for _, c := range cases {
+		data := []byte(`{
+			"priv_key_file": "priv",
+			"federation": ["` + me + `", "` + c.key + `"],
+			"db": {"addresses": ["localhost"], "database": "prod"}
+		}`)
+		_, err := Parse(data, dir)
+		assert.Equal(t, &MalformedKeyError{Index: 1, Key: c.key, Reason: c.reason}, err)
+	}
+}
+
+func TestParseInvalidField(t *testing.T) {
+	dir, me := setupConfigDir(t)
+	defer os.RemoveAll(dir)
+	other := newPubKey(t)
+
+	cases := []struct {
+		data  string
+		field string
+	}{
+		{`{
+			"federation": ["` + me + `"],
+			"db": {"addresses": ["localhost"], "database": "prod"}
+		}`, "priv_key_file"},
+		{`{
+			"priv_key_file": "priv",
+			"db": {"addresses": ["localhost"], "database": "prod"}
+		}`, "federation"},
+		{`{
+			"priv_key_file": "priv",
+			"federation": ["` + other + `"],
+			"db": {"addresses": ["localhost"], "database": "prod"}
+		}`, "federation"},
+		{`{
+			"priv_key_file": "priv",
+			"federation": ["` + me + `"],
+			"db": {"database": "prod"}
+		}`, "db.addresses"},
+		{`{
+			"priv_key_file": "priv",
+			"federation": ["` + me + `"],
+			"db": {"addresses": ["localhost"]}
+		}`, "db.database"},
+		{`{
+			"priv_key_file": "priv",
+			"federation": ["` + me + `"],
+			"db": {"addresses": ["localhost"], "database": "prod"},
+			"loops": {"reassign_stale_age_ms": -1}
+		}`, "loops.reassign_stale_age_ms"},
+		{`{
+			"priv_key_file": "priv",
+			"federation": ["` + me + `"],
+			"db": {"addresses": ["localhost"], "database": "prod"},
+			"loops": {"restart_min_backoff_ms": 90000}
+		}`, "loops.restart_min_backoff_ms"},
+		{`{
+			"priv_key_file": "priv",
+			"federation": ["` + me + `"],
+			"db": {"addresses": ["localhost"], "database": "prod"},
+			"assignment": "random"
+		}`, "assignment"},
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c.data), dir)
+		if assert.IsType(t, &InvalidFieldError{}, err) {
+			assert.Equal(t, c.field, err.(*InvalidFieldError).Field)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import "fmt"
+
+type InvalidFieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvalidFieldError) Error() string {
+	return fmt.Sprintf("Invalid config field \"%s\": %s", e.Field, e.Reason)
+}
+
+type MalformedKeyError struct {
+	Index  int // Index of the key in the federation
+	Key    string
+	Reason string
+}
+
+func (e *MalformedKeyError) Error() string {
+	return fmt.Sprintf("Malformed public key %d in federation \"%s\": %s", e.Index, e.Key,
+		e.Reason)
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Names of the assignment strategies, used to select one in the node config
+const (
+	ASSIGNMENT_ROUND_ROBIN     = "round_robin"
+	ASSIGNMENT_LEAST_BACKLOG   = "least_backlog"
+	ASSIGNMENT_CONSISTENT_HASH = "consistent_hash"
+)
+
+var ASSIGNMENT_STRATEGIES = []string{
+	ASSIGNMENT_ROUND_ROBIN,
+	ASSIGNMENT_LEAST_BACKLOG,
+	ASSIGNMENT_CONSISTENT_HASH,
+}
+
+// Number of points that every node gets on the hash ring
+const consistentHashReplicas = 32
+
+// Decides which nodes build blocks out of the transactions in the backlog.
+type AssignmentStrategy interface {
+	// Returns the node to assign every transaction to. candidates is never empty. Transactions that
+	// are reassigned should go to a different node than before, if there is one.
+	Assign(txs []*Transaction, candidates []*Node) ([]*Node, error)
+}
+
+// Creates the assignment strategy with the given name for this blockchain.
+func (bc *Blockchain) NewAssignmentStrategy(name string) (AssignmentStrategy, error) {
+	switch name {
+	case ASSIGNMENT_ROUND_ROBIN:
+		return &RoundRobinAssignment{}, nil
+	case ASSIGNMENT_LEAST_BACKLOG:
+		return &LeastBacklogAssignment{db: bc.db}, nil
+	case ASSIGNMENT_CONSISTENT_HASH:
+		return &ConsistentHashAssignment{}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("Invalid assignment strategy: %s\n", name))
+	}
+}
+
+// --------------------------------
+// RoundRobinAssignment implementation
+//
+// Assigns transactions to the candidates in turn
+// --------------------------------
+
+type RoundRobinAssignment struct {
+	next uint64
+	lock sync.Mutex
+}
+
+func (s *RoundRobinAssignment) Assign(txs []*Transaction, candidates []*Node) ([]*Node, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	assignees := make([]*Node, len(txs))
+	for i, tx := range txs {
+		assignee := candidates[s.next%uint64(len(candidates))]
+		s.next++
+		if len(candidates) > 1 && bytes.Equal(assignee.PubKey, tx.AssignedTo) {
+			assignee = candidates[s.next%uint64(len(candidates))]
+			s.next++
+		}
+		assignees[i] = assignee
+	}
+	return assignees, nil
+}
+
+// --------------------------------
+// LeastBacklogAssignment implementation
+//
+// Assigns every transaction to the candidate with the fewest assigned transactions in the backlog
+// --------------------------------
+
+type LeastBacklogAssignment struct {
+	db meddb.BlockchainDB
+}
+
+func (s *LeastBacklogAssignment) Assign(txs []*Transaction, candidates []*Node) ([]*Node, error) {
+	counts, err := s.db.CountAssignedTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	assignees := make([]*Node, len(txs))
+	for i, tx := range txs {
+		if tx.AssignedTo != nil && counts[string(tx.AssignedTo)] > 0 {
+			// Reassigned transactions no longer count for their old assignee
+			counts[string(tx.AssignedTo)]--
+		}
+
+		var assignee *Node = nil
+		for _, node := range candidates {
+			if len(candidates) > 1 && bytes.Equal(node.PubKey, tx.AssignedTo) {
+				continue
+			}
+			// Ties go to the smallest public key, so that every node breaks them the same way
+			if assignee == nil || counts[string(node.PubKey)] < counts[string(assignee.PubKey)] ||
+				(counts[string(node.PubKey)] == counts[string(assignee.PubKey)] &&
+					bytes.Compare(node.PubKey, assignee.PubKey) < 0) {
+
+				assignee = node
+			}
+		}
+		counts[string(assignee.PubKey)]++
+		assignees[i] = assignee
+	}
+	return assignees, nil
+}
+
+// --------------------------------
+// ConsistentHashAssignment implementation
+//
+// Assigns every transaction to the candidate that follows the hash of the transaction on a hash
+// ring. The same transaction goes to the same node on every node, and only the transactions of a
+// node move when it joins or leaves. A reassigned transaction goes to the next node on the ring.
+// --------------------------------
+
+type ConsistentHashAssignment struct{}
+
+type ringPoint struct {
+	hash Hash
+	node *Node
+}
+
+func (s *ConsistentHashAssignment) Assign(txs []*Transaction,
+	candidates []*Node) ([]*Node, error) {
+
+	ring := make([]*ringPoint, 0, len(candidates)*consistentHashReplicas)
+	for _, node := range candidates {
+		for replica := 0; replica < consistentHashReplicas; replica++ {
+			replicaBytes := make([]byte, 8)
+			binary.BigEndian.PutUint64(replicaBytes, uint64(replica))
+			ring = append(ring, &ringPoint{
+				hash: rlpHash([][]byte{node.PubKey, replicaBytes}),
+				node: node,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return bytes.Compare(ring[i].hash.Bytes(), ring[j].hash.Bytes()) < 0
+	})
+
+	assignees := make([]*Node, len(txs))
+	for i, tx := range txs {
+		txHash := tx.Hash().Bytes()
+		j := sort.Search(len(ring), func(j int) bool {
+			return bytes.Compare(ring[j].hash.Bytes(), txHash) >= 0
+		})
+		// Reassigned transactions skip their old assignee
+		assignee := ring[j%len(ring)].node
+		for k := 1; k < len(ring) && bytes.Equal(assignee.PubKey, tx.AssignedTo); k++ {
+			assignee = ring[(j+k)%len(ring)].node
+		}
+		assignees[i] = assignee
+	}
+	return assignees, nil
+}
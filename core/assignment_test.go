@@ -0,0 +1,167 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// -------
+// Helpers
+// -------
+
+func getAssignmentNodes() []*Node {
+	return []*Node{
+		&Node{PubKey: []byte{42}},
+		&Node{PubKey: []byte{43}},
+		&Node{PubKey: []byte{44}},
+	}
+}
+
+func getAssignmentTransactions(n int) []*Transaction {
+	txs := make([]*Transaction, n)
+	for i := range txs {
+		txs[i] = &Transaction{
+			TableName:  []byte("cars"),
+			RowId:      []byte{byte(i)},
+			AssignedAt: big.NewInt(int64(i)),
+		}
+	}
+	return txs
+}
+
+// -----
+// Tests
+// -----
+
+func TestNewAssignmentStrategy(t *testing.T) {
+	bc := NewBlockchain(nil, nil, nil, nil)
+
+	for _, name := range ASSIGNMENT_STRATEGIES {
+		s, err := bc.NewAssignmentStrategy(name)
+		assert.Nil(t, err)
+		assert.NotNil(t, s)
+	}
+
+	_, err := bc.NewAssignmentStrategy("random")
+	assert.NotNil(t, err)
+}
+
+func TestRoundRobinAssignment(t *testing.T) {
+	nodes := getAssignmentNodes()
+	s := &RoundRobinAssignment{}
+
+	assignees, err := s.Assign(getAssignmentTransactions(4), nodes)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[0], nodes[1], nodes[2], nodes[0]}, assignees)
+
+	// Continues where the last batch stopped
+	assignees, err = s.Assign(getAssignmentTransactions(2), nodes)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[1], nodes[2]}, assignees)
+
+	// Skips the old assignee of reassigned transactions
+	txs := getAssignmentTransactions(1)
+	txs[0].AssignedTo = nodes[0].PubKey
+	assignees, err = s.Assign(txs, nodes)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[1]}, assignees)
+}
+
+func TestLeastBacklogAssignment(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	nodes := getAssignmentNodes()
+	backlog := getAssignmentTransactions(3)
+	backlog[0].AssignedTo = nodes[0].PubKey
+	backlog[1].AssignedTo = nodes[0].PubKey
+	backlog[2].AssignedTo = nodes[2].PubKey
+	for _, tx := range backlog {
+		assert.Nil(t, db.WriteTransaction(tx.toDBTransaction()))
+	}
+
+	s := &LeastBacklogAssignment{db: db}
+	txs := getAssignmentTransactions(4)
+	assignees, err := s.Assign(txs, nodes)
+	assert.Nil(t, err)
+	// Counts start at 2, 0, 1 and ties go to the smallest public key
+	assert.Equal(t, []*Node{nodes[1], nodes[1], nodes[2], nodes[0]}, assignees)
+
+	// Transactions that are reassigned go to another node and no longer count for their old
+	// assignee
+	assignees, err = s.Assign(backlog, nodes)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[1], nodes[1], nodes[0]}, assignees)
+}
+
+func TestConsistentHashAssignment(t *testing.T) {
+	nodes := getAssignmentNodes()
+	txs := getAssignmentTransactions(100)
+	s := &ConsistentHashAssignment{}
+
+	assignees, err := s.Assign(txs, nodes)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), len(assignees))
+
+	counts := make(map[string]int)
+	for _, node := range assignees {
+		counts[string(node.PubKey)]++
+	}
+	// Every node gets part of the transactions
+	assert.Equal(t, len(nodes), len(counts))
+
+	// Same assignment regardless of the order of the candidates
+	reversed := []*Node{nodes[2], nodes[1], nodes[0]}
+	again, err := s.Assign(txs, reversed)
+	assert.Nil(t, err)
+	assert.Equal(t, assignees, again)
+
+	// Reassigned transactions go to another node
+	reassigned := getAssignmentTransactions(100)
+	for i, tx := range reassigned {
+		tx.AssignedTo = assignees[i].PubKey
+	}
+	moved, err := s.Assign(reassigned, nodes)
+	assert.Nil(t, err)
+	for i := range txs {
+		assert.NotEqual(t, assignees[i], moved[i])
+	}
+
+	// Only transactions of the node that leaves are moved
+	remaining, err := s.Assign(txs, nodes[:2])
+	assert.Nil(t, err)
+	for i := range txs {
+		if assignees[i] != nodes[2] {
+			assert.Equal(t, assignees[i], remaining[i])
+		}
+	}
+}
+
+func TestAddTransactionsSkipsMe(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	nodes := getAssignmentNodes()
+	bc := NewBlockchain(db, nil, nodes[0], nodes)
+	for _, node := range nodes {
+		assert.Nil(t, NewBlockchain(db, nil, node, nodes).WriteHeartbeat())
+	}
+
+	txs := getAssignmentTransactions(4)
+	assert.Equal(t, []error{nil, nil, nil, nil}, bc.AddTransactions(txs))
+	for _, tx := range txs {
+		assert.NotEqual(t, nodes[0].PubKey, tx.AssignedTo)
+	}
+
+	// Only node in the federation, so it has to build blocks itself
+	bc = NewBlockchain(db, nil, nodes[0], nodes[:1])
+	assert.Nil(t, bc.AddTransaction(txs[0]))
+	assert.Equal(t, nodes[0].PubKey, txs[0].AssignedTo)
+
+	bc = NewBlockchain(db, nil, nil, nil)
+	assert.NotNil(t, bc.AddTransaction(txs[0]))
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"math/big"
 
 	"github.com/wojtechnology/glacier/meddb"
@@ -16,11 +17,14 @@ const (
 
 type Block struct {
 	Transactions []*Transaction // Contains hashes of all contained transactions
-	CreatedAt    *big.Int       // Time at which block was created, will be used to determine order
-	Creator      []byte
-	Sig          []byte
-	Voters       [][]byte
-	State        BlockState
+	// Number of blocks that existed before this block, as seen by its creator. Blocks are ordered
+	// by height and then by hash, which unlike time is the same on every node.
+	Height    *big.Int
+	CreatedAt *big.Int // Time at which block was created
+	Creator   []byte
+	Sig       []byte
+	Voters    [][]byte
+	State     BlockState
 }
 
 // ---------
@@ -29,6 +33,7 @@ type Block struct {
 
 type blockBody struct {
 	Creator      []byte
+	Height       *big.Int
 	Transactions []Hash // Contains hashes of all contained transactions
 	Voters       [][]byte
 }
@@ -40,13 +45,28 @@ func (b *Block) Hash() Hash {
 	}
 	return rlpHash(&blockBody{
 		Creator:      b.Creator,
+		Height:       b.Height,
 		Transactions: txs,
 		Voters:       b.Voters,
 	})
 }
 
+// Returns whether this block comes before the other block in the order of the blockchain.
+func (b *Block) IsBefore(other *Block) bool {
+	if c := compareBigInts(b.Height, other.Height); c != 0 {
+		return c < 0
+	}
+	return bytes.Compare(b.Hash().Bytes(), other.Hash().Bytes()) < 0
+}
+
 func (b *Block) toDBBlock() *meddb.Block {
-	var createdAt *big.Int = nil
+	var (
+		height    *big.Int = nil
+		createdAt *big.Int = nil
+	)
+	if b.Height != nil {
+		height = big.NewInt(b.Height.Int64())
+	}
 	if b.CreatedAt != nil {
 		createdAt = big.NewInt(b.CreatedAt.Int64())
 	}
@@ -63,6 +83,7 @@ func (b *Block) toDBBlock() *meddb.Block {
 	return &meddb.Block{
 		Hash:         b.Hash().Bytes(),
 		Transactions: txs,
+		Height:       height,
 		CreatedAt:    createdAt,
 		Creator:      b.Creator,
 		Sig:          b.Sig,
@@ -72,7 +93,13 @@ func (b *Block) toDBBlock() *meddb.Block {
 }
 
 func fromDBBlock(b *meddb.Block) *Block {
-	var createdAt *big.Int = nil
+	var (
+		height    *big.Int = nil
+		createdAt *big.Int = nil
+	)
+	if b.Height != nil {
+		height = big.NewInt(b.Height.Int64())
+	}
 	if b.CreatedAt != nil {
 		createdAt = big.NewInt(b.CreatedAt.Int64())
 	}
@@ -88,6 +115,7 @@ func fromDBBlock(b *meddb.Block) *Block {
 	// TODO(wojtek): Maybe make copies here
 	return &Block{
 		Transactions: txs,
+		Height:       height,
 		CreatedAt:    createdAt,
 		Creator:      b.Creator,
 		Sig:          b.Sig,
@@ -103,3 +131,18 @@ func fromDBBlocks(dbBs []*meddb.Block) []*Block {
 	}
 	return bs
 }
+
+// Compares big ints where nil comes before every number.
+func compareBigInts(x, y *big.Int) int {
+	if x == nil || y == nil {
+		switch {
+		case x == y:
+			return 0
+		case x == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return x.Cmp(y)
+}
@@ -13,6 +13,7 @@ func TestDBBlockMapper(t *testing.T) {
 	tx := &Transaction{TableName: []byte("cars")}
 	b := &Block{
 		Transactions: []*Transaction{tx},
+		Height:       big.NewInt(42),
 		CreatedAt:    big.NewInt(43),
 		Creator:      []byte{44},
 		Sig:          []byte{69},
@@ -21,6 +22,7 @@ func TestDBBlockMapper(t *testing.T) {
 	}
 	hash := rlpHash(&blockBody{
 		Creator:      b.Creator,
+		Height:       b.Height,
 		Transactions: []Hash{tx.Hash()},
 		Voters:       b.Voters,
 	})
@@ -32,6 +34,7 @@ func TestDBBlockMapper(t *testing.T) {
 			Hash:      txHash.Bytes(),
 			TableName: []byte("cars"),
 		}},
+		Height:    big.NewInt(42),
 		CreatedAt: big.NewInt(43),
 		Creator:   []byte{44},
 		Sig:       []byte{69},
@@ -58,3 +61,15 @@ func TestDBBlockMapperEmpty(t *testing.T) {
 	back := fromDBBlock(actual)
 	assert.Equal(t, b, back)
 }
+
+func TestBlockIsBefore(t *testing.T) {
+	low := &Block{Height: big.NewInt(1), Creator: []byte{1}}
+	high := &Block{Height: big.NewInt(2), Creator: []byte{2}}
+	assert.True(t, low.IsBefore(high))
+	assert.False(t, high.IsBefore(low))
+
+	// Same height is ordered by hash
+	other := &Block{Height: big.NewInt(1), Creator: []byte{3}}
+	assert.NotEqual(t, low.IsBefore(other), other.IsBefore(low))
+	assert.False(t, low.IsBefore(low))
+}
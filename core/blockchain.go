@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"math/big"
-	"math/rand"
+	"sync"
 
 	"github.com/wojtechnology/glacier/common"
 	"github.com/wojtechnology/glacier/crypto"
@@ -12,23 +12,33 @@ import (
 )
 
 type Blockchain struct {
-	db         meddb.BlockchainDB // Stores db data structures
-	bt         meddb.Bigtable     // Bigtable that stores cells
-	me         *Node              // This node
-	federation []*Node            // All other nodes in the network
-	// TODO: Federation lock
+	db             meddb.BlockchainDB // Stores db data structures
+	bt             meddb.Bigtable     // Bigtable that stores cells
+	me             *Node              // This node
+	federation     []*Node            // All nodes in the network, changes through transactions
+	federationLock sync.RWMutex
+	quorum         *Quorum            // Fraction of voters required to decide on a block
+	clock          func() int64       // Returns the current time in ms
+	assignment     AssignmentStrategy // Decides who builds blocks out of new transactions
+	// Age in ms after which a node without a newer heartbeat is considered dead
+	heartbeatStaleAgeMS int64
+	heartbeats          *heartbeatState // Heartbeats of the other nodes seen by LiveNodes
+	forks               *forkState      // Blocks and votes read by earlier fork checks
 }
 
-// Temp method, has hardcoded stuff
-func InitBlockchain(me *Node) (*Blockchain, error) {
-	addresses := []string{"localhost"}
-	database := "prod"
+// Connects to the databases at the given addresses and creates the blockchain of node me, which
+// is part of the given federation.
+func InitBlockchain(me *Node, federation []*Node, addresses []string,
+	database string) (*Blockchain, error) {
 
 	// Init db that contains meddb
-	db, err := meddb.NewRethinkBlockchainDB(addresses, database)
+	rethinkDB, err := meddb.NewRethinkBlockchainDB(addresses, database)
 	if err != nil {
 		return nil, err
 	}
+	// Accepted outputs never change, so validation does not have to read them again. Keyed outputs
+	// are replaced by their later versions, so those are always read.
+	db := newOutputCacheDB(rethinkDB)
 
 	// Init bigtable that contains cells
 	bt, err := meddb.NewRethinkBigtable(addresses, database)
@@ -36,13 +46,7 @@ func InitBlockchain(me *Node) (*Blockchain, error) {
 		return nil, err
 	}
 
-	bc := NewBlockchain(
-		db,
-		bt,
-		me,
-		[]*Node{me},
-	)
-	return bc, nil
+	return NewBlockchain(db, bt, me, federation), nil
 }
 
 func NewBlockchain(db meddb.BlockchainDB, bt meddb.Bigtable,
@@ -51,25 +55,82 @@ func NewBlockchain(db meddb.BlockchainDB, bt meddb.Bigtable,
 		db:         db,
 		bt:         bt,
 		me:         me,
-		federation: federation,
+		federation: append([]*Node{}, federation...), // Not shared with the caller
+		quorum:     DEFAULT_QUORUM,
+		clock:      common.Now,
+		assignment: &RoundRobinAssignment{},
+
+		heartbeatStaleAgeMS: DEFAULT_HEARTBEAT_STALE_AGE_MS,
+		heartbeats:          newHeartbeatState(),
+		forks:               newForkState(),
 	}
 }
 
+// Sets the fraction of voters required to decide on a block.
+func (bc *Blockchain) SetQuorum(q *Quorum) {
+	bc.quorum = q
+}
+
+// Sets the strategy that assigns new transactions to nodes.
+func (bc *Blockchain) SetAssignmentStrategy(s AssignmentStrategy) {
+	bc.assignment = s
+}
+
+// Sets the age in ms after which nodes without a newer heartbeat are not assigned transactions.
+func (bc *Blockchain) SetHeartbeatStaleAge(ageMS int64) {
+	bc.heartbeatStaleAgeMS = ageMS
+}
+
+// Sets the clock used for all timestamps created by this node, i.e. to simulate clock skew.
+func (bc *Blockchain) SetClock(clock func() int64) {
+	bc.clock = clock
+}
+
 // --------------
 // Blockchain API
 // --------------
 
 // Adds transaction to blockchain backlog.
 func (bc *Blockchain) AddTransaction(tx *Transaction) error {
-	now := common.Now()
-	tx.AssignedTo = bc.randomAssignee(now).PubKey
-	tx.AssignedAt = big.NewInt(now)
+	return bc.AddTransactions([]*Transaction{tx})[0]
+}
 
-	if err := bc.db.WriteTransaction(tx.toDBTransaction()); err != nil {
-		return err
+// Assigns transactions and adds them to blockchain backlog in one batch.
+// Returns an error for every transaction, nil when adding that transaction is successful. If the
+// batch write fails for some of the transactions only, the others are added. If it fails as a
+// whole, none of the transactions are assumed to be added.
+func (bc *Blockchain) AddTransactions(txs []*Transaction) []error {
+	errs := make([]error, len(txs))
+	if len(txs) == 0 {
+		return errs
 	}
 
-	return nil
+	assignees, err := bc.assign(txs)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	now := bc.clock()
+	dbTxs := make([]*meddb.Transaction, len(txs))
+	for i, tx := range txs {
+		tx.AssignedTo = assignees[i].PubKey
+		tx.AssignedAt = big.NewInt(now)
+		dbTxs[i] = tx.toDBTransaction()
+	}
+
+	if err := bc.db.WriteTransactions(dbTxs); err != nil {
+		if batchErr, ok := err.(*meddb.BatchWriteError); ok && len(batchErr.Errors) == len(errs) {
+			copy(errs, batchErr.Errors)
+			return errs
+		}
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	return errs
 }
 
 // Returns list of transactions currently assigned to this node in the backlog.
@@ -83,7 +144,7 @@ func (bc *Blockchain) GetMyTransactions() ([]*Transaction, error) {
 
 // Returns list of transactions that are at least staleAge old from backlog.
 func (bc *Blockchain) GetStaleTransactions(staleAge int64) ([]*Transaction, error) {
-	dbTxs, err := bc.db.GetStaleTransactions(common.Now() - staleAge)
+	dbTxs, err := bc.db.GetStaleTransactions(bc.clock() - staleAge)
 	if err != nil {
 		return nil, err
 	}
@@ -91,96 +152,14 @@ func (bc *Blockchain) GetStaleTransactions(staleAge int64) ([]*Transaction, erro
 }
 
 // Validates transaction.
-// Returns nil when validation is successful, returns error with reason otherwise.
+// Returns nil when validation is successful, returns error with reason otherwise. Errors from
+// reading the databases are returned as they are, see ValidateTransactions.
 func (bc *Blockchain) ValidateTransaction(tx *Transaction) error {
-	outputReqs := map[string]OutputRequirement{}
-	for _, input := range tx.Inputs {
-		// Linked outputs are required
-		outputReqs[input.OutputHash().String()] = OUTPUT_REQUIREMENT_REQUIRED
-	}
-
-	ruleset, err := tx.GetRuleset()
+	errs, err := bc.ValidateTransactions([]*Transaction{tx})
 	if err != nil {
 		return err
 	}
-	for _, rule := range ruleset {
-		ruleOutputReqs := rule.RequestedOutputIds(tx)
-		for outputStrId, outputReq := range ruleOutputReqs {
-			// We want the strictest requirement in the map
-			if oldOutputReq, ok := outputReqs[outputStrId]; !ok || outputReq > oldOutputReq {
-				outputReqs[outputStrId] = outputReq
-			}
-		}
-	}
-
-	i := 0
-	outputIds := make([][]byte, len(outputReqs))
-	for outputStrId, _ := range outputReqs {
-		outputIds[i] = []byte(outputStrId)
-		i++
-	}
-
-	// Gets all required outputs from database.
-	outputResponses, err := bc.db.GetOutputs(outputIds)
-	if err != nil {
-		return err
-	}
-
-	// TODO: Replace with transaction level caching of hash
-	txHash := tx.Hash().Bytes()
-
-	// Get the state of all outputs.
-	acceptedOutputs := make(map[string]Output)
-	undecidedOutputs := make(map[string]Output)
-	for _, outputRes := range outputResponses {
-		// Want to ignore outputs from the same transaction
-		if !bytes.Equal(txHash, outputRes.Transaction.Hash) {
-			dbOutput := outputRes.Output
-			output, err := NewOutput(OutputType(dbOutput.Type), dbOutput.Data)
-			// TODO: Probably just ignore the output here.
-			if err != nil {
-				return err
-			}
-			switch BlockState(outputRes.Block.State) {
-			case BLOCK_STATE_UNDECIDED:
-				undecidedOutputs[HashOutput(output).String()] = output
-			case BLOCK_STATE_ACCEPTED:
-				acceptedOutputs[HashOutput(output).String()] = output
-			}
-		}
-	}
-
-	// Look at output requirements and make sure that they are met.
-	// The strategy for this is optimisitic. I.E. if there exists an accepted and undecided version
-	// of some output, it will take the accepted version.
-	undecidedOutputIds := make([][]byte, 0)
-	rejectedOutputIds := make([][]byte, 0) // rejected or missing
-	for _, outputId := range outputIds {
-		outputStr := string(outputId)
-		if _, ok := acceptedOutputs[outputStr]; !ok {
-			req := outputReqs[outputStr]
-			if _, undecidedOk := undecidedOutputs[outputStr]; undecidedOk {
-				// At least as strict as DECIDED
-				if req >= OUTPUT_REQUIREMENT_DECIDED {
-					undecidedOutputIds = append(undecidedOutputIds, outputId)
-				}
-			} else {
-				// At least as strict as REQUIRED
-				if req >= OUTPUT_REQUIREMENT_REQUIRED {
-					rejectedOutputIds = append(rejectedOutputIds, outputId)
-				}
-			}
-		}
-	}
-
-	if len(rejectedOutputIds) > 0 { // rejected or missing and were required
-		return &MissingOutputsError{OutputIds: rejectedOutputIds}
-	} else if len(undecidedOutputIds) > 0 { // in undecided block but was required to be decided
-		return &UndecidedOutputsError{OutputIds: undecidedOutputIds}
-	}
-
-	// No currency yet, so spentInputs is empty
-	return tx.Validate(acceptedOutputs, map[string][]Input{})
+	return errs[0]
 }
 
 // Proxy to db to delete transactions from backlog.
@@ -204,36 +183,56 @@ func (bc *Blockchain) GetMyTransactionChangefeed() (*TransactionChangeCursor, er
 // Creates the genesis block which contains one transaction with the message in GENESIS_MESSAGE.
 // Generally this message should be some string that could not have been created before the date
 // of blockchain genesis.
+// The transaction also has a NODE output for every node of the initial federation, so that they
+// can sign changes to the federation.
 func (bc *Blockchain) BuildGenesis() (*Block, error) {
+	federation := bc.Federation()
+	outputs := make([]Output, len(federation))
+	for i, node := range federation {
+		outputs[i] = &NodeOutput{TableNameMixin: &TableNameMixin{}, PubKey: node.PubKey}
+	}
+
 	genTx := &Transaction{
 		Type: TransactionType(-1), // Reserved type should never be used for other transactions,
 		// although not a big deal if it does.
 		Cols: map[string]*Cell{
 			"message": &Cell{Data: []byte(GENESIS_MESSAGE)},
 		},
+		Outputs: outputs,
 	}
 
-	return bc.BuildBlock([]*Transaction{genTx})
+	// Genesis is always the first block
+	return bc.buildBlock([]*Transaction{genTx}, 0)
 }
 
-// Builds block from given transactions.
+// Builds block from given transactions, on top of the highest block in the blocks table.
+// Transactions are sorted by their hashes, see ValidateBlock.
 // DOES NOT VALIDATE TRANSACTIONS. That must be done before.
 func (bc *Blockchain) BuildBlock(txs []*Transaction) (*Block, error) {
+	maxHeight, err := bc.db.GetMaxBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	return bc.buildBlock(txs, maxHeight+1)
+}
+
+func (bc *Blockchain) buildBlock(txs []*Transaction, height int64) (*Block, error) {
 	if len(txs) == 0 {
 		// TODO: Raise error here, should never be called with zero transactions
 		return nil, errors.New("Cannot build block with zero transactions")
 	}
 
-	// TODO: Lock
-	voters := make([][]byte, len(bc.federation))
-	for i, node := range bc.federation {
+	federation := bc.Federation()
+	voters := make([][]byte, len(federation))
+	for i, node := range federation {
 		voters[i] = node.PubKey
 	}
 
 	// Create block out of transactions
 	b := &Block{
-		Transactions: txs,
-		CreatedAt:    big.NewInt(common.Now()),
+		Transactions: sortByHash(txs),
+		Height:       big.NewInt(height),
+		CreatedAt:    big.NewInt(bc.clock()),
 		Creator:      bc.me.PubKey,
 		Voters:       voters,
 	}
@@ -250,7 +249,10 @@ func (bc *Blockchain) BuildBlock(txs []*Transaction) (*Block, error) {
 
 // Validates block.
 // Checks whether the signature of the block is valid.
-// Checks whether the transactions within the block are valid.
+// Checks whether the height of the block is above the height of every decided block.
+// Checks whether the transactions within the block are sorted by their hashes, which is the order
+// they are applied in, and whether at most one of them spends each output.
+// Checks whether the transactions within the block are valid, all in one batch.
 func (bc *Blockchain) ValidateBlock(b *Block) error {
 	// Check whether signature is valid
 	pubKey, err := crypto.RetrievePublicKey(b.Hash().Bytes(), b.Sig)
@@ -262,10 +264,28 @@ func (bc *Blockchain) ValidateBlock(b *Block) error {
 		return &BlockSignatureInvalidError{BlockId: b.Hash()}
 	}
 
-	// Check whether transactions are valid
+	if err := bc.validateHeight(b); err != nil {
+		return err
+	}
+
+	if !isSortedByHash(b.Transactions) {
+		return &BlockTransactionsUnorderedError{BlockId: b.Hash()}
+	}
+
+	// Inputs of the block itself are not in the database yet when the block is validated by its
+	// creator, so conflicts within the block are found here rather than by getSpentInputs
+	if errs := getBlockConflicts(b.Transactions); len(errs) > 0 {
+		return &TransactionErrors{BlockId: b.Hash(), Errors: errs}
+	}
+
+	// Check whether transactions are valid. Errors from reading the databases say nothing about
+	// the block, so those are not turned into TransactionErrors.
+	txErrs, err := bc.validateTransactions(b.Transactions, b)
+	if err != nil {
+		return err
+	}
 	errs := make([]error, 0)
-	for _, tx := range b.Transactions {
-		err := bc.ValidateTransaction(tx)
+	for _, err := range txErrs {
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -298,6 +318,11 @@ func (bc *Blockchain) GetBlocks(blockIds []Hash) ([]*Block, error) {
 	return fromDBBlocks(dbBs), nil
 }
 
+// Updates the state of the block with the given blockId in blocks table.
+func (bc *Blockchain) UpdateBlockState(blockId Hash, state BlockState) error {
+	return bc.db.UpdateBlockState(blockId.Bytes(), int(state))
+}
+
 // Returns `limit` blocks from blocks table starting at given timestamp, sorted by increasing
 // CreatedAt.
 func (bc *Blockchain) GetOldestBlocks(after int64, limit int) ([]*Block, error) {
@@ -308,6 +333,16 @@ func (bc *Blockchain) GetOldestBlocks(after int64, limit int) ([]*Block, error)
 	return fromDBBlocks(dbBs), nil
 }
 
+// Returns `limit` blocks from blocks table that come after the block with the given height and
+// blockId in the order of the blockchain. Passing a height of -1 starts at the first block.
+func (bc *Blockchain) GetBlocksAfter(height int64, blockId Hash, limit int) ([]*Block, error) {
+	dbBs, err := bc.db.GetBlocksAfter(height, blockId.Bytes(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromDBBlocks(dbBs), nil
+}
+
 func (bc *Blockchain) GetBlockChangefeed() (*BlockChangeCursor, error) {
 	changefeed, err := bc.db.GetBlockChangefeed()
 	if err != nil {
@@ -320,7 +355,7 @@ func (bc *Blockchain) GetBlockChangefeed() (*BlockChangeCursor, error) {
 func (bc *Blockchain) BuildVote(blockId, prevBlockId Hash, value bool) (*Vote, error) {
 	v := &Vote{
 		Voter:     bc.me.PubKey,
-		VotedAt:   big.NewInt(common.Now()),
+		VotedAt:   big.NewInt(bc.clock()),
 		PrevBlock: prevBlockId,
 		NextBlock: blockId,
 		Value:     value,
@@ -336,6 +371,41 @@ func (bc *Blockchain) BuildVote(blockId, prevBlockId Hash, value bool) (*Vote, e
 	return v, nil
 }
 
+// Validates vote on the given block.
+// Checks whether the voter is one of the voters of the block.
+// Checks whether the vote was signed by the voter.
+func (bc *Blockchain) ValidateVote(b *Block, v *Vote) error {
+	if !isVoter(b, v.Voter) {
+		return &VoterNotEligibleError{BlockId: b.Hash(), Voter: v.Voter}
+	}
+
+	pubKey, err := crypto.RetrievePublicKey(v.Hash().Bytes(), v.Sig)
+	if err != nil || !bytes.Equal(pubKey, v.Voter) {
+		return &VoteSignatureInvalidError{VoteHash: v.Hash()}
+	}
+
+	return nil
+}
+
+// Splits the given votes on the block into the valid votes and offenses for the invalid ones.
+// Votes on other blocks are left out of both.
+func (bc *Blockchain) FilterValidVotes(b *Block, vs []*Vote) ([]*Vote, []*VoteOffense) {
+	blockId := b.Hash()
+	valid := make([]*Vote, 0, len(vs))
+	offenses := make([]*VoteOffense, 0)
+	for _, v := range vs {
+		if v.NextBlock != blockId {
+			continue
+		}
+		if err := bc.ValidateVote(b, v); err != nil {
+			offenses = append(offenses, bc.NewVoteOffense(v, err))
+			continue
+		}
+		valid = append(valid, v)
+	}
+	return valid, offenses
+}
+
 // Writes vote to vote table.
 // Assumes vote is already signed.
 func (bc *Blockchain) WriteVote(v *Vote) error {
@@ -367,6 +437,35 @@ func (bc *Blockchain) GetRecentVotes() ([]*Vote, error) {
 	return fromDBVotes(dbVs), nil
 }
 
+// Returns all of the votes cast on the block with the given blockId.
+func (bc *Blockchain) GetBlockVotes(blockId Hash) ([]*Vote, error) {
+	dbVs, err := bc.db.GetBlockVotes(blockId.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return fromDBVotes(dbVs), nil
+}
+
+// Counts the given votes on the block using the quorum of this blockchain and returns the state
+// that the block should be in.
+func (bc *Blockchain) TallyVotes(b *Block, vs []*Vote) BlockState {
+	return TallyVotes(b, vs, bc.quorum)
+}
+
+// Returns whether this node has voted on the block with the given blockId.
+func (bc *Blockchain) HasVoted(blockId Hash) (bool, error) {
+	vs, err := bc.GetBlockVotes(blockId)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range vs {
+		if bytes.Equal(v.Voter, bc.me.PubKey) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (bc *Blockchain) GetVoteChangefeed() (*VoteChangeCursor, error) {
 	changefeed, err := bc.db.GetVoteChangefeed()
 	if err != nil {
@@ -375,12 +474,131 @@ func (bc *Blockchain) GetVoteChangefeed() (*VoteChangeCursor, error) {
 	return &VoteChangeCursor{changefeed: changefeed}, nil
 }
 
+// Reads cells from the given table in bigtable.
+func (bc *Blockchain) GetCells(tableName []byte, op *meddb.GetOp) (map[string][]*Cell, error) {
+	dbCells, err := bc.bt.Get(tableName, op)
+	if err != nil {
+		return nil, err
+	}
+
+	cells := make(map[string][]*Cell)
+	for colId, dbCol := range dbCells {
+		cells[colId] = make([]*Cell, len(dbCol))
+		for i, dbCell := range dbCol {
+			cells[colId][i] = fromDBCell(dbCell)
+		}
+	}
+	return cells, nil
+}
+
+// Row of a table in bigtable
+type Row struct {
+	RowId []byte
+	Cols  map[string][]*Cell
+}
+
+// Reads a page of rows from the given table in bigtable.
+// Returns the rows and the token of the next page, nil if this is the last page.
+func (bc *Blockchain) ScanRows(tableName []byte, op *meddb.ScanOp) ([]*Row, []byte, error) {
+	res, err := bc.bt.Scan(tableName, op)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]*Row, len(res.Rows))
+	for i, dbRow := range res.Rows {
+		rows[i] = &Row{RowId: dbRow.RowId, Cols: make(map[string][]*Cell)}
+		for colId, dbCol := range dbRow.Cols {
+			rows[i].Cols[colId] = make([]*Cell, len(dbCol))
+			for j, dbCell := range dbCol {
+				rows[i].Cols[colId][j] = fromDBCell(dbCell)
+			}
+		}
+	}
+	return rows, res.NextPageToken, nil
+}
+
 // -------
 // Helpers
 // -------
 
-// Returns a random node to assign a transaction to that is not this node.
-func (bc *Blockchain) randomAssignee(seed int64) *Node {
-	rand.Seed(seed)
-	return bc.federation[rand.Intn(len(bc.federation))]
+// Wraps db in the cache of accepted outputs that validation reads through.
+func newOutputCacheDB(db meddb.BlockchainDB) *meddb.OutputCacheDB {
+	return meddb.NewOutputCacheDB(db, OUTPUT_CACHE_SIZE, int(BLOCK_STATE_ACCEPTED),
+		int(OUTPUT_TYPE_COL_SCHEMA))
+}
+
+// Returns BlockHeightInvalidError if the block has no height or is not above the highest decided
+// block. ApplyBlocks moves its cursor past decided blocks in the order of the blockchain, so a
+// block written later with a lower height would never be applied. The block is written before it
+// is validated, so once a decided block has passed its position, every voter rejects it.
+func (bc *Blockchain) validateHeight(b *Block) error {
+	if b.Height == nil {
+		return &BlockHeightInvalidError{BlockId: b.Hash()}
+	}
+
+	maxHeight, err := bc.db.GetMaxBlockHeightNotInState(int(BLOCK_STATE_UNDECIDED))
+	if err != nil {
+		return err
+	}
+	if b.Height.Int64() <= maxHeight {
+		return &BlockHeightInvalidError{BlockId: b.Hash(), Height: b.Height, MaxHeight: maxHeight}
+	}
+	return nil
+}
+
+// Returns the node that every transaction is assigned to by the assignment strategy.
+func (bc *Blockchain) assign(txs []*Transaction) ([]*Node, error) {
+	candidates, err := bc.assignmentCandidates()
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("No nodes to assign transactions to\n")
+	}
+	return bc.assignment.Assign(txs, candidates)
+}
+
+// Returns the nodes that transactions can be assigned to, which are the live nodes in the
+// federation except for this node. If no other node is live, this node builds the blocks itself.
+// If this node is not part of the federation either, all nodes in the federation are candidates.
+func (bc *Blockchain) assignmentCandidates() ([]*Node, error) {
+	live, err := bc.LiveNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	federation := bc.Federation()
+	var me *Node = nil
+	if bc.me != nil {
+		for _, node := range federation {
+			if bytes.Equal(node.PubKey, bc.me.PubKey) {
+				me = node
+			}
+		}
+	}
+
+	liveOthers := excludeNode(live, me)
+	if len(liveOthers) > 0 {
+		return liveOthers, nil
+	}
+	if me != nil {
+		return []*Node{me}, nil
+	}
+	return federation, nil
+}
+
+// Returns the given nodes without node.
+func excludeNode(nodes []*Node, node *Node) []*Node {
+	if node == nil {
+		return nodes
+	}
+
+	others := make([]*Node, 0, len(nodes))
+	for _, other := range nodes {
+		if !bytes.Equal(other.PubKey, node.PubKey) {
+			others = append(others, other)
+		}
+	}
+	return others
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
@@ -11,6 +12,16 @@ import (
 	"github.com/wojtechnology/glacier/meddb"
 )
 
+// Fails all writes of transactions to the backlog
+type failingWriteDB struct {
+	meddb.BlockchainDB
+	err error
+}
+
+func (db *failingWriteDB) WriteTransactions(txs []*meddb.Transaction) error {
+	return db.err
+}
+
 func assertRecent(t *testing.T, x int64) {
 	assert.True(t, x >= common.Now()-1000)
 	assert.True(t, x <= common.Now())
@@ -45,6 +56,58 @@ func TestAddTransaction(t *testing.T) {
 	assert.Equal(t, tx, fromDBTransaction(txs[0]))
 }
 
+func TestAddTransactions(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	other := &Node{PubKey: []byte{69}}
+	txs := make([]*Transaction, 3)
+	for i := range txs {
+		txs[i] = &Transaction{TableName: []byte{123}, RowId: []byte{byte(i)}}
+	}
+
+	bc := NewBlockchain(db, nil, nil, []*Node{other})
+
+	errs := bc.AddTransactions(txs)
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	for _, tx := range txs {
+		assert.Equal(t, other.PubKey, tx.AssignedTo)
+		assertRecent(t, tx.AssignedAt.Int64())
+	}
+
+	dbTxs, err := db.GetAssignedTransactions(other.PubKey)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(dbTxs))
+	assert.Subset(t, txs, fromDBTransactions(dbTxs))
+
+	assert.Equal(t, []error{}, bc.AddTransactions([]*Transaction{}))
+}
+
+func TestAddTransactionsWriteFails(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	writeErr := errors.New("write failed")
+	bc := NewBlockchain(&failingWriteDB{db, writeErr}, nil, nil, []*Node{&Node{PubKey: []byte{69}}})
+
+	txs := []*Transaction{&Transaction{RowId: []byte{1}}, &Transaction{RowId: []byte{2}}}
+	assert.Equal(t, []error{writeErr, writeErr}, bc.AddTransactions(txs))
+	assert.Equal(t, writeErr, bc.AddTransaction(txs[0]))
+}
+
+func TestAddTransactionsWritePartiallyFails(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	writeErr := errors.New("write failed")
+	batchErr := &meddb.BatchWriteError{Errors: []error{nil, writeErr}}
+	bc := NewBlockchain(&failingWriteDB{db, batchErr}, nil, nil,
+		[]*Node{&Node{PubKey: []byte{69}}})
+
+	txs := []*Transaction{&Transaction{RowId: []byte{1}}, &Transaction{RowId: []byte{2}}}
+	assert.Equal(t, []error{nil, writeErr}, bc.AddTransactions(txs))
+}
+
 func TestGetMyTransactions(t *testing.T) {
 	db, err := meddb.NewMemoryBlockchainDB()
 	assert.Nil(t, err)
@@ -152,6 +215,9 @@ func TestBuildGenesis(t *testing.T) {
 		Cols: map[string]*Cell{
 			"message": &Cell{Data: []byte(GENESIS_MESSAGE)},
 		},
+		Outputs: []Output{
+			&NodeOutput{TableNameMixin: &TableNameMixin{}, PubKey: me.PubKey},
+		},
 	}
 	txs := []*Transaction{tx}
 	assert.Equal(t, txs, b.Transactions)
@@ -186,7 +252,15 @@ func TestBuildBlock(t *testing.T) {
 	assert.Equal(t, [][]byte{other.PubKey}, b.Voters)
 	assert.Equal(t, sig, b.Sig)
 	assert.Equal(t, BLOCK_STATE_UNDECIDED, b.State)
+	assert.Equal(t, big.NewInt(0), b.Height)
 	assertRecent(t, b.CreatedAt.Int64())
+
+	err = bc.WriteBlock(b)
+	assert.Nil(t, err)
+	next, err := bc.BuildBlock(txs)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1), next.Height)
+	assert.NotEqual(t, b.Hash(), next.Hash())
 }
 
 func TestWriteBlock(t *testing.T) {
@@ -222,6 +296,25 @@ func TestGetBlocks(t *testing.T) {
 	assert.Equal(t, b, bs[0])
 }
 
+func TestUpdateBlockState(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	b := &Block{Transactions: []*Transaction{&Transaction{TableName: []byte{123}}}}
+	err = db.WriteBlock(b.toDBBlock())
+	assert.Nil(t, err)
+
+	bc := NewBlockchain(db, nil, nil, nil)
+
+	err = bc.UpdateBlockState(b.Hash(), BLOCK_STATE_ACCEPTED)
+	assert.Nil(t, err)
+
+	bs, err := db.GetBlocks([][]byte{b.Hash().Bytes()})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(bs))
+	assert.Equal(t, BLOCK_STATE_ACCEPTED, BlockState(bs[0].State))
+}
+
 func TestValidSignedBlock(t *testing.T) {
 	db, err := meddb.NewMemoryBlockchainDB()
 	assert.Nil(t, err)
@@ -244,6 +337,88 @@ func TestValidSignedBlock(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestValidateBlockHeight(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	me := NewNode(priv)
+	bc := NewBlockchain(db, nil, me, []*Node{me})
+
+	txs := []*Transaction{&Transaction{TableName: []byte{1}, Outputs: []Output{
+		&TableExistsOutput{&TableNameMixin{}},
+	}}}
+	decided, err := bc.buildBlock(txs, 3)
+	assert.Nil(t, err)
+	decided.State = BLOCK_STATE_REJECTED
+	assert.Nil(t, bc.WriteBlock(decided))
+	undecided, err := bc.buildBlock(txs, 5)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.WriteBlock(undecided))
+
+	// Blocks above the highest decided block are valid, even below undecided blocks
+	for _, height := range []int64{4, 5, 6} {
+		b, err := bc.buildBlock(txs, height)
+		assert.Nil(t, err)
+		assert.Nil(t, bc.ValidateBlock(b))
+	}
+
+	// Blocks written late would sort before decided blocks
+	for _, height := range []int64{0, 3} {
+		b, err := bc.buildBlock(txs, height)
+		assert.Nil(t, err)
+		err = bc.ValidateBlock(b)
+		assert.Equal(t, &BlockHeightInvalidError{
+			BlockId:   b.Hash(),
+			Height:    big.NewInt(height),
+			MaxHeight: 3,
+		}, err)
+	}
+
+	// Blocks without height are never applied
+	b, err := bc.buildBlock(txs, 4)
+	assert.Nil(t, err)
+	b.Height = nil
+	b.Sig, err = crypto.Sign(b.Hash().Bytes(), priv)
+	assert.Nil(t, err)
+	err = bc.ValidateBlock(b)
+	assert.Equal(t, &BlockHeightInvalidError{BlockId: b.Hash()}, err)
+}
+
+func TestValidateBlockUnordered(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	me := NewNode(priv)
+	bc := NewBlockchain(db, nil, me, []*Node{me})
+
+	txs := make([]*Transaction, 3)
+	for i := range txs {
+		txs[i] = &Transaction{TableName: []byte{byte(i)}, Outputs: []Output{
+			&TableExistsOutput{&TableNameMixin{[]byte{byte(i)}}},
+		}}
+	}
+	b, err := bc.BuildBlock(txs)
+	assert.Nil(t, err)
+	assert.Equal(t, sortByHash(txs), b.Transactions)
+	assert.Nil(t, bc.ValidateBlock(b))
+
+	// Built by another creator in a different order
+	b.Transactions[0], b.Transactions[1] = b.Transactions[1], b.Transactions[0]
+	b.Sig, err = crypto.Sign(b.Hash().Bytes(), priv)
+	assert.Nil(t, err)
+	assert.Equal(t, &BlockTransactionsUnorderedError{BlockId: b.Hash()}, bc.ValidateBlock(b))
+
+	// Same transaction twice
+	b.Transactions[0] = b.Transactions[1]
+	b.Sig, err = crypto.Sign(b.Hash().Bytes(), priv)
+	assert.Nil(t, err)
+	assert.Equal(t, &BlockTransactionsUnorderedError{BlockId: b.Hash()}, bc.ValidateBlock(b))
+}
+
 func TestGetOldestBlocks(t *testing.T) {
 	db, err := meddb.NewMemoryBlockchainDB()
 	assert.Nil(t, err)
@@ -276,6 +451,28 @@ func TestGetOldestBlocks(t *testing.T) {
 	assert.Equal(t, otherB2, bs[1])
 }
 
+func TestGetBlocksAfter(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	bs := make([]*Block, 3)
+	for i := range bs {
+		bs[i] = &Block{Height: big.NewInt(int64(i)), CreatedAt: big.NewInt(int64(10 - i))}
+		err = db.WriteBlock(bs[i].toDBBlock())
+		assert.Nil(t, err)
+	}
+
+	bc := NewBlockchain(db, nil, nil, nil)
+
+	res, err := bc.GetBlocksAfter(-1, Hash{}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, bs[:2], res)
+
+	res, err = bc.GetBlocksAfter(1, bs[1].Hash(), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, bs[2:], res)
+}
+
 func TestBuildVote(t *testing.T) {
 	db, err := meddb.NewMemoryBlockchainDB()
 	assert.Nil(t, err)
@@ -303,6 +500,95 @@ func TestBuildVote(t *testing.T) {
 	assertRecent(t, v.VotedAt.Int64())
 }
 
+func TestValidateVote(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	voterPriv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	otherPriv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	voter, other := NewNode(voterPriv), NewNode(otherPriv)
+
+	b := &Block{Voters: [][]byte{voter.PubKey}}
+	bc := NewBlockchain(db, nil, voter, []*Node{voter})
+	v, err := bc.BuildVote(b.Hash(), StringToHash("prev"), true)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.ValidateVote(b, v))
+
+	// Signed by someone else
+	forged := *v
+	forged.Sig, err = crypto.Sign(v.Hash().Bytes(), otherPriv)
+	assert.Nil(t, err)
+	assert.Equal(t, &VoteSignatureInvalidError{VoteHash: v.Hash()}, bc.ValidateVote(b, &forged))
+
+	// Signature of a different vote
+	flipped := *v
+	flipped.Value = false
+	assert.Equal(t, &VoteSignatureInvalidError{VoteHash: flipped.Hash()},
+		bc.ValidateVote(b, &flipped))
+
+	// Properly signed, but not a voter of the block
+	otherBc := NewBlockchain(db, nil, other, []*Node{voter})
+	otherV, err := otherBc.BuildVote(b.Hash(), StringToHash("prev"), true)
+	assert.Nil(t, err)
+	assert.Equal(t, &VoterNotEligibleError{BlockId: b.Hash(), Voter: other.PubKey},
+		bc.ValidateVote(b, otherV))
+}
+
+func TestFilterValidVotes(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	me := NewNode(priv)
+
+	b := &Block{Voters: [][]byte{me.PubKey}}
+	bc := NewBlockchain(db, nil, me, []*Node{me})
+	v, err := bc.BuildVote(b.Hash(), StringToHash("prev"), true)
+	assert.Nil(t, err)
+	otherBlockV, err := bc.BuildVote(StringToHash("other"), StringToHash("prev"), true)
+	assert.Nil(t, err)
+	forged := *v
+	forged.Value = false
+
+	valid, offenses := bc.FilterValidVotes(b, []*Vote{v, otherBlockV, &forged})
+	assert.Equal(t, []*Vote{v}, valid)
+	assert.Equal(t, 1, len(offenses))
+	assert.Equal(t, forged.Hash(), offenses[0].VoteHash)
+	assert.Equal(t, me.PubKey, offenses[0].Voter)
+	assert.Equal(t, b.Hash(), offenses[0].BlockId)
+	assert.Equal(t, "VoteSignatureInvalidError", offenses[0].ErrorType)
+	assert.Equal(t, me.PubKey, offenses[0].Node)
+	assertRecent(t, offenses[0].RecordedAt.Int64())
+
+	// Ignored votes do not count towards the quorum
+	assert.Equal(t, BLOCK_STATE_ACCEPTED, bc.TallyVotes(b, valid))
+}
+
+func TestWriteVoteOffenses(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	me := &Node{PubKey: []byte{69}}
+	bc := NewBlockchain(db, nil, me, nil)
+
+	v := &Vote{Voter: []byte{70}, NextBlock: StringToHash("2")}
+	o := bc.NewVoteOffense(v, &VoterNotEligibleError{BlockId: v.NextBlock, Voter: v.Voter})
+	err = bc.WriteVoteOffenses([]*VoteOffense{o})
+	assert.Nil(t, err)
+
+	res, err := bc.GetVoteOffenses([]byte{70})
+	assert.Nil(t, err)
+	assert.Equal(t, []*VoteOffense{o}, res)
+	assert.Equal(t, "VoterNotEligibleError", res[0].ErrorType)
+
+	res, err = bc.GetVoteOffenses(me.PubKey)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
 func TestWriteVote(t *testing.T) {
 	db, err := meddb.NewMemoryBlockchainDB()
 	assert.Nil(t, err)
@@ -426,13 +712,122 @@ func TestGetRecentVotesEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(vs))
 }
 
-func TestRandomAssignee(t *testing.T) {
-	node := &Node{PubKey: []byte{42}}
-	otherNode := &Node{PubKey: []byte{43}}
+func TestGetBlockVotes(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
 
-	bc := &Blockchain{federation: []*Node{node, otherNode}}
-	randNode := bc.randomAssignee(0)
-	assert.Equal(t, node, randNode)
-	randNode = bc.randomAssignee(1)
-	assert.Equal(t, otherNode, randNode)
+	var (
+		v1 = &Vote{
+			PrevBlock: StringToHash("1"),
+			NextBlock: StringToHash("2"),
+			Voter:     []byte{69},
+		}
+		v2 = &Vote{
+			PrevBlock: StringToHash("1"),
+			NextBlock: StringToHash("2"),
+			Voter:     []byte{70},
+		}
+		v3 = &Vote{
+			PrevBlock: StringToHash("2"),
+			NextBlock: StringToHash("3"),
+			Voter:     []byte{69},
+		}
+	)
+	err = db.WriteVote(v1.toDBVote())
+	assert.Nil(t, err)
+	err = db.WriteVote(v2.toDBVote())
+	assert.Nil(t, err)
+	err = db.WriteVote(v3.toDBVote())
+	assert.Nil(t, err)
+
+	bc := NewBlockchain(db, nil, nil, nil)
+
+	vs, err := bc.GetBlockVotes(StringToHash("2"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(vs))
+	expected := []*Vote{v1, v2}
+	assert.Subset(t, expected, vs)
+	assert.Subset(t, vs, expected)
+}
+
+func TestHasVoted(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	v := &Vote{
+		PrevBlock: StringToHash("1"),
+		NextBlock: StringToHash("2"),
+		Voter:     []byte{70},
+	}
+	err = db.WriteVote(v.toDBVote())
+	assert.Nil(t, err)
+
+	me := &Node{PubKey: []byte{69}}
+	bc := NewBlockchain(db, nil, me, nil)
+
+	voted, err := bc.HasVoted(StringToHash("2"))
+	assert.Nil(t, err)
+	assert.False(t, voted)
+
+	v.Voter = me.PubKey
+	err = db.WriteVote(v.toDBVote())
+	assert.Nil(t, err)
+
+	voted, err = bc.HasVoted(StringToHash("2"))
+	assert.Nil(t, err)
+	assert.True(t, voted)
+}
+
+func TestGetCells(t *testing.T) {
+	bt, err := meddb.NewMemoryBigtable()
+	assert.Nil(t, err)
+	err = bt.CreateTable([]byte("cars"))
+	assert.Nil(t, err)
+
+	op := meddb.NewPutOp([]byte("tesla"))
+	op.AddColVer([]byte("wheels"), 1, []byte("3"))
+	op.AddColVer([]byte("doors"), 2, []byte("2"))
+	err = bt.Put([]byte("cars"), op)
+	assert.Nil(t, err)
+	op = meddb.NewPutOp([]byte("tesla"))
+	op.AddColVer([]byte("wheels"), 3, []byte("4"))
+	err = bt.Put([]byte("cars"), op)
+	assert.Nil(t, err)
+
+	bc := NewBlockchain(nil, bt, nil, nil)
+
+	cells, err := bc.GetCells([]byte("cars"), meddb.NewGetOpLimit(
+		[]byte("tesla"), [][]byte{[]byte("wheels"), []byte("doors")}, 1))
+	assert.Nil(t, err)
+	expected := map[string][]*Cell{
+		"wheels": []*Cell{&Cell{Data: []byte("4"), VerId: big.NewInt(3)}},
+		"doors":  []*Cell{&Cell{Data: []byte("2"), VerId: big.NewInt(2)}},
+	}
+	assert.Equal(t, expected, cells)
+}
+
+func TestScanRows(t *testing.T) {
+	bt, err := meddb.NewMemoryBigtable()
+	assert.Nil(t, err)
+	err = bt.CreateTable([]byte("cars"))
+	assert.Nil(t, err)
+
+	for _, rowId := range []string{"tesla", "ford", "audi"} {
+		op := meddb.NewPutOp([]byte(rowId))
+		op.AddColVer([]byte("wheels"), 1, []byte("4"))
+		err = bt.Put([]byte("cars"), op)
+		assert.Nil(t, err)
+	}
+
+	bc := NewBlockchain(nil, bt, nil, nil)
+
+	rows, pageToken, err := bc.ScanRows([]byte("cars"), meddb.NewScanOp(nil).WithPageSize(2))
+	assert.Nil(t, err)
+	wheels := map[string][]*Cell{"wheels": []*Cell{&Cell{Data: []byte("4"), VerId: big.NewInt(1)}}}
+	expected := []*Row{
+		&Row{RowId: []byte("audi"), Cols: wheels},
+		&Row{RowId: []byte("ford"), Cols: wheels},
+	}
+	assert.Equal(t, expected, rows)
+	assert.Equal(t, []byte("tesla"), pageToken)
 }
@@ -36,6 +36,11 @@ func (cursor *TransactionChangeCursor) Next(change *TransactionChange) bool {
 	return changed
 }
 
+// Stops the changefeed. A Next that is waiting for a change returns false.
+func (cursor *TransactionChangeCursor) Close() error {
+	return cursor.changefeed.Close()
+}
+
 type BlockChange struct {
 	NewBlock *Block
 	OldBlock *Block
@@ -66,6 +71,11 @@ func (cursor *BlockChangeCursor) Next(change *BlockChange) bool {
 	return changed
 }
 
+// Stops the changefeed. A Next that is waiting for a change returns false.
+func (cursor *BlockChangeCursor) Close() error {
+	return cursor.changefeed.Close()
+}
+
 type VoteChange struct {
 	NewVote *Vote
 	OldVote *Vote
@@ -95,3 +105,8 @@ func (cursor *VoteChangeCursor) Next(change *VoteChange) bool {
 
 	return changed
 }
+
+// Stops the changefeed. A Next that is waiting for a change returns false.
+func (cursor *VoteChangeCursor) Close() error {
+	return cursor.changefeed.Close()
+}
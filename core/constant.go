@@ -5,3 +5,9 @@ const GENESIS_MESSAGE = `Our vision has always been to create an iPhone that is
 	One so immersive the device itself disappears into the experience. And so intelligent it can
 	respond to a tap, your voice and even a glance. With iPhone X, that vision is now a reality.
 	Say hello to the future.`
+
+// Number of output ids whose accepted outputs are cached by the blockchain db
+const OUTPUT_CACHE_SIZE = 10000
+
+// Age in ms after which a node without a newer heartbeat is not assigned transactions anymore
+const DEFAULT_HEARTBEAT_STALE_AGE_MS = 10000
@@ -1,6 +1,9 @@
 package core
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+)
 
 type MissingOutputsError struct {
 	OutputIds [][]byte
@@ -36,6 +39,16 @@ func (e *TransactionErrors) Error() string {
 		e.BlockId, e.Errors)
 }
 
+// Transactions of a block have to be sorted by their hashes, since that is the order they are
+// applied in and the order that outputs of the same block are resolved in.
+type BlockTransactionsUnorderedError struct {
+	BlockId Hash
+}
+
+func (e *BlockTransactionsUnorderedError) Error() string {
+	return fmt.Sprintf("Transactions not sorted by hash in block with id: %v", e.BlockId)
+}
+
 type BlockSignatureInvalidError struct {
 	BlockId Hash
 }
@@ -43,3 +56,39 @@ type BlockSignatureInvalidError struct {
 func (e *BlockSignatureInvalidError) Error() string {
 	return fmt.Sprintf("Block signature invalid for block with id: %v", e.BlockId)
 }
+
+type BlockHeightInvalidError struct {
+	BlockId   Hash
+	Height    *big.Int
+	MaxHeight int64 // Height of the highest decided block
+}
+
+func (e *BlockHeightInvalidError) Error() string {
+	return fmt.Sprintf("Block height %v invalid for block with id: %v, decided blocks up to %d",
+		e.Height, e.BlockId, e.MaxHeight)
+}
+
+type VoteSignatureInvalidError struct {
+	VoteHash Hash
+}
+
+func (e *VoteSignatureInvalidError) Error() string {
+	return fmt.Sprintf("Vote signature invalid for vote with hash: %v", e.VoteHash)
+}
+
+type VoterNotEligibleError struct {
+	BlockId Hash
+	Voter   []byte
+}
+
+func (e *VoterNotEligibleError) Error() string {
+	return fmt.Sprintf("Voter %x is not a voter of block with id: %v", e.Voter, e.BlockId)
+}
+
+type SpentOutputsError struct {
+	OutputIds [][]byte
+}
+
+func (e *SpentOutputsError) Error() string {
+	return fmt.Sprintf("Outputs spent in undecided blocks: %v", e.OutputIds)
+}
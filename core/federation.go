@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Returns a copy of the nodes that are currently in the federation.
+func (bc *Blockchain) Federation() []*Node {
+	bc.federationLock.RLock()
+	defer bc.federationLock.RUnlock()
+
+	return append([]*Node{}, bc.federation...)
+}
+
+// Returns whether the node with the given public key is currently in the federation.
+func (bc *Blockchain) IsMember(pubKey []byte) bool {
+	bc.federationLock.RLock()
+	defer bc.federationLock.RUnlock()
+
+	return indexOfNode(bc.federation, pubKey) >= 0
+}
+
+// Returns the ruleset of the transaction. Changes to the federation are also checked against the
+// current federation of this blockchain.
+func (bc *Blockchain) getRuleset(tx *Transaction) ([]Rule, error) {
+	ruleset, err := tx.GetRuleset()
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.IsMembershipChange() {
+		federation := bc.Federation()
+		members := make([][]byte, len(federation))
+		for i, node := range federation {
+			members[i] = node.PubKey
+		}
+
+		// Copy so that the shared ruleset is not modified
+		ruleset = append(append([]Rule{}, ruleset...),
+			&FederationRule{members: members, quorum: bc.quorum})
+	}
+	return ruleset, nil
+}
+
+// Replaces the federation with the federation that the membership changes applied to the bigtable
+// led to. Keeps the federation that the blockchain was created with if no change was applied yet.
+// Has to be called on startup before any block is built, voted on or applied, since the state
+// cursor is already past the blocks with those changes.
+func (bc *Blockchain) LoadFederation() error {
+	op := meddb.NewGetOpLimit([]byte(stateFederationRow), [][]byte{[]byte(stateFederationCol)}, 1)
+	res, err := bc.bt.Get([]byte(STATE_TABLE), op)
+	if err != nil {
+		return err
+	}
+
+	cells, ok := res[stateFederationCol]
+	if !ok || len(cells) == 0 {
+		return nil
+	}
+
+	members := make([][]byte, 0)
+	if err := rlpDecode(cells[0].Data, &members); err != nil {
+		return err
+	}
+
+	bc.federationLock.Lock()
+	defer bc.federationLock.Unlock()
+
+	federation := make([]*Node, len(members))
+	for i, member := range members {
+		if j := indexOfNode(bc.federation, member); j >= 0 {
+			// Keeps the private key if this node is the member
+			federation[i] = bc.federation[j]
+		} else {
+			federation[i] = &Node{PubKey: member}
+		}
+	}
+	bc.federation = federation
+	return nil
+}
+
+// Adds or removes the node of an ACCEPTED membership transaction to or from the federation, and
+// writes the new federation to bigtable so that LoadFederation finds it after a restart.
+// Blocks built afterwards have the new federation as voters.
+// Applying the same transaction again does nothing.
+func (bc *Blockchain) applyMembershipChange(tx *Transaction) error {
+	cell, ok := tx.Cols[NODE_COL]
+	if !ok {
+		return nil
+	}
+
+	bc.federationLock.Lock()
+	defer bc.federationLock.Unlock()
+
+	i := indexOfNode(bc.federation, cell.Data)
+	switch tx.Type {
+	case TRANSACTION_TYPE_ADD_NODE:
+		if i < 0 {
+			bc.federation = append(bc.federation, &Node{PubKey: cell.Data})
+		}
+	case TRANSACTION_TYPE_REMOVE_NODE:
+		if i >= 0 {
+			bc.federation = append(append([]*Node{}, bc.federation[:i]...),
+				bc.federation[i+1:]...)
+		}
+	}
+
+	members := make([][]byte, len(bc.federation))
+	for i, node := range bc.federation {
+		members[i] = node.PubKey
+	}
+	data, err := rlpEncode(members)
+	if err != nil {
+		return err
+	}
+
+	op := meddb.NewPutOp([]byte(stateFederationRow))
+	op.AddCol([]byte(stateFederationCol), data)
+	return bc.bt.Put([]byte(STATE_TABLE), op)
+}
+
+// -------
+// Helpers
+// -------
+
+// Returns the index of the node with the given public key, or -1 if there is none.
+func indexOfNode(nodes []*Node, pubKey []byte) int {
+	for i, node := range nodes {
+		if bytes.Equal(node.PubKey, pubKey) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/crypto"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Creates a blockchain with a federation of n nodes and an ACCEPTED genesis block.
+func getFederationBlockchain(t *testing.T, n int) (*Blockchain, []*Node) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bt, err := meddb.NewMemoryBigtable()
+	assert.Nil(t, err)
+
+	federation := make([]*Node, n)
+	for i := 0; i < n; i++ {
+		priv, err := crypto.NewPrivateKey()
+		assert.Nil(t, err)
+		federation[i] = NewNode(priv)
+	}
+
+	bc := NewBlockchain(db, bt, federation[0], federation)
+	assert.Nil(t, bc.SetupState())
+
+	gen, err := bc.BuildGenesis()
+	assert.Nil(t, err)
+	gen.State = BLOCK_STATE_ACCEPTED
+	assert.Nil(t, bc.WriteBlock(gen))
+
+	return bc, federation
+}
+
+func newNode(t *testing.T) *Node {
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	return NewNode(priv)
+}
+
+// Builds a membership transaction for the node that is signed by all signers.
+func getMembershipTransaction(t *testing.T, txType TransactionType, node *Node,
+	signers ...*Node) *Transaction {
+
+	tx := &Transaction{
+		Type: txType,
+		Cols: map[string]*Cell{NODE_COL: &Cell{Data: node.PubKey}},
+	}
+	if txType == TRANSACTION_TYPE_ADD_NODE {
+		tx.Outputs = []Output{&NodeOutput{TableNameMixin: &TableNameMixin{}, PubKey: node.PubKey}}
+	}
+
+	inputs := make([]Input, len(signers))
+	for i, signer := range signers {
+		inputs[i] = &MemberInput{InputLink: InputLink{HashOutput(&NodeOutput{
+			TableNameMixin: &TableNameMixin{},
+			PubKey:         signer.PubKey,
+		})}}
+	}
+	tx.Inputs = inputs
+
+	for i, signer := range signers {
+		sig, err := crypto.Sign(tx.Hash().Bytes(), signer.PrivKey)
+		assert.Nil(t, err)
+		tx.Inputs[i].FromData(sig)
+	}
+	return tx
+}
+
+func acceptMembershipTransaction(t *testing.T, bc *Blockchain, tx *Transaction) {
+	b, err := bc.BuildBlock([]*Transaction{tx})
+	assert.Nil(t, err)
+	b.State = BLOCK_STATE_ACCEPTED
+	assert.Nil(t, bc.WriteBlock(b))
+
+	applied, err := bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, applied)
+}
+
+// -----
+// Tests
+// -----
+
+func TestValidateAddNode(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, newNode(t),
+		federation[0], federation[2])
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+func TestValidateAddNodeNoQuorum(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, newNode(t), federation[1])
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+}
+
+func TestValidateAddNodeDuplicateSigner(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, newNode(t),
+		federation[1], federation[1])
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+}
+
+func TestValidateAddNodeUnknownSigner(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, newNode(t),
+		federation[0], newNode(t))
+	assert.IsType(t, &MissingOutputsError{}, bc.ValidateTransaction(tx))
+}
+
+func TestValidateAddNodeAlreadyMember(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, federation[1],
+		federation[0], federation[1], federation[2])
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+}
+
+func TestValidateAddNodeMissingOutput(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_REMOVE_NODE, newNode(t),
+		federation[0], federation[1])
+	tx.Type = TRANSACTION_TYPE_ADD_NODE
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+}
+
+func TestValidateRemoveNode(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_REMOVE_NODE, federation[2],
+		federation[0], federation[1])
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+func TestValidateRemoveNodeNotMember(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_REMOVE_NODE, newNode(t),
+		federation[0], federation[1])
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+}
+
+func TestValidateRemoveLastNode(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 1)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_REMOVE_NODE, federation[0], federation[0])
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+}
+
+func TestApplyAddNode(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	node := newNode(t)
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, node,
+		federation[0], federation[1])
+	acceptMembershipTransaction(t, bc, tx)
+
+	assert.True(t, bc.IsMember(node.PubKey))
+	assert.Equal(t, 4, len(bc.Federation()))
+
+	b, err := bc.BuildBlock([]*Transaction{&Transaction{}})
+	assert.Nil(t, err)
+	assert.Equal(t, node.PubKey, b.Voters[3])
+
+	// New member can sign the next change
+	tx = getMembershipTransaction(t, TRANSACTION_TYPE_REMOVE_NODE, federation[0],
+		federation[1], federation[2], node)
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+func TestApplyRemoveNode(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+	removeTx := getMembershipTransaction(t, TRANSACTION_TYPE_REMOVE_NODE, federation[2],
+		federation[0], federation[1])
+	acceptMembershipTransaction(t, bc, removeTx)
+
+	assert.False(t, bc.IsMember(federation[2].PubKey))
+	assert.Equal(t, 2, len(bc.Federation()))
+
+	// Removed node cannot sign anymore
+	tx := getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE, newNode(t),
+		federation[0], federation[2])
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(tx))
+
+	// Applying the same transaction again does nothing
+	b := &Block{Height: big.NewInt(1), Transactions: []*Transaction{removeTx}}
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Equal(t, 2, len(bc.Federation()))
+}
+
+func TestLoadFederation(t *testing.T) {
+	bc, federation := getFederationBlockchain(t, 3)
+
+	// Nothing applied yet
+	assert.Nil(t, bc.LoadFederation())
+	assert.Equal(t, federation, bc.Federation())
+
+	node := newNode(t)
+	acceptMembershipTransaction(t, bc, getMembershipTransaction(t, TRANSACTION_TYPE_ADD_NODE,
+		node, federation[0], federation[1]))
+
+	// Restarted node has the federation from its config
+	restarted := NewBlockchain(bc.db, bc.bt, federation[1], federation)
+	assert.Nil(t, restarted.LoadFederation())
+	assert.Equal(t, bc.Federation(), restarted.Federation())
+	assert.True(t, restarted.IsMember(node.PubKey))
+
+	b, err := restarted.BuildBlock([]*Transaction{&Transaction{}})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(b.Voters))
+}
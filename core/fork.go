@@ -0,0 +1,328 @@
+package core
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+const forkBlockBatchSize = 100
+
+// Blocks that a voter voted on, in the order that it voted on them.
+type VoteChain struct {
+	Voter  []byte
+	Blocks []Hash
+}
+
+// Voters that voted on a block right after voting on PrevBlock.
+type ForkBranch struct {
+	PrevBlock Hash // ROOT_BLOCK_ID if the vote started the chain of the voter
+	Voters    [][]byte
+}
+
+// Block that voters disagree on the predecessor of.
+type Fork struct {
+	BlockId  Hash
+	Branches []*ForkBranch // Sorted by decreasing number of voters
+}
+
+type ForkReport struct {
+	Chains []*VoteChain
+	Forks  []*Fork
+	// Blocks in the order that a quorum of the voters of every block agrees on, starting at the
+	// root. Stops where no quorum agrees on what comes next.
+	CanonicalOrder []Hash
+	// Number of blocks that come before CanonicalOrder and are no longer checked, see DetectForks
+	Settled int64
+}
+
+// PrevBlock of votes that start a vote chain. Every node starts its chain at a genesis block that
+// is never voted on, so all of those are treated as the same root.
+var ROOT_BLOCK_ID = Hash{}
+
+// Blocks and votes read by earlier fork checks, so that every check only reads what changed since.
+type forkState struct {
+	lock    sync.Mutex // Held for the whole check
+	height  int64      // Position of the last block read, in the order of the blockchain
+	blockId Hash
+	blocks  []*Block
+	votes   map[Hash][]*Vote
+	pending []*Block // Blocks that some of their voters have not voted on yet
+	settled int64    // Number of blocks dropped from blocks, see prune
+
+	reportLock sync.RWMutex
+	report     *ForkReport
+}
+
+func newForkState() *forkState {
+	return &forkState{height: -1, blocks: make([]*Block, 0), votes: make(map[Hash][]*Vote)}
+}
+
+// Reconstructs the vote chain of every voter from the votes table and looks for forks.
+// Only reads the blocks written since the last check, and the votes of blocks that not every voter
+// had voted on by then. The blocks and votes of the earlier checks are kept in memory. Blocks that
+// are written late with a height below blocks that were already read are not picked up, voters
+// reject those anyway (see ValidateBlock).
+// Blocks that settled in the canonical order are dropped after the check, so the next check starts
+// at the last of them and its vote chains leave the dropped blocks out.
+// The report is kept until the next check, see LastForkReport.
+func (bc *Blockchain) DetectForks() (*ForkReport, error) {
+	s := bc.forks
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Nothing is kept if reading fails halfway, so the next check reads the same blocks again
+	votes := make(map[Hash][]*Vote)
+	pending := make([]*Block, 0)
+	readVotes := func(b *Block) error {
+		vs, err := bc.GetBlockVotes(b.Hash())
+		if err != nil {
+			return err
+		}
+		votes[b.Hash()] = vs
+		if !allVoted(b, vs) {
+			pending = append(pending, b)
+		}
+		return nil
+	}
+
+	for _, b := range s.pending {
+		if err := readVotes(b); err != nil {
+			return nil, err
+		}
+	}
+
+	bs := make([]*Block, 0)
+	height, blockId := s.height, s.blockId
+	for {
+		batch, err := bc.GetBlocksAfter(height, blockId, forkBlockBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range batch {
+			height, blockId = b.Height.Int64(), b.Hash()
+			if err := readVotes(b); err != nil {
+				return nil, err
+			}
+			bs = append(bs, b)
+		}
+
+		if len(batch) < forkBlockBatchSize {
+			break
+		}
+	}
+
+	s.height, s.blockId = height, blockId
+	s.blocks = append(s.blocks, bs...)
+	for blockId, vs := range votes {
+		s.votes[blockId] = vs
+	}
+	s.pending = pending
+
+	vs := make([]*Vote, 0)
+	for _, b := range s.blocks {
+		vs = append(vs, s.votes[b.Hash()]...)
+	}
+	report := AnalyzeVoteChains(s.blocks, vs, bc.quorum)
+	report.Settled = s.settled
+	s.prune(report)
+
+	s.reportLock.Lock()
+	s.report = report
+	s.reportLock.Unlock()
+	return report, nil
+}
+
+// Returns the report of the last fork check, nil if no check has finished yet.
+func (bc *Blockchain) LastForkReport() *ForkReport {
+	bc.forks.reportLock.RLock()
+	defer bc.forks.reportLock.RUnlock()
+	return bc.forks.report
+}
+
+// Builds the vote chains from the given votes on the given blocks. Only votes from the voters of
+// a block count. A fork is a block that voters voted on after different blocks. A link from one
+// block to the next is canonical once the quorum of the voters of the next block agrees on it.
+func AnalyzeVoteChains(bs []*Block, vs []*Vote, q *Quorum) *ForkReport {
+	blocks := make(map[Hash]*Block)
+	for _, b := range bs {
+		blocks[b.Hash()] = b
+	}
+
+	// Only votes that count, every voter at most once for every link
+	votedOn := make(map[Hash]bool)
+	counted := make([]*Vote, 0)
+	seen := make(map[string]bool)
+	for _, v := range vs {
+		b, ok := blocks[v.NextBlock]
+		if !ok || !isVoter(b, v.Voter) {
+			continue
+		}
+		key := string(v.Voter) + v.PrevBlock.String() + v.NextBlock.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		votedOn[v.NextBlock] = true
+		counted = append(counted, v)
+	}
+
+	// Chains of votes follow the order the votes were cast in
+	sort.SliceStable(counted, func(i, j int) bool {
+		return compareBigInts(counted[i].VotedAt, counted[j].VotedAt) < 0
+	})
+
+	chains := make([]*VoteChain, 0)
+	chainsByVoter := make(map[string]*VoteChain)
+	branches := make(map[Hash]map[Hash]*ForkBranch) // NextBlock -> PrevBlock -> branch
+	for _, v := range counted {
+		chain, ok := chainsByVoter[string(v.Voter)]
+		if !ok {
+			chain = &VoteChain{Voter: v.Voter, Blocks: make([]Hash, 0)}
+			chainsByVoter[string(v.Voter)] = chain
+			chains = append(chains, chain)
+		}
+		chain.Blocks = append(chain.Blocks, v.NextBlock)
+
+		prevBlock := v.PrevBlock
+		if !votedOn[prevBlock] {
+			prevBlock = ROOT_BLOCK_ID
+		}
+		if _, ok := branches[v.NextBlock]; !ok {
+			branches[v.NextBlock] = make(map[Hash]*ForkBranch)
+		}
+		branch, ok := branches[v.NextBlock][prevBlock]
+		if !ok {
+			branch = &ForkBranch{PrevBlock: prevBlock, Voters: make([][]byte, 0)}
+			branches[v.NextBlock][prevBlock] = branch
+		}
+		branch.Voters = append(branch.Voters, v.Voter)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return bytes.Compare(chains[i].Voter, chains[j].Voter) < 0
+	})
+
+	forks := make([]*Fork, 0)
+	next := make(map[Hash][]*Block) // Canonical links from PrevBlock to the next blocks
+	for _, b := range bs {
+		blockId := b.Hash()
+		blockBranches := sortedBranches(branches[blockId])
+		if len(blockBranches) > 1 {
+			forks = append(forks, &Fork{BlockId: blockId, Branches: blockBranches})
+		}
+		if len(blockBranches) > 0 && len(blockBranches[0].Voters) >= q.Threshold(len(b.Voters)) {
+			prevBlock := blockBranches[0].PrevBlock
+			next[prevBlock] = append(next[prevBlock], b)
+		}
+	}
+
+	return &ForkReport{
+		Chains:         chains,
+		Forks:          forks,
+		CanonicalOrder: canonicalOrder(next),
+	}
+}
+
+// -------
+// Helpers
+// -------
+
+// Drops the blocks at the start of the canonical order of the report that every voter voted on
+// and that are not forks, together with their votes. The last of them is kept, so that the blocks
+// after it still have a start: votes whose PrevBlock was dropped count as starting a vote chain.
+func (s *forkState) prune(report *ForkReport) {
+	unsettled := make(map[Hash]bool)
+	for _, b := range s.pending {
+		unsettled[b.Hash()] = true
+	}
+	for _, fork := range report.Forks {
+		unsettled[fork.BlockId] = true
+	}
+
+	dropped := make(map[Hash]bool)
+	for i := 0; i+1 < len(report.CanonicalOrder); i++ {
+		blockId := report.CanonicalOrder[i]
+		if unsettled[blockId] || unsettled[report.CanonicalOrder[i+1]] {
+			break
+		}
+		dropped[blockId] = true
+	}
+	if len(dropped) == 0 {
+		return
+	}
+
+	blocks := make([]*Block, 0, len(s.blocks)-len(dropped))
+	for _, b := range s.blocks {
+		if dropped[b.Hash()] {
+			delete(s.votes, b.Hash())
+			continue
+		}
+		blocks = append(blocks, b)
+	}
+	s.blocks = blocks
+	s.settled += int64(len(dropped))
+}
+
+func isVoter(b *Block, voter []byte) bool {
+	for _, v := range b.Voters {
+		if bytes.Equal(v, voter) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether every voter of the block is among the voters of the given votes.
+func allVoted(b *Block, vs []*Vote) bool {
+	voted := make(map[string]bool)
+	for _, v := range vs {
+		voted[string(v.Voter)] = true
+	}
+	for _, voter := range b.Voters {
+		if !voted[string(voter)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Sorts branches by decreasing number of voters, ties are broken by PrevBlock.
+func sortedBranches(branches map[Hash]*ForkBranch) []*ForkBranch {
+	sorted := make([]*ForkBranch, 0, len(branches))
+	for _, branch := range branches {
+		sorted = append(sorted, branch)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].Voters) != len(sorted[j].Voters) {
+			return len(sorted[i].Voters) > len(sorted[j].Voters)
+		}
+		return bytes.Compare(sorted[i].PrevBlock.Bytes(), sorted[j].PrevBlock.Bytes()) < 0
+	})
+	return sorted
+}
+
+// Follows the canonical links from the root. Blocks that follow the same block are visited in
+// the order of the blockchain.
+func canonicalOrder(next map[Hash][]*Block) []Hash {
+	order := make([]Hash, 0)
+	visited := make(map[Hash]bool)
+
+	var visit func(blockId Hash)
+	visit = func(blockId Hash) {
+		bs := next[blockId]
+		sort.Slice(bs, func(i, j int) bool { return bs[i].IsBefore(bs[j]) })
+		for _, b := range bs {
+			nextId := b.Hash()
+			if visited[nextId] {
+				continue
+			}
+			visited[nextId] = true
+			order = append(order, nextId)
+			visit(nextId)
+		}
+	}
+	visit(ROOT_BLOCK_ID)
+
+	return order
+}
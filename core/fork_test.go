@@ -0,0 +1,250 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+var forkVoters = [][]byte{[]byte{1}, []byte{2}, []byte{3}, []byte{4}}
+
+func getForkBlocks(n int) []*Block {
+	bs := make([]*Block, n)
+	for i := range bs {
+		bs[i] = &Block{Height: big.NewInt(int64(i)), Voters: forkVoters}
+	}
+	return bs
+}
+
+// Counts the blocks that votes are read for
+type countingVotesDB struct {
+	meddb.BlockchainDB
+	reads map[string]int
+}
+
+func (db *countingVotesDB) GetBlockVotes(blockId []byte) ([]*meddb.Vote, error) {
+	db.reads[string(blockId)]++
+	return db.BlockchainDB.GetBlockVotes(blockId)
+}
+
+// Builds the votes of a voter that voted on the blocks in the given order.
+func getForkVotes(voter []byte, genesis Hash, bs ...*Block) []*Vote {
+	vs := make([]*Vote, len(bs))
+	prevBlockId := genesis
+	for i, b := range bs {
+		vs[i] = &Vote{
+			Voter:     voter,
+			VotedAt:   big.NewInt(int64(i)),
+			PrevBlock: prevBlockId,
+			NextBlock: b.Hash(),
+			Value:     true,
+		}
+		prevBlockId = b.Hash()
+	}
+	return vs
+}
+
+// -----
+// Tests
+// -----
+
+func TestAnalyzeVoteChainsNoForks(t *testing.T) {
+	bs := getForkBlocks(3)
+	vs := make([]*Vote, 0)
+	for i, voter := range forkVoters {
+		// Every voter starts at its own genesis
+		vs = append(vs, getForkVotes(voter, StringToHash(string(voter)+"genesis"), bs...)...)
+		if i == 3 {
+			// Last voter has not voted on the last block yet
+			vs = vs[:len(vs)-1]
+		}
+	}
+
+	report := AnalyzeVoteChains(bs, vs, DEFAULT_QUORUM)
+	assert.Equal(t, 0, len(report.Forks))
+	assert.Equal(t, []Hash{bs[0].Hash(), bs[1].Hash(), bs[2].Hash()}, report.CanonicalOrder)
+	assert.Equal(t, 4, len(report.Chains))
+	assert.Equal(t, &VoteChain{
+		Voter:  forkVoters[0],
+		Blocks: []Hash{bs[0].Hash(), bs[1].Hash(), bs[2].Hash()},
+	}, report.Chains[0])
+	assert.Equal(t, []Hash{bs[0].Hash(), bs[1].Hash()}, report.Chains[3].Blocks)
+}
+
+func TestAnalyzeVoteChainsFork(t *testing.T) {
+	bs := getForkBlocks(3)
+	vs := make([]*Vote, 0)
+	for _, voter := range forkVoters[:3] {
+		vs = append(vs, getForkVotes(voter, Hash{}, bs[0], bs[1], bs[2])...)
+	}
+	// Last voter saw the blocks in a different order
+	vs = append(vs, getForkVotes(forkVoters[3], Hash{}, bs[0], bs[2], bs[1])...)
+
+	report := AnalyzeVoteChains(bs, vs, DEFAULT_QUORUM)
+	assert.Equal(t, []Hash{bs[0].Hash(), bs[1].Hash(), bs[2].Hash()}, report.CanonicalOrder)
+	assert.Equal(t, 2, len(report.Forks))
+
+	assert.Equal(t, &Fork{
+		BlockId: bs[1].Hash(),
+		Branches: []*ForkBranch{
+			&ForkBranch{PrevBlock: bs[0].Hash(), Voters: forkVoters[:3]},
+			&ForkBranch{PrevBlock: bs[2].Hash(), Voters: forkVoters[3:]},
+		},
+	}, report.Forks[0])
+	assert.Equal(t, &Fork{
+		BlockId: bs[2].Hash(),
+		Branches: []*ForkBranch{
+			&ForkBranch{PrevBlock: bs[1].Hash(), Voters: forkVoters[:3]},
+			&ForkBranch{PrevBlock: bs[0].Hash(), Voters: forkVoters[3:]},
+		},
+	}, report.Forks[1])
+}
+
+func TestAnalyzeVoteChainsNoQuorum(t *testing.T) {
+	bs := getForkBlocks(3)
+	vs := make([]*Vote, 0)
+	for _, voter := range forkVoters[:2] {
+		vs = append(vs, getForkVotes(voter, Hash{}, bs[0], bs[1], bs[2])...)
+	}
+	for _, voter := range forkVoters[2:] {
+		vs = append(vs, getForkVotes(voter, Hash{}, bs[0], bs[2], bs[1])...)
+	}
+
+	// Nobody agrees on what comes after the first block
+	report := AnalyzeVoteChains(bs, vs, DEFAULT_QUORUM)
+	assert.Equal(t, []Hash{bs[0].Hash()}, report.CanonicalOrder)
+	assert.Equal(t, 2, len(report.Forks))
+}
+
+func TestAnalyzeVoteChainsIgnoresNonVoters(t *testing.T) {
+	bs := getForkBlocks(2)
+	vs := make([]*Vote, 0)
+	for _, voter := range forkVoters {
+		vs = append(vs, getForkVotes(voter, Hash{}, bs[0], bs[1])...)
+	}
+	vs = append(vs, getForkVotes([]byte{5}, Hash{}, bs[1], bs[0])...)
+
+	report := AnalyzeVoteChains(bs, vs, DEFAULT_QUORUM)
+	assert.Equal(t, 0, len(report.Forks))
+	assert.Equal(t, 4, len(report.Chains))
+}
+
+func TestDetectForks(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	bs := getForkBlocks(2)
+	for _, b := range bs {
+		assert.Nil(t, db.WriteBlock(b.toDBBlock()))
+	}
+	for _, voter := range forkVoters[:3] {
+		for _, v := range getForkVotes(voter, Hash{}, bs[0], bs[1]) {
+			assert.Nil(t, db.WriteVote(v.toDBVote()))
+		}
+	}
+	for _, v := range getForkVotes(forkVoters[3], Hash{}, bs[1], bs[0]) {
+		assert.Nil(t, db.WriteVote(v.toDBVote()))
+	}
+
+	bc := NewBlockchain(db, nil, nil, nil)
+	report, err := bc.DetectForks()
+	assert.Nil(t, err)
+	assert.Equal(t, []Hash{bs[0].Hash(), bs[1].Hash()}, report.CanonicalOrder)
+	assert.Equal(t, 2, len(report.Forks))
+}
+
+func TestDetectForksIncremental(t *testing.T) {
+	memoryDB, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	db := &countingVotesDB{BlockchainDB: memoryDB, reads: make(map[string]int)}
+
+	bs := getForkBlocks(3)
+	for _, b := range bs[:2] {
+		assert.Nil(t, db.WriteBlock(b.toDBBlock()))
+	}
+	for _, voter := range forkVoters {
+		vs := getForkVotes(voter, Hash{}, bs[0], bs[1])
+		if voter[0] == 4 {
+			// Last voter has not voted on the second block yet
+			vs = vs[:1]
+		}
+		for _, v := range vs {
+			assert.Nil(t, db.WriteVote(v.toDBVote()))
+		}
+	}
+
+	bc := NewBlockchain(db, nil, nil, nil)
+	assert.Nil(t, bc.LastForkReport())
+	report, err := bc.DetectForks()
+	assert.Nil(t, err)
+	assert.Equal(t, report, bc.LastForkReport())
+	assert.Equal(t, []Hash{bs[0].Hash(), bs[1].Hash()}, report.CanonicalOrder)
+
+	// Last voter votes on the third block before the second one
+	assert.Nil(t, db.WriteBlock(bs[2].toDBBlock()))
+	for _, v := range getForkVotes(forkVoters[3], bs[0].Hash(), bs[2], bs[1]) {
+		assert.Nil(t, db.WriteVote(v.toDBVote()))
+	}
+
+	report, err = bc.DetectForks()
+	assert.Nil(t, err)
+	assert.Equal(t, report, bc.LastForkReport())
+	assert.Equal(t, 1, len(report.Forks))
+	assert.Equal(t, bs[1].Hash(), report.Forks[0].BlockId)
+
+	// Votes of the first block are only read once, since every voter had voted on it
+	assert.Equal(t, 1, db.reads[string(bs[0].Hash().Bytes())])
+	assert.Equal(t, 2, db.reads[string(bs[1].Hash().Bytes())])
+	assert.Equal(t, 1, db.reads[string(bs[2].Hash().Bytes())])
+}
+
+func TestDetectForksPrunesSettled(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	bs := getForkBlocks(5)
+	for _, b := range bs[:3] {
+		assert.Nil(t, db.WriteBlock(b.toDBBlock()))
+	}
+	for _, voter := range forkVoters {
+		for _, v := range getForkVotes(voter, Hash{}, bs[:3]...) {
+			assert.Nil(t, db.WriteVote(v.toDBVote()))
+		}
+	}
+
+	bc := NewBlockchain(db, nil, nil, nil)
+	report, err := bc.DetectForks()
+	assert.Nil(t, err)
+	assert.Equal(t, []Hash{bs[0].Hash(), bs[1].Hash(), bs[2].Hash()}, report.CanonicalOrder)
+	assert.Equal(t, int64(0), report.Settled)
+
+	// Only the last settled block is kept
+	assert.Equal(t, []*Block{bs[2]}, bc.forks.blocks)
+	assert.Equal(t, 1, len(bc.forks.votes))
+
+	// Last voter votes on the fifth block right after the second one
+	for _, b := range bs[3:] {
+		assert.Nil(t, db.WriteBlock(b.toDBBlock()))
+	}
+	for _, voter := range forkVoters[:3] {
+		for _, v := range getForkVotes(voter, bs[2].Hash(), bs[3], bs[4]) {
+			assert.Nil(t, db.WriteVote(v.toDBVote()))
+		}
+	}
+	for _, v := range getForkVotes(forkVoters[3], bs[1].Hash(), bs[4]) {
+		assert.Nil(t, db.WriteVote(v.toDBVote()))
+	}
+
+	report, err = bc.DetectForks()
+	assert.Nil(t, err)
+	assert.Equal(t, []Hash{bs[2].Hash(), bs[3].Hash(), bs[4].Hash()}, report.CanonicalOrder)
+	assert.Equal(t, int64(2), report.Settled)
+	assert.Equal(t, 1, len(report.Forks))
+	assert.Equal(t, bs[4].Hash(), report.Forks[0].BlockId)
+
+	// Nothing else is dropped, since the last voter has not voted on the fourth block
+	assert.Equal(t, []*Block{bs[2], bs[3], bs[4]}, bc.forks.blocks)
+}
@@ -15,6 +15,8 @@ const (
 	INPUT_TYPE_ADMIN      InputType = iota // ADMIN      = 0
 	INPUT_TYPE_WRITER                      // WRITER     = 1
 	INPUT_TYPE_ROW_WRITER                  // ROW_WRITER = 2
+	INPUT_TYPE_MEMBER                      // MEMBER     = 3
+	INPUT_TYPE_COL_WRITER                  // COL_WRITER = 4
 )
 
 type Input interface {
@@ -113,6 +115,54 @@ func (in *RowWriterInput) FromData(data []byte) error {
 	return nil
 }
 
+// --------------------------------
+// ColWriterInput implementation
+//
+// Allows a particular user to write to a restricted column of a table
+// --------------------------------
+
+type ColWriterInput struct {
+	InputLink
+	Sig []byte
+}
+
+func (in *ColWriterInput) Type() InputType {
+	return INPUT_TYPE_COL_WRITER
+}
+
+func (in *ColWriterInput) Data() []byte {
+	return in.Sig
+}
+
+func (in *ColWriterInput) FromData(data []byte) error {
+	in.Sig = data
+	return nil
+}
+
+// --------------------------------
+// MemberInput implementation
+//
+// Signature of a member of the federation on a change to the federation
+// --------------------------------
+
+type MemberInput struct {
+	InputLink
+	Sig []byte
+}
+
+func (in *MemberInput) Type() InputType {
+	return INPUT_TYPE_MEMBER
+}
+
+func (in *MemberInput) Data() []byte {
+	return in.Sig
+}
+
+func (in *MemberInput) FromData(data []byte) error {
+	in.Sig = data
+	return nil
+}
+
 // -------
 // Helpers
 // -------
@@ -146,6 +196,10 @@ func inputFromInputType(inputType InputType, outputHash []byte) (Input, error) {
 		return &WriterInput{InputLink: InputLink{BytesToHash(outputHash)}}, nil
 	case INPUT_TYPE_ROW_WRITER:
 		return &RowWriterInput{InputLink: InputLink{BytesToHash(outputHash)}}, nil
+	case INPUT_TYPE_MEMBER:
+		return &MemberInput{InputLink: InputLink{BytesToHash(outputHash)}}, nil
+	case INPUT_TYPE_COL_WRITER:
+		return &ColWriterInput{InputLink: InputLink{BytesToHash(outputHash)}}, nil
 	default:
 		return nil, errors.New(fmt.Sprintf("Invalid input type %d\n", inputType))
 	}
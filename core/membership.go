@@ -0,0 +1,77 @@
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Heartbeat of a node as seen by this node
+type seenHeartbeat struct {
+	beatAt int64 // On the clock of the node
+	seenAt int64 // On the clock of this node
+}
+
+// Latest heartbeats seen by this node, by the public key of the node
+type heartbeatState struct {
+	lock sync.Mutex
+	seen map[string]*seenHeartbeat
+}
+
+func newHeartbeatState() *heartbeatState {
+	return &heartbeatState{seen: make(map[string]*seenHeartbeat)}
+}
+
+// Writes a heartbeat for this node, which tells the federation that this node is alive.
+func (bc *Blockchain) WriteHeartbeat() error {
+	return bc.db.WriteHeartbeat(&meddb.Heartbeat{
+		Node:   bc.me.PubKey,
+		BeatAt: big.NewInt(bc.clock()),
+	})
+}
+
+// Returns the nodes in the federation whose heartbeat changed within the heartbeat stale age, in
+// the order of the federation.
+// Heartbeats are timestamped with the clock of the node that wrote them, which can be off from the
+// clock of this node. So the age of a heartbeat is measured on the clock of this node, from the
+// first call that saw it. A heartbeat that has not been seen before cannot be older than its own
+// timestamp, so that is used as long as it is not in the future. The more often this is called,
+// the sooner nodes that stopped are seen as dead.
+func (bc *Blockchain) LiveNodes() ([]*Node, error) {
+	hbs, err := bc.db.GetHeartbeats()
+	if err != nil {
+		return nil, err
+	}
+
+	now := bc.clock()
+	s := bc.heartbeats
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, hb := range hbs {
+		if hb.BeatAt == nil {
+			continue
+		}
+		beatAt := hb.BeatAt.Int64()
+		seen, ok := s.seen[string(hb.Node)]
+		if !ok {
+			seenAt := beatAt
+			if seenAt > now {
+				seenAt = now
+			}
+			s.seen[string(hb.Node)] = &seenHeartbeat{beatAt: beatAt, seenAt: seenAt}
+		} else if seen.beatAt != beatAt {
+			seen.beatAt, seen.seenAt = beatAt, now
+		}
+	}
+
+	oldest := now - bc.heartbeatStaleAgeMS
+	live := make([]*Node, 0)
+	for _, node := range bc.Federation() {
+		if seen, ok := s.seen[string(node.PubKey)]; ok && seen.seenAt >= oldest {
+			live = append(live, node)
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/common"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+func TestWriteHeartbeat(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	me := &Node{PubKey: []byte{42}}
+	bc := NewBlockchain(db, nil, me, []*Node{me})
+	assert.Nil(t, bc.WriteHeartbeat())
+
+	hbs, err := db.GetHeartbeats()
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(hbs)) {
+		assert.Equal(t, me.PubKey, hbs[0].Node)
+		assertRecent(t, hbs[0].BeatAt.Int64())
+	}
+}
+
+func TestLiveNodes(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	nodes := getAssignmentNodes()
+	now := common.Now()
+	assert.Nil(t, db.WriteHeartbeat(&meddb.Heartbeat{
+		Node:   nodes[0].PubKey,
+		BeatAt: big.NewInt(now),
+	}))
+	assert.Nil(t, db.WriteHeartbeat(&meddb.Heartbeat{
+		Node:   nodes[1].PubKey,
+		BeatAt: big.NewInt(now - DEFAULT_HEARTBEAT_STALE_AGE_MS - 1000),
+	}))
+	// Heartbeats of nodes outside of the federation are ignored
+	assert.Nil(t, db.WriteHeartbeat(&meddb.Heartbeat{Node: []byte{99}, BeatAt: big.NewInt(now)}))
+
+	bc := NewBlockchain(db, nil, nil, nodes)
+	live, err := bc.LiveNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[0]}, live)
+
+	bc.SetHeartbeatStaleAge(DEFAULT_HEARTBEAT_STALE_AGE_MS + 5000)
+	live, err = bc.LiveNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[0], nodes[1]}, live)
+}
+
+func TestAddTransactionsSkipsStaleNodes(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	nodes := getAssignmentNodes()
+	bc := NewBlockchain(db, nil, nodes[0], nodes)
+
+	// Nobody else is alive, so this node has to build the blocks
+	txs := getAssignmentTransactions(3)
+	assert.Equal(t, []error{nil, nil, nil}, bc.AddTransactions(txs))
+	for _, tx := range txs {
+		assert.Equal(t, nodes[0].PubKey, tx.AssignedTo)
+	}
+
+	assert.Nil(t, NewBlockchain(db, nil, nodes[2], nodes).WriteHeartbeat())
+	assert.Equal(t, []error{nil, nil, nil}, bc.AddTransactions(txs))
+	for _, tx := range txs {
+		assert.Equal(t, nodes[2].PubKey, tx.AssignedTo)
+	}
+}
+
+func TestLiveNodesClockSkew(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	nodes := getAssignmentNodes()
+	var now int64 = 1000000000
+	bc := NewBlockchain(db, nil, nil, nodes)
+	bc.SetClock(func() int64 { return now })
+
+	beat := func(node *Node, beatAt int64) {
+		assert.Nil(t, db.WriteHeartbeat(&meddb.Heartbeat{
+			Node:   node.PubKey,
+			BeatAt: big.NewInt(beatAt),
+		}))
+	}
+	// Clock of the first node is an hour behind, clock of the second node an hour ahead
+	beat(nodes[0], now-3600000)
+	beat(nodes[1], now+3600000)
+
+	live, err := bc.LiveNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[1]}, live)
+
+	// Heartbeats are judged by when they change on the clock of this node
+	now += DEFAULT_HEARTBEAT_STALE_AGE_MS / 2
+	beat(nodes[0], now-3600000)
+	live, err = bc.LiveNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[0], nodes[1]}, live)
+
+	now += DEFAULT_HEARTBEAT_STALE_AGE_MS
+	beat(nodes[0], now-3600000)
+	live, err = bc.LiveNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Node{nodes[0]}, live)
+}
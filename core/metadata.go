@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -25,6 +26,9 @@ const (
 	TABLE_METADATA_WRITERS
 	TABLE_METADATA_ROW_RULES
 	TABLE_METADATA_COL_RULES
+	TABLE_METADATA_GENERATION
+	TABLE_METADATA_COL_SCHEMAS
+	TABLE_METADATA_RESTRICTED_COLS
 	TABLE_METADATA_ALL TableMetadataFlag = 0
 )
 
@@ -33,10 +37,13 @@ const TABLE_METADATA_TABLE = "table_metadata"
 
 // Map from TableMetadataFlag to the column name
 var TABLE_METADATA_MAP = map[TableMetadataFlag]string{
-	TABLE_METADATA_ADMINS:    "admins",
-	TABLE_METADATA_WRITERS:   "writers",
-	TABLE_METADATA_ROW_RULES: "row_rules",
-	TABLE_METADATA_COL_RULES: "col_rules",
+	TABLE_METADATA_ADMINS:          "admins",
+	TABLE_METADATA_WRITERS:         "writers",
+	TABLE_METADATA_ROW_RULES:       "row_rules",
+	TABLE_METADATA_COL_RULES:       "col_rules",
+	TABLE_METADATA_GENERATION:      "generation",
+	TABLE_METADATA_COL_SCHEMAS:     "col_schemas",
+	TABLE_METADATA_RESTRICTED_COLS: "restricted_cols",
 }
 
 // --------------------------
@@ -52,11 +59,14 @@ type ColRules struct {
 }
 
 type TableMetadata struct {
-	TableName []byte    // Name of the table this metadata is for
-	Admins    [][]byte  // List of public keys of admins of this table
-	Writers   [][]byte  // List of public keys that can write to this table
-	RowRules  *RowRules // Row level rules
-	ColRules  *ColRules // Col level rules
+	TableName      []byte       // Name of the table this metadata is for
+	Admins         [][]byte     // List of public keys of admins of this table
+	Writers        [][]byte     // List of public keys that can write to this table
+	RowRules       *RowRules    // Row level rules
+	ColRules       *ColRules    // Col level rules
+	Generation     *big.Int     // Number of times the table has been dropped before it was created
+	ColSchemas     []*ColSchema // Types of the columns, columns without a schema can hold any data
+	RestrictedCols [][]byte     // Col ids that only their col writers can write to
 }
 
 // Writes non-null fields (specified by flag) of TableMetadata to bigtable
@@ -110,6 +120,31 @@ func (tm *TableMetadata) Read(bt meddb.Bigtable, flag TableMetadataFlag) error {
 	return nil
 }
 
+// Adds the admins, writers, allowed columns, column schemas and restricted columns granted by the
+// given outputs to the metadata. Entries that already exist are not added again, schemas replace
+// the schema that their column had before.
+func (tm *TableMetadata) addOutputs(outputs []Output) {
+	for _, output := range outputs {
+		switch o := output.(type) {
+		case *AdminOutput:
+			tm.Admins = appendUnique(tm.Admins, o.PubKey)
+		case *WriterOutput:
+			tm.Writers = appendUnique(tm.Writers, o.PubKey)
+		case *ColAllowedOutput:
+			if tm.ColRules == nil {
+				tm.ColRules = &ColRules{}
+			}
+			tm.ColRules.AllowedColIds = appendUnique(tm.ColRules.AllowedColIds, o.ColName)
+		case *TableGenerationOutput:
+			tm.Generation = big.NewInt(o.Generation.Int64())
+		case *ColSchemaOutput:
+			tm.ColSchemas = setColSchema(tm.ColSchemas, o.Schema())
+		case *RestrictedColOutput:
+			tm.RestrictedCols = appendUnique(tm.RestrictedCols, o.ColName)
+		}
+	}
+}
+
 // Returns rlp encoded attribute as byte string
 func (tm *TableMetadata) getRlpAttribute(flag TableMetadataFlag) ([]byte, error) {
 	var o interface{}
@@ -122,6 +157,12 @@ func (tm *TableMetadata) getRlpAttribute(flag TableMetadataFlag) ([]byte, error)
 		o = tm.RowRules
 	case TABLE_METADATA_COL_RULES:
 		o = tm.ColRules
+	case TABLE_METADATA_GENERATION:
+		o = tm.Generation
+	case TABLE_METADATA_COL_SCHEMAS:
+		o = tm.ColSchemas
+	case TABLE_METADATA_RESTRICTED_COLS:
+		o = tm.RestrictedCols
 	default:
 		return nil, errors.New(fmt.Sprintf("Invalid TableMetadataFlag: %d\n", flag))
 	}
@@ -149,6 +190,12 @@ func (tm *TableMetadata) setRlpAttribute(flag TableMetadataFlag, b []byte) error
 		o = new(RowRules)
 	case TABLE_METADATA_COL_RULES:
 		o = new(ColRules)
+	case TABLE_METADATA_GENERATION:
+		o = new(big.Int)
+	case TABLE_METADATA_COL_SCHEMAS:
+		o = &[]*ColSchema{}
+	case TABLE_METADATA_RESTRICTED_COLS:
+		o = &[][]byte{}
 	default:
 		return errors.New(fmt.Sprintf("Invalid TableMetadataFlag: %d\n", flag))
 	}
@@ -166,7 +213,23 @@ func (tm *TableMetadata) setRlpAttribute(flag TableMetadataFlag, b []byte) error
 		tm.RowRules = o.(*RowRules)
 	case TABLE_METADATA_COL_RULES:
 		tm.ColRules = o.(*ColRules)
+	case TABLE_METADATA_GENERATION:
+		tm.Generation = o.(*big.Int)
+	case TABLE_METADATA_COL_SCHEMAS:
+		tm.ColSchemas = *o.(*[]*ColSchema)
+	case TABLE_METADATA_RESTRICTED_COLS:
+		tm.RestrictedCols = *o.(*[][]byte)
 		// Default case will never happen
 	}
 	return nil
 }
+
+// Appends b to list if list does not contain it yet.
+func appendUnique(list [][]byte, b []byte) [][]byte {
+	for _, item := range list {
+		if bytes.Equal(item, b) {
+			return list
+		}
+	}
+	return append(list, b)
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,11 +16,21 @@ func TestTableMetadataReadWrite(t *testing.T) {
 
 	tableName := []byte("Some table")
 	meta := &TableMetadata{
-		TableName: tableName,
-		Admins:    [][]byte{[]byte("me")},
-		Writers:   [][]byte{[]byte("me"), []byte("you")},
-		RowRules:  &RowRules{Type: intToBigInt(int(ROW_RULE_ALL))},
-		ColRules:  &ColRules{AllowedColIds: [][]byte{[]byte("stuff")}},
+		TableName:  tableName,
+		Admins:     [][]byte{[]byte("me")},
+		Writers:    [][]byte{[]byte("me"), []byte("you")},
+		RowRules:   &RowRules{Type: intToBigInt(int(ROW_RULE_ALL))},
+		ColRules:   &ColRules{AllowedColIds: [][]byte{[]byte("stuff")}},
+		Generation: big.NewInt(2),
+		ColSchemas: []*ColSchema{
+			&ColSchema{
+				ColId:     []byte("stuff"),
+				Type:      intToBigInt(int(COL_TYPE_JSON)),
+				MaxLength: big.NewInt(128),
+				Nullable:  true,
+			},
+		},
+		RestrictedCols: [][]byte{[]byte("stuff")},
 	}
 
 	err = meta.Write(bt, TABLE_METADATA_ALL)
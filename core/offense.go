@@ -0,0 +1,92 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Record of an invalid vote, kept to audit the voters that cast them.
+type VoteOffense struct {
+	VoteHash   Hash
+	Voter      []byte // Public key claimed by the vote
+	BlockId    Hash   // Block that was voted on
+	ErrorType  string // Name of the type of the validation error, i.e. VoterNotEligibleError
+	Message    string
+	Node       []byte // Public key of node that found the offense
+	RecordedAt *big.Int
+}
+
+// Creates an offense found by this node for the given invalid vote and validation error.
+func (bc *Blockchain) NewVoteOffense(v *Vote, err error) *VoteOffense {
+	return &VoteOffense{
+		VoteHash:   v.Hash(),
+		Voter:      v.Voter,
+		BlockId:    v.NextBlock,
+		ErrorType:  errorTypeName(err),
+		Message:    err.Error(),
+		Node:       bc.me.PubKey,
+		RecordedAt: big.NewInt(bc.clock()),
+	}
+}
+
+// Writes offenses to the offense table.
+func (bc *Blockchain) WriteVoteOffenses(offenses []*VoteOffense) error {
+	dbOffenses := make([]*meddb.VoteOffense, len(offenses))
+	for i, o := range offenses {
+		dbOffenses[i] = o.toDBVoteOffense()
+	}
+	return bc.db.WriteVoteOffenses(dbOffenses)
+}
+
+// Returns all recorded offenses of the voter with the given public key.
+func (bc *Blockchain) GetVoteOffenses(voter []byte) ([]*VoteOffense, error) {
+	dbOffenses, err := bc.db.GetVoteOffenses(voter)
+	if err != nil {
+		return nil, err
+	}
+
+	offenses := make([]*VoteOffense, len(dbOffenses))
+	for i, dbO := range dbOffenses {
+		offenses[i] = fromDBVoteOffense(dbO)
+	}
+	return offenses, nil
+}
+
+// -------
+// Helpers
+// -------
+
+func (o *VoteOffense) toDBVoteOffense() *meddb.VoteOffense {
+	var recordedAt *big.Int = nil
+	if o.RecordedAt != nil {
+		recordedAt = big.NewInt(o.RecordedAt.Int64())
+	}
+
+	return &meddb.VoteOffense{
+		VoteHash:   o.VoteHash.Bytes(),
+		Voter:      o.Voter,
+		BlockId:    o.BlockId.Bytes(),
+		ErrorType:  o.ErrorType,
+		Message:    o.Message,
+		Node:       o.Node,
+		RecordedAt: recordedAt,
+	}
+}
+
+func fromDBVoteOffense(o *meddb.VoteOffense) *VoteOffense {
+	var recordedAt *big.Int = nil
+	if o.RecordedAt != nil {
+		recordedAt = big.NewInt(o.RecordedAt.Int64())
+	}
+
+	return &VoteOffense{
+		VoteHash:   BytesToHash(o.VoteHash),
+		Voter:      o.Voter,
+		BlockId:    BytesToHash(o.BlockId),
+		ErrorType:  o.ErrorType,
+		Message:    o.Message,
+		Node:       o.Node,
+		RecordedAt: recordedAt,
+	}
+}
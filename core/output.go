@@ -22,6 +22,12 @@ const (
 	OUTPUT_TYPE_WRITER                             // WRITER           = 6
 	OUTPUT_TYPE_ALL_ROW_WRITERS                    // ALL_ROW_WRITERS  = 7
 	OUTPUT_TYPE_ROW_WRITER                         // ROW_WRITER       = 8
+	OUTPUT_TYPE_NODE                               // NODE             = 9
+	OUTPUT_TYPE_TABLE_DROPPED                      // TABLE_DROPPED    = 10
+	OUTPUT_TYPE_TABLE_GENERATION                   // TABLE_GENERATION = 11
+	OUTPUT_TYPE_COL_SCHEMA                         // COL_SCHEMA       = 12
+	OUTPUT_TYPE_RESTRICTED_COL                     // RESTRICTED_COL   = 13
+	OUTPUT_TYPE_COL_WRITER                         // COL_WRITER       = 14
 )
 
 type Output interface {
@@ -293,10 +299,223 @@ func (o *RowWriterOutput) FromData(data []byte) error {
 	return nil
 }
 
+// --------------------------------
+// NodeOutput implementation
+//
+// Allows a particular node to sign changes to the federation while it is part of it. Not tied to
+// a table.
+// --------------------------------
+
+type NodeOutput struct {
+	*TableNameMixin
+	PubKey []byte
+}
+
+func (o *NodeOutput) Type() OutputType {
+	return OUTPUT_TYPE_NODE
+}
+
+func (o *NodeOutput) Data() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(o)
+	return data
+}
+
+func (o *NodeOutput) FromData(data []byte) error {
+	if err := rlpDecode(data, o); err != nil {
+		return err
+	}
+	return nil
+}
+
+// --------------------------------
+// RestrictedColOutput implementation
+//
+// Signals that only the col writers of the column can write to it
+// --------------------------------
+
+type RestrictedColOutput struct {
+	*TableNameMixin
+	ColName []byte
+}
+
+func (o *RestrictedColOutput) Type() OutputType {
+	return OUTPUT_TYPE_RESTRICTED_COL
+}
+
+func (o *RestrictedColOutput) Data() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(o)
+	return data
+}
+
+func (o *RestrictedColOutput) FromData(data []byte) error {
+	if err := rlpDecode(data, o); err != nil {
+		return err
+	}
+	return nil
+}
+
+// --------------------------------
+// ColWriterOutput implementation
+//
+// Allows a particular user to write to a restricted column of a table
+// --------------------------------
+
+type ColWriterOutput struct {
+	*TableNameMixin
+	ColName []byte
+	PubKey  []byte
+}
+
+func (o *ColWriterOutput) Type() OutputType {
+	return OUTPUT_TYPE_COL_WRITER
+}
+
+func (o *ColWriterOutput) Data() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(o)
+	return data
+}
+
+func (o *ColWriterOutput) FromData(data []byte) error {
+	if err := rlpDecode(data, o); err != nil {
+		return err
+	}
+	return nil
+}
+
+// --------------------------------
+// TableDroppedOutput implementation
+//
+// Used to check whether a generation of a table has been dropped. Every time a dropped table is
+// created again it gets the next generation, the first generation is 0.
+// --------------------------------
+
+type TableDroppedOutput struct {
+	*TableNameMixin
+	Generation *big.Int
+}
+
+func (o *TableDroppedOutput) Type() OutputType {
+	return OUTPUT_TYPE_TABLE_DROPPED
+}
+
+func (o *TableDroppedOutput) Data() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(o)
+	return data
+}
+
+func (o *TableDroppedOutput) FromData(data []byte) error {
+	if err := rlpDecode(data, o); err != nil {
+		return err
+	}
+	return nil
+}
+
+// --------------------------------
+// TableGenerationOutput implementation
+//
+// Used to check whether a dropped table has been created again under the given generation. Takes
+// the place of the TableExistsOutput for all generations after the first one.
+// --------------------------------
+
+type TableGenerationOutput struct {
+	*TableNameMixin
+	Generation *big.Int
+}
+
+func (o *TableGenerationOutput) Type() OutputType {
+	return OUTPUT_TYPE_TABLE_GENERATION
+}
+
+func (o *TableGenerationOutput) Data() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(o)
+	return data
+}
+
+func (o *TableGenerationOutput) FromData(data []byte) error {
+	if err := rlpDecode(data, o); err != nil {
+		return err
+	}
+	return nil
+}
+
+// --------------------------------
+// ColSchemaOutput implementation
+//
+// Declares the type of the data that the cells of a column hold, see ColSchema. Replaces the
+// schema that the column had before.
+// --------------------------------
+
+type ColSchemaOutput struct {
+	*TableNameMixin
+	ColName   []byte
+	ColType   *big.Int
+	MaxLength *big.Int
+	Nullable  bool
+}
+
+func (o *ColSchemaOutput) Type() OutputType {
+	return OUTPUT_TYPE_COL_SCHEMA
+}
+
+func (o *ColSchemaOutput) Data() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(o)
+	return data
+}
+
+func (o *ColSchemaOutput) FromData(data []byte) error {
+	if err := rlpDecode(data, o); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Identifies the output by its table and column only, so that the latest schema of a column can
+// be looked up without knowing it, see ColSchemaRule.
+func (o *ColSchemaOutput) Key() []byte {
+	// TODO: Log on error here, should never happen
+	data, _ := rlpEncode(&colSchemaKey{Table: o.TableName(), ColName: o.ColName})
+	return data
+}
+
+// Returns the schema that the output declares.
+func (o *ColSchemaOutput) Schema() *ColSchema {
+	schema := &ColSchema{
+		ColId:     o.ColName,
+		Type:      big.NewInt(0),
+		MaxLength: big.NewInt(0),
+		Nullable:  o.Nullable,
+	}
+	if o.ColType != nil {
+		schema.Type.Set(o.ColType)
+	}
+	if o.MaxLength != nil {
+		schema.MaxLength.Set(o.MaxLength)
+	}
+	return schema
+}
+
 // -------
 // Helpers
 // -------
 
+// Outputs whose id is the hash of a key instead of their data. Versions of the output with the same
+// key share the id, and the one in the latest block is the one that holds.
+type keyedOutput interface {
+	Key() []byte
+}
+
+// Part of a ColSchemaOutput that identifies it
+type colSchemaKey struct {
+	Table   []byte
+	ColName []byte
+}
+
 // Object used to rlpEncode and hash an output.
 // Type field provides coverage for conflicts between different output types.
 type outputHashObject struct {
@@ -304,8 +523,21 @@ type outputHashObject struct {
 	Data []byte
 }
 
-// Hashes rlp encoded outputHashObject with fields filled in.
+// Gets the id of the output, which is the hash of its key for keyed outputs and the hash of all of
+// its data for the others.
 func HashOutput(o Output) Hash {
+	if keyed, ok := o.(keyedOutput); ok {
+		return rlpHash(&outputHashObject{
+			Type: intToBigInt(int(o.Type())),
+			Data: keyed.Key(),
+		})
+	}
+	return hashOutputData(o)
+}
+
+// Hashes rlp encoded outputHashObject with fields filled in. Covers all of the data of the output,
+// unlike the id of keyed outputs.
+func hashOutputData(o Output) Hash {
 	return rlpHash(&outputHashObject{
 		Type: intToBigInt(int(o.Type())),
 		Data: o.Data(),
@@ -342,6 +574,18 @@ func outputFromOutputType(outputType OutputType) (Output, error) {
 		return &AllRowWritersOutput{TableNameMixin: &TableNameMixin{}}, nil
 	case OUTPUT_TYPE_ROW_WRITER:
 		return &RowWriterOutput{TableNameMixin: &TableNameMixin{}}, nil
+	case OUTPUT_TYPE_NODE:
+		return &NodeOutput{TableNameMixin: &TableNameMixin{}}, nil
+	case OUTPUT_TYPE_TABLE_DROPPED:
+		return &TableDroppedOutput{TableNameMixin: &TableNameMixin{}}, nil
+	case OUTPUT_TYPE_TABLE_GENERATION:
+		return &TableGenerationOutput{TableNameMixin: &TableNameMixin{}}, nil
+	case OUTPUT_TYPE_COL_SCHEMA:
+		return &ColSchemaOutput{TableNameMixin: &TableNameMixin{}}, nil
+	case OUTPUT_TYPE_RESTRICTED_COL:
+		return &RestrictedColOutput{TableNameMixin: &TableNameMixin{}}, nil
+	case OUTPUT_TYPE_COL_WRITER:
+		return &ColWriterOutput{TableNameMixin: &TableNameMixin{}}, nil
 	default:
 		return nil, errors.New(fmt.Sprintf("Invalid output type %d\n", outputType))
 	}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,3 +29,26 @@ func TestNewOutputFromMapInvalidField(t *testing.T) {
 	})
 	assert.IsType(t, errors.New(""), err)
 }
+
+func TestHashKeyedOutput(t *testing.T) {
+	schemaOutput := func(colName string, colType ColType) *ColSchemaOutput {
+		return &ColSchemaOutput{
+			TableNameMixin: &TableNameMixin{[]byte("cars")},
+			ColName:        []byte(colName),
+			ColType:        intToBigInt(int(colType)),
+			MaxLength:      big.NewInt(0),
+		}
+	}
+	wheels := schemaOutput("wheels", COL_TYPE_INT64)
+	otherWheels := schemaOutput("wheels", COL_TYPE_STRING)
+
+	// Versions of the schema of a column share the id
+	assert.Equal(t, HashOutput(wheels), HashOutput(otherWheels))
+	assert.NotEqual(t, HashOutput(wheels), HashOutput(schemaOutput("doors", COL_TYPE_INT64)))
+
+	// Transactions still tell the versions apart
+	assert.NotEqual(t, hashOutputData(wheels), hashOutputData(otherWheels))
+	tx := &Transaction{Type: TRANSACTION_TYPE_UPDATE_TABLE, Outputs: []Output{wheels}}
+	otherTx := &Transaction{Type: TRANSACTION_TYPE_UPDATE_TABLE, Outputs: []Output{otherWheels}}
+	assert.NotEqual(t, tx.Hash(), otherTx.Hash())
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Record of a transaction that was dropped from the backlog because it was invalid.
+type Rejection struct {
+	TxHash     Hash
+	ErrorType  string // Name of the type of the validation error, i.e. MissingOutputsError
+	Message    string
+	Node       []byte // Public key of node that rejected the transaction
+	RejectedAt *big.Int
+}
+
+// Creates a rejection of the transaction by this node for the given validation error.
+func (bc *Blockchain) NewRejection(tx *Transaction, err error) *Rejection {
+	return &Rejection{
+		TxHash:     tx.Hash(),
+		ErrorType:  errorTypeName(err),
+		Message:    err.Error(),
+		Node:       bc.me.PubKey,
+		RejectedAt: big.NewInt(bc.clock()),
+	}
+}
+
+// Writes rejections to the rejection table.
+func (bc *Blockchain) WriteRejections(rejs []*Rejection) error {
+	dbRejs := make([]*meddb.Rejection, len(rejs))
+	for i, rej := range rejs {
+		dbRejs[i] = rej.toDBRejection()
+	}
+	return bc.db.WriteRejections(dbRejs)
+}
+
+// Returns the latest rejection of the transaction with the given hash.
+// Returns nil if the transaction has not been rejected, or if the rejection has been deleted.
+func (bc *Blockchain) GetRejection(txHash Hash) (*Rejection, error) {
+	dbRej, err := bc.db.GetRejection(txHash.Bytes())
+	if err != nil {
+		if _, ok := err.(*meddb.NotFoundError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fromDBRejection(dbRej), nil
+}
+
+// Deletes rejections that are at least retention old from the rejection table.
+func (bc *Blockchain) DeleteOldRejections(retention int64) error {
+	return bc.db.DeleteRejections(bc.clock() - retention)
+}
+
+// -------
+// Helpers
+// -------
+
+// Returns the name of the type of the error without the package and pointer.
+func errorTypeName(err error) string {
+	t := reflect.TypeOf(err)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func (rej *Rejection) toDBRejection() *meddb.Rejection {
+	var rejectedAt *big.Int = nil
+	if rej.RejectedAt != nil {
+		rejectedAt = big.NewInt(rej.RejectedAt.Int64())
+	}
+
+	return &meddb.Rejection{
+		TxHash:     rej.TxHash.Bytes(),
+		ErrorType:  rej.ErrorType,
+		Message:    rej.Message,
+		Node:       rej.Node,
+		RejectedAt: rejectedAt,
+	}
+}
+
+func fromDBRejection(rej *meddb.Rejection) *Rejection {
+	var rejectedAt *big.Int = nil
+	if rej.RejectedAt != nil {
+		rejectedAt = big.NewInt(rej.RejectedAt.Int64())
+	}
+
+	return &Rejection{
+		TxHash:     BytesToHash(rej.TxHash),
+		ErrorType:  rej.ErrorType,
+		Message:    rej.Message,
+		Node:       rej.Node,
+		RejectedAt: rejectedAt,
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+func TestNewRejection(t *testing.T) {
+	bc := NewBlockchain(nil, nil, &Node{PubKey: []byte("me")}, nil)
+	tx := getStatusTransaction("tesla")
+	err := &RuleErrors{Errors: nil}
+
+	rej := bc.NewRejection(tx, err)
+	assert.Equal(t, tx.Hash(), rej.TxHash)
+	assert.Equal(t, "RuleErrors", rej.ErrorType)
+	assert.Equal(t, err.Error(), rej.Message)
+	assert.Equal(t, []byte("me"), rej.Node)
+	assert.NotNil(t, rej.RejectedAt)
+}
+
+func TestGetRejection(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bc := NewBlockchain(db, nil, &Node{PubKey: []byte("me")}, nil)
+	tx := getStatusTransaction("tesla")
+
+	rej, err := bc.GetRejection(tx.Hash())
+	assert.Nil(t, err)
+	assert.Nil(t, rej)
+
+	expected := bc.NewRejection(tx, &MissingOutputsError{})
+	assert.Nil(t, bc.WriteRejections([]*Rejection{expected}))
+
+	rej, err = bc.GetRejection(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, rej)
+}
+
+func TestDeleteOldRejections(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bc := NewBlockchain(db, nil, &Node{PubKey: []byte("me")}, nil)
+	oldTx := getStatusTransaction("tesla")
+	newTx := getStatusTransaction("ford")
+
+	oldRej := bc.NewRejection(oldTx, &MissingOutputsError{})
+	oldRej.RejectedAt = big.NewInt(0)
+	newRej := bc.NewRejection(newTx, &MissingOutputsError{})
+	assert.Nil(t, bc.WriteRejections([]*Rejection{oldRej, newRej}))
+
+	assert.Nil(t, bc.DeleteOldRejections(60000))
+
+	rej, err := bc.GetRejection(oldTx.Hash())
+	assert.Nil(t, err)
+	assert.Nil(t, rej)
+	rej, err = bc.GetRejection(newTx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, newRej, rej)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/wojtechnology/glacier/crypto"
 )
@@ -30,38 +31,89 @@ const (
 
 // --------------------------------
 // TableExistsOutputMixin
+//
+// Looks up the outputs that tell whether the generation of the table that the transaction is
+// validated against exists. The generation is the number of times the table has been dropped,
+// see Blockchain.getTableGenerations.
 // --------------------------------
 
-type TableExistsOutputMixin struct{}
+type TableExistsOutputMixin struct {
+	generation int64
+}
 
 func (mixin *TableExistsOutputMixin) getTableExistsOutputHash(tx *Transaction) Hash {
-	return HashOutput(&TableExistsOutput{&TableNameMixin{tx.TableName}})
+	if mixin.generation == 0 {
+		return HashOutput(&TableExistsOutput{&TableNameMixin{tx.TableName}})
+	}
+	return HashOutput(&TableGenerationOutput{
+		TableNameMixin: &TableNameMixin{tx.TableName},
+		Generation:     big.NewInt(mixin.generation),
+	})
+}
+
+func (mixin *TableExistsOutputMixin) getTableDroppedOutputHash(tx *Transaction) Hash {
+	return HashOutput(&TableDroppedOutput{
+		TableNameMixin: &TableNameMixin{tx.TableName},
+		Generation:     big.NewInt(mixin.generation),
+	})
 }
 
 func (mixin *TableExistsOutputMixin) RequestedOutputIds(
 	tx *Transaction) map[string]OutputRequirement {
 
 	return map[string]OutputRequirement{
-		mixin.getTableExistsOutputHash(tx).String(): OUTPUT_REQUIREMENT_DECIDED,
+		mixin.getTableExistsOutputHash(tx).String():  OUTPUT_REQUIREMENT_DECIDED,
+		mixin.getTableDroppedOutputHash(tx).String(): OUTPUT_REQUIREMENT_DECIDED,
 	}
 }
 
+// Rules that depend on the generation of the table of the transaction
+type generationRule interface {
+	withGeneration(generation int64) Rule
+}
+
+// Returns a copy of the ruleset where the rules that depend on the generation of the table check
+// the given generation.
+func withGeneration(ruleset []Rule, generation int64) []Rule {
+	if generation == 0 {
+		// Rules in the rulesets check the first generation already
+		return ruleset
+	}
+
+	generationRuleset := make([]Rule, len(ruleset))
+	for i, rule := range ruleset {
+		if genRule, ok := rule.(generationRule); ok {
+			generationRuleset[i] = genRule.withGeneration(generation)
+		} else {
+			generationRuleset[i] = rule
+		}
+	}
+	return generationRuleset
+}
+
 // --------------------------------
 // TableExistsRule implementation
 //
-// Used to check whether a table with a given name already exists.
+// Used to check whether a table with a given name already exists and has not been dropped.
 // --------------------------------
 
 type TableExistsRule struct {
 	TableExistsOutputMixin
 }
 
+func (rule *TableExistsRule) withGeneration(generation int64) Rule {
+	return &TableExistsRule{TableExistsOutputMixin{generation: generation}}
+}
+
 func (rule *TableExistsRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
 	spentInputs map[string][]Input) error {
 
 	if _, ok := linkedOutputs[rule.getTableExistsOutputHash(tx).String()]; !ok {
 		return errors.New(fmt.Sprintf("Table does not exist: %v\n", tx.TableName))
 	}
+	if _, ok := linkedOutputs[rule.getTableDroppedOutputHash(tx).String()]; ok {
+		return errors.New(fmt.Sprintf("Table has been dropped: %v\n", tx.TableName))
+	}
 	return nil
 }
 
@@ -75,6 +127,10 @@ type TableMissingRule struct {
 	TableExistsOutputMixin
 }
 
+func (rule *TableMissingRule) withGeneration(generation int64) Rule {
+	return &TableMissingRule{TableExistsOutputMixin{generation: generation}}
+}
+
 func (rule *TableMissingRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
 	spentInputs map[string][]Input) error {
 
@@ -275,6 +331,84 @@ func (rule *WriterRule) Validate(tx *Transaction, linkedOutputs map[string]Outpu
 	return nil
 }
 
+// --------------------------------
+// ColWriterRule implementation
+//
+// Used to check whether user can write to the restricted columns of the transaction. Columns that
+// are not restricted can be written by all writers of the table. DELETE_ROW does not name its
+// columns, so it is only checked by the WriterRule and does not delete the cells of restricted
+// columns when applied, see Blockchain.applyTransaction.
+// --------------------------------
+
+type ColWriterRule struct{}
+
+func (rule *ColWriterRule) getRestrictedColOutputHash(tx *Transaction, colId string) Hash {
+	return HashOutput(&RestrictedColOutput{
+		TableNameMixin: &TableNameMixin{tx.TableName},
+		ColName:        []byte(colId),
+	})
+}
+
+func (rule *ColWriterRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	outputReqs := map[string]OutputRequirement{}
+	for colId, _ := range tx.Cols {
+		// Restrictions that are not decided yet might apply once they are
+		outputReqs[rule.getRestrictedColOutputHash(tx, colId).String()] =
+			OUTPUT_REQUIREMENT_DECIDED
+	}
+	return outputReqs
+}
+
+func (rule *ColWriterRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	authorizedCols := make(map[string]bool)
+	for _, input := range tx.Inputs {
+		colWriterInput, ok := input.(*ColWriterInput)
+		if !ok {
+			continue
+		}
+
+		output, outputExists := linkedOutputs[colWriterInput.OutputHash().String()]
+		if !outputExists {
+			return errors.New(fmt.Sprintf("Output missing for col writer rule: %v\n",
+				colWriterInput.OutputHash().Bytes()))
+		}
+
+		colWriterOutput, outputTypeCorrect := output.(*ColWriterOutput)
+		if !outputTypeCorrect {
+			return errors.New(fmt.Sprintf("Invalid output type for col writer rule: %v\n",
+				output))
+		}
+		if !bytes.Equal(colWriterOutput.TableName(), tx.TableName) {
+			return errors.New(fmt.Sprintf("Col writer output is for another table: %s\n",
+				colWriterOutput.TableName()))
+		}
+
+		pubKey, err := crypto.RetrievePublicKey(tx.Hash().Bytes(), colWriterInput.Sig)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(pubKey, colWriterOutput.PubKey) {
+			return errors.New("Signature invalid\n")
+		}
+		authorizedCols[string(colWriterOutput.ColName)] = true
+	}
+
+	for colId, _ := range tx.Cols {
+		if _, ok := linkedOutputs[rule.getRestrictedColOutputHash(tx, colId).String()]; !ok {
+			continue
+		}
+		if !authorizedCols[colId] {
+			return errors.New(fmt.Sprintf("Must have a col writer input for column %s\n",
+				colId))
+		}
+	}
+
+	return nil
+}
+
 // --------------------------------
 // RowRule implementation
 //
@@ -371,10 +505,17 @@ func (rule *ValidOutputTypesRule) Validate(tx *Transaction, linkedOutputs map[st
 // --------------------------------
 // HasTableExistsRule implementation
 //
-// Used to check whether a transaction has a TABLE_EXISTS output
+// Used to check whether a transaction has a TABLE_EXISTS output, and a TABLE_GENERATION output for
+// the next generation if the table has been dropped before
 // --------------------------------
 
-type HasTableExistsRule struct{}
+type HasTableExistsRule struct {
+	TableExistsOutputMixin
+}
+
+func (rule *HasTableExistsRule) withGeneration(generation int64) Rule {
+	return &HasTableExistsRule{TableExistsOutputMixin{generation: generation}}
+}
 
 func (rule *HasTableExistsRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
 	return map[string]OutputRequirement{}
@@ -383,11 +524,338 @@ func (rule *HasTableExistsRule) RequestedOutputIds(tx *Transaction) map[string]O
 func (rule *HasTableExistsRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
 	spentInputs map[string][]Input) error {
 
+	hasTableExists, hasGeneration := false, rule.generation == 0
+	generationHash := rule.getTableExistsOutputHash(tx)
 	for _, output := range tx.Outputs {
 		if output.Type() == OUTPUT_TYPE_TABLE_EXISTS {
+			hasTableExists = true
+		} else if HashOutput(output) == generationHash {
+			hasGeneration = true
+		}
+	}
+
+	if !hasTableExists {
+		return errors.New(fmt.Sprintf("Transaction doesn't have a TABLE_EXISTS output type"))
+	}
+	if !hasGeneration {
+		return errors.New(fmt.Sprintf("Transaction doesn't have a TABLE_GENERATION output for "+
+			"generation %d\n", rule.generation))
+	}
+	return nil
+}
+
+// --------------------------------
+// HasTableDroppedRule implementation
+//
+// Used to check whether a transaction has a TABLE_DROPPED output for the current generation of the
+// table
+// --------------------------------
+
+type HasTableDroppedRule struct {
+	TableExistsOutputMixin
+}
+
+func (rule *HasTableDroppedRule) withGeneration(generation int64) Rule {
+	return &HasTableDroppedRule{TableExistsOutputMixin{generation: generation}}
+}
+
+func (rule *HasTableDroppedRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	return map[string]OutputRequirement{}
+}
+
+func (rule *HasTableDroppedRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	droppedHash := rule.getTableDroppedOutputHash(tx)
+	for _, output := range tx.Outputs {
+		if HashOutput(output) == droppedHash {
 			return nil
 		}
 	}
 
-	return errors.New(fmt.Sprintf("Transaction doesn't have a TABLE_EXISTS output type"))
+	return errors.New(fmt.Sprintf("Transaction doesn't have a TABLE_DROPPED output for "+
+		"generation %d\n", rule.generation))
+}
+
+// --------------------------------
+// NodeColRule implementation
+//
+// Used to check whether a membership transaction names exactly one node by its public key
+// --------------------------------
+
+type NodeColRule struct{}
+
+func (rule *NodeColRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	return map[string]OutputRequirement{}
+}
+
+func (rule *NodeColRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	cell, ok := tx.Cols[NODE_COL]
+	if !ok || len(tx.Cols) != 1 {
+		return errors.New(fmt.Sprintf("Transaction must only have the column %s\n", NODE_COL))
+	}
+
+	pub := crypto.ParsePublicKey(cell.Data)
+	if pub == nil || pub.X == nil {
+		return errors.New(fmt.Sprintf("Invalid node public key: %v\n", cell.Data))
+	}
+
+	return nil
+}
+
+// --------------------------------
+// HasNodeOutputRule implementation
+//
+// Used to check whether a transaction has a NODE output for the node that joins the federation
+// --------------------------------
+
+type HasNodeOutputRule struct{}
+
+func (rule *HasNodeOutputRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	return map[string]OutputRequirement{}
+}
+
+func (rule *HasNodeOutputRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	if cell, ok := tx.Cols[NODE_COL]; ok {
+		for _, output := range tx.Outputs {
+			nodeOutput, ok := output.(*NodeOutput)
+			if ok && bytes.Equal(nodeOutput.PubKey, cell.Data) {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("Transaction doesn't have a NODE output for the added node\n")
+}
+
+// --------------------------------
+// DeleteRule implementation
+//
+// Used to check whether a delete transaction names the columns it deletes and nothing else
+// --------------------------------
+
+type DeleteRule struct{}
+
+func (rule *DeleteRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	return map[string]OutputRequirement{}
+}
+
+func (rule *DeleteRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	if tx.Type == TRANSACTION_TYPE_DELETE_ROW {
+		if len(tx.Cols) > 0 {
+			return errors.New(fmt.Sprintf("DELETE_ROW cannot have columns. Have %v\n",
+				len(tx.Cols)))
+		}
+		return nil
+	}
+
+	if len(tx.Cols) == 0 {
+		return errors.New("DELETE_CELLS must have at least 1 column\n")
+	}
+	for colId, cell := range tx.Cols {
+		if cell == nil {
+			return errors.New(fmt.Sprintf("Cell missing for column %s\n", colId))
+		}
+		if len(cell.Data) > 0 {
+			return errors.New(fmt.Sprintf("Deleted column %s cannot have data\n", colId))
+		}
+	}
+
+	return nil
+}
+
+// --------------------------------
+// VerIdRule implementation
+//
+// Used to check that a delete does not pick the versions of its tombstones, since those are given
+// by the order in which transactions are applied (see applyVersion)
+// --------------------------------
+
+type VerIdRule struct{}
+
+func (rule *VerIdRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	return map[string]OutputRequirement{}
+}
+
+func (rule *VerIdRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	for colId, cell := range tx.Cols {
+		if cell != nil && cell.VerId != nil {
+			return errors.New(fmt.Sprintf("Column %s cannot have a VerId\n", colId))
+		}
+	}
+	return nil
+}
+
+// --------------------------------
+// ColSchemaRule implementation
+//
+// Used to check whether the schemas that a transaction declares are valid, and whether the cells
+// of a PUT_CELLS transaction conform to the schemas of their columns. The latest COL_SCHEMA output
+// of every column is looked up by its key, see ColSchemaOutput.Key.
+// --------------------------------
+
+type ColSchemaRule struct{}
+
+func (rule *ColSchemaRule) getColSchemaOutputHash(tx *Transaction, colId string) Hash {
+	return HashOutput(&ColSchemaOutput{
+		TableNameMixin: &TableNameMixin{tx.TableName},
+		ColName:        []byte(colId),
+	})
+}
+
+func (rule *ColSchemaRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	outputReqs := map[string]OutputRequirement{}
+	if tx.Type != TRANSACTION_TYPE_PUT_CELLS {
+		return outputReqs
+	}
+	for colId, _ := range tx.Cols {
+		// Schemas that are not decided yet might apply once they are
+		outputReqs[rule.getColSchemaOutputHash(tx, colId).String()] = OUTPUT_REQUIREMENT_DECIDED
+	}
+	return outputReqs
+}
+
+func (rule *ColSchemaRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	if tx.Type != TRANSACTION_TYPE_PUT_CELLS {
+		declared := make(map[string]bool)
+		for _, output := range tx.Outputs {
+			schemaOutput, ok := output.(*ColSchemaOutput)
+			if !ok {
+				continue
+			}
+			if declared[string(schemaOutput.ColName)] {
+				return errors.New(fmt.Sprintf("Column %s has more than 1 schema\n",
+					schemaOutput.ColName))
+			}
+			declared[string(schemaOutput.ColName)] = true
+
+			if err := schemaOutput.Schema().Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for colId, cell := range tx.Cols {
+		output, ok := linkedOutputs[rule.getColSchemaOutputHash(tx, colId).String()]
+		if !ok {
+			// Columns without a schema can hold any data
+			continue
+		}
+		schemaOutput, outputTypeCorrect := output.(*ColSchemaOutput)
+		if !outputTypeCorrect {
+			return errors.New(fmt.Sprintf("Invalid output type for col schema rule: %v\n", output))
+		}
+		schema := schemaOutput.Schema()
+
+		var data []byte = nil
+		if cell != nil {
+			data = cell.Data
+		}
+		if err := schema.Check(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// --------------------------------
+// FederationRule implementation
+//
+// Used to check whether a membership transaction is signed by a quorum of the current federation,
+// and whether the node can join or leave it
+// --------------------------------
+
+type FederationRule struct {
+	members [][]byte // Public keys of the current federation
+	quorum  *Quorum
+}
+
+func (rule *FederationRule) isMember(pubKey []byte) bool {
+	for _, member := range rule.members {
+		if bytes.Equal(member, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *FederationRule) RequestedOutputIds(tx *Transaction) map[string]OutputRequirement {
+	// The outputs of member inputs are always required
+	return map[string]OutputRequirement{}
+}
+
+func (rule *FederationRule) Validate(tx *Transaction, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
+
+	var node []byte = nil
+	if cell, ok := tx.Cols[NODE_COL]; ok {
+		node = cell.Data
+	}
+
+	switch tx.Type {
+	case TRANSACTION_TYPE_ADD_NODE:
+		if rule.isMember(node) {
+			return errors.New(fmt.Sprintf("Node already in federation: %v\n", node))
+		}
+	case TRANSACTION_TYPE_REMOVE_NODE:
+		if !rule.isMember(node) {
+			return errors.New(fmt.Sprintf("Node not in federation: %v\n", node))
+		}
+		if len(rule.members) == 1 {
+			return errors.New("Cannot remove the last node of the federation\n")
+		}
+	}
+
+	signers := make(map[string]bool)
+	for _, input := range tx.Inputs {
+		memberInput, ok := input.(*MemberInput)
+		if !ok {
+			continue
+		}
+
+		output, outputExists := linkedOutputs[memberInput.OutputHash().String()]
+		if !outputExists {
+			return errors.New(fmt.Sprintf("Output missing for federation rule: %v\n",
+				memberInput.OutputHash().Bytes()))
+		}
+
+		nodeOutput, outputTypeCorrect := output.(*NodeOutput)
+		if !outputTypeCorrect {
+			return errors.New(fmt.Sprintf("Invalid output type for federation rule: %v\n", output))
+		}
+
+		pubKey, err := crypto.RetrievePublicKey(tx.Hash().Bytes(), memberInput.Sig)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(pubKey, nodeOutput.PubKey) {
+			return errors.New("Signature invalid\n")
+		}
+
+		if !rule.isMember(pubKey) {
+			return errors.New(fmt.Sprintf("Signer not in federation: %v\n", pubKey))
+		}
+		signers[string(pubKey)] = true
+	}
+
+	threshold := rule.quorum.Threshold(len(rule.members))
+	if len(signers) < threshold {
+		return errors.New(fmt.Sprintf("Need signatures from %d members. Have %d\n", threshold,
+			len(signers)))
+	}
+
+	return nil
 }
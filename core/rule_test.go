@@ -2,9 +2,12 @@ package core
 
 import (
 	"errors"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/crypto"
 )
 
 func TestValidOutputTypesRule(t *testing.T) {
@@ -65,3 +68,235 @@ func TestHasTableExistsRuleInvalid(t *testing.T) {
 
 	assert.IsType(t, errors.New(""), rule.Validate(tx, nil, nil))
 }
+
+func TestDeleteRule(t *testing.T) {
+	rule := &DeleteRule{}
+
+	tx := &Transaction{
+		Type: TRANSACTION_TYPE_DELETE_CELLS,
+		Cols: map[string]*Cell{"wheels": &Cell{}},
+	}
+	assert.Nil(t, rule.Validate(tx, nil, nil))
+
+	tx = &Transaction{Type: TRANSACTION_TYPE_DELETE_ROW}
+	assert.Nil(t, rule.Validate(tx, nil, nil))
+}
+
+func TestDeleteRuleInvalid(t *testing.T) {
+	rule := &DeleteRule{}
+
+	tx := &Transaction{Type: TRANSACTION_TYPE_DELETE_CELLS}
+	assert.IsType(t, errors.New(""), rule.Validate(tx, nil, nil))
+
+	tx = &Transaction{
+		Type: TRANSACTION_TYPE_DELETE_CELLS,
+		Cols: map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+	}
+	assert.IsType(t, errors.New(""), rule.Validate(tx, nil, nil))
+
+	tx = &Transaction{
+		Type: TRANSACTION_TYPE_DELETE_ROW,
+		Cols: map[string]*Cell{"wheels": &Cell{}},
+	}
+	assert.IsType(t, errors.New(""), rule.Validate(tx, nil, nil))
+}
+
+func TestVerIdRule(t *testing.T) {
+	rule := &VerIdRule{}
+
+	tx := &Transaction{Cols: map[string]*Cell{"wheels": &Cell{Data: []byte("4")}}}
+	assert.Nil(t, rule.Validate(tx, nil, nil))
+
+	tx = &Transaction{
+		Cols: map[string]*Cell{"wheels": &Cell{Data: []byte("4"), VerId: big.NewInt(5)}},
+	}
+	assert.IsType(t, errors.New(""), rule.Validate(tx, nil, nil))
+}
+
+func TestHasTableExistsRuleGeneration(t *testing.T) {
+	tableName := []byte("cars")
+	tx := &Transaction{
+		TableName: tableName,
+		Outputs:   []Output{&TableExistsOutput{&TableNameMixin{tableName}}},
+	}
+
+	rule := withGeneration([]Rule{&HasTableExistsRule{}}, 2)[0]
+	assert.IsType(t, errors.New(""), rule.Validate(tx, nil, nil))
+
+	tx.Outputs = append(tx.Outputs, &TableGenerationOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		Generation:     big.NewInt(2),
+	})
+	assert.Nil(t, rule.Validate(tx, nil, nil))
+}
+
+func TestHasTableDroppedRule(t *testing.T) {
+	tableName := []byte("cars")
+	tx := &Transaction{
+		TableName: tableName,
+		Outputs: []Output{
+			&TableDroppedOutput{
+				TableNameMixin: &TableNameMixin{tableName},
+				Generation:     big.NewInt(1),
+			},
+		},
+	}
+
+	assert.IsType(t, errors.New(""), (&HasTableDroppedRule{}).Validate(tx, nil, nil))
+	rule := withGeneration([]Rule{&HasTableDroppedRule{}}, 1)[0]
+	assert.Nil(t, rule.Validate(tx, nil, nil))
+}
+
+func TestWithGeneration(t *testing.T) {
+	ruleset := []Rule{&TableExistsRule{}, &AdminRule{}}
+	assert.Equal(t, ruleset, withGeneration(ruleset, 0))
+
+	generationRuleset := withGeneration(ruleset, 3)
+	assert.Equal(t, &TableExistsRule{TableExistsOutputMixin{generation: 3}}, generationRuleset[0])
+	assert.Equal(t, ruleset[1], generationRuleset[1])
+	// Shared ruleset is not modified
+	assert.Equal(t, &TableExistsRule{}, ruleset[0])
+}
+
+func TestColSchemaRule(t *testing.T) {
+	tableName := []byte("cars")
+	tx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: tableName,
+		Cols: map[string]*Cell{
+			"wheels": &Cell{Data: []byte("4")},
+			"doors":  &Cell{Data: []byte{2}},
+		},
+	}
+	rule := &ColSchemaRule{}
+	wheels := &ColSchemaOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		ColName:        []byte("wheels"),
+		ColType:        intToBigInt(int(COL_TYPE_INT64)),
+	}
+	wheelsId := HashOutput(wheels).String()
+
+	// Schemas are requested for every column and have to be decided
+	assert.Equal(t, OUTPUT_REQUIREMENT_DECIDED, rule.RequestedOutputIds(tx)[wheelsId])
+	assert.Equal(t, 2, len(rule.RequestedOutputIds(tx)))
+
+	// Columns without a schema can hold any data
+	assert.Nil(t, rule.Validate(tx, map[string]Output{}, nil))
+
+	linkedOutputs := map[string]Output{wheelsId: wheels}
+	assert.Nil(t, rule.Validate(tx, linkedOutputs, nil))
+
+	tx.Cols["wheels"] = &Cell{Data: []byte("four")}
+	assert.IsType(t, errors.New(""), rule.Validate(tx, linkedOutputs, nil))
+	tx.Cols["wheels"] = nil
+	assert.IsType(t, errors.New(""), rule.Validate(tx, linkedOutputs, nil))
+}
+
+func TestColSchemaRuleDeclared(t *testing.T) {
+	tableName := []byte("cars")
+	wheels := &ColSchemaOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		ColName:        []byte("wheels"),
+		ColType:        intToBigInt(int(COL_TYPE_INT64)),
+	}
+	tx := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: tableName,
+		Outputs:   []Output{&TableExistsOutput{&TableNameMixin{tableName}}, wheels},
+	}
+	assert.Nil(t, (&ColSchemaRule{}).Validate(tx, nil, nil))
+
+	// Invalid type
+	invalid := &ColSchemaOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		ColName:        []byte("doors"),
+		ColType:        big.NewInt(100),
+	}
+	tx.Outputs = []Output{wheels, invalid}
+	assert.IsType(t, errors.New(""), (&ColSchemaRule{}).Validate(tx, nil, nil))
+
+	// Same column twice
+	tx.Outputs = []Output{wheels, wheels}
+	assert.IsType(t, errors.New(""), (&ColSchemaRule{}).Validate(tx, nil, nil))
+}
+
+func TestColWriterRule(t *testing.T) {
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	writer := NewNode(priv)
+
+	tableName := []byte("cars")
+	restricted := &RestrictedColOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		ColName:        []byte("price"),
+	}
+	colWriter := &ColWriterOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		ColName:        []byte("price"),
+		PubKey:         writer.PubKey,
+	}
+	linkedOutputs := map[string]Output{
+		HashOutput(restricted).String(): restricted,
+		HashOutput(colWriter).String():  colWriter,
+	}
+
+	tx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: tableName,
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+	}
+	rule := &ColWriterRule{}
+	assert.Equal(t, 1, len(rule.RequestedOutputIds(tx)))
+	// Column is not restricted
+	assert.Nil(t, rule.Validate(tx, linkedOutputs, nil))
+
+	tx.Cols["price"] = &Cell{Data: []byte("40000")}
+	assert.IsType(t, errors.New(""), rule.Validate(tx, linkedOutputs, nil))
+
+	tx.Inputs = []Input{&ColWriterInput{InputLink: InputLink{HashOutput(colWriter)}}}
+	sig, err := crypto.Sign(tx.Hash().Bytes(), priv)
+	assert.Nil(t, err)
+	tx.Inputs[0].FromData(sig)
+	assert.Nil(t, rule.Validate(tx, linkedOutputs, nil))
+
+	// Signed by someone else
+	other, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	sig, err = crypto.Sign(tx.Hash().Bytes(), other)
+	assert.Nil(t, err)
+	tx.Inputs[0].FromData(sig)
+	assert.IsType(t, errors.New(""), rule.Validate(tx, linkedOutputs, nil))
+}
+
+func TestColWriterRuleOtherTable(t *testing.T) {
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	writer := NewNode(priv)
+
+	restricted := &RestrictedColOutput{
+		TableNameMixin: &TableNameMixin{[]byte("cars")},
+		ColName:        []byte("price"),
+	}
+	colWriter := &ColWriterOutput{
+		TableNameMixin: &TableNameMixin{[]byte("bikes")},
+		ColName:        []byte("price"),
+		PubKey:         writer.PubKey,
+	}
+	linkedOutputs := map[string]Output{
+		HashOutput(restricted).String(): restricted,
+		HashOutput(colWriter).String():  colWriter,
+	}
+
+	tx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"price": &Cell{Data: []byte("40000")}},
+		Inputs:    []Input{&ColWriterInput{InputLink: InputLink{HashOutput(colWriter)}}},
+	}
+	sig, err := crypto.Sign(tx.Hash().Bytes(), priv)
+	assert.Nil(t, err)
+	tx.Inputs[0].FromData(sig)
+	assert.IsType(t, errors.New(""), (&ColWriterRule{}).Validate(tx, linkedOutputs, nil))
+}
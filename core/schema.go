@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Enum for the types of data that the cells of a column can hold
+type ColType int
+
+const (
+	COL_TYPE_BYTES  ColType = iota // BYTES  = 0 - any data
+	COL_TYPE_INT64                 // INT64  = 1 - base 10 integer that fits into an int64
+	COL_TYPE_STRING                // STRING = 2 - UTF-8 encoded text
+	COL_TYPE_BOOL                  // BOOL   = 3 - "true" or "false"
+	COL_TYPE_JSON                  // JSON   = 4 - JSON encoded value
+)
+
+// Type of the data that the cells of a column hold. Cells without data are null.
+type ColSchema struct {
+	ColId     []byte
+	Type      *big.Int // ColType of the data
+	MaxLength *big.Int // Most bytes that the data of a cell can have, 0 means no limit
+	Nullable  bool     // Whether cells can be written without data
+}
+
+// Returns the ColType of the schema. Missing types are BYTES.
+func (schema *ColSchema) ColType() ColType {
+	if schema.Type == nil {
+		return COL_TYPE_BYTES
+	}
+	return ColType(schema.Type.Int64())
+}
+
+// Returns whether the schema is one that cells can be checked against.
+func (schema *ColSchema) Validate() error {
+	if len(schema.ColId) == 0 {
+		return errors.New("Schema must have a column\n")
+	}
+	if t := schema.ColType(); t < COL_TYPE_BYTES || t > COL_TYPE_JSON {
+		return errors.New(fmt.Sprintf("Invalid type %d for column %s\n", t, schema.ColId))
+	}
+	if schema.MaxLength != nil && schema.MaxLength.Sign() < 0 {
+		return errors.New(fmt.Sprintf("Invalid max length %v for column %s\n",
+			schema.MaxLength, schema.ColId))
+	}
+	return nil
+}
+
+// Returns an error if the data of a cell does not conform to the schema.
+func (schema *ColSchema) Check(data []byte) error {
+	if len(data) == 0 {
+		if !schema.Nullable {
+			return errors.New(fmt.Sprintf("Column %s cannot be null\n", schema.ColId))
+		}
+		return nil
+	}
+
+	if schema.MaxLength != nil && schema.MaxLength.Sign() > 0 &&
+		int64(len(data)) > schema.MaxLength.Int64() {
+
+		return errors.New(fmt.Sprintf("Column %s can have at most %v bytes. Have %d\n",
+			schema.ColId, schema.MaxLength, len(data)))
+	}
+
+	valid := true
+	switch schema.ColType() {
+	case COL_TYPE_BYTES:
+	case COL_TYPE_INT64:
+		_, err := strconv.ParseInt(string(data), 10, 64)
+		valid = err == nil
+	case COL_TYPE_STRING:
+		valid = utf8.Valid(data)
+	case COL_TYPE_BOOL:
+		valid = string(data) == "true" || string(data) == "false"
+	case COL_TYPE_JSON:
+		valid = json.Valid(data)
+	default:
+		return errors.New(fmt.Sprintf("Invalid type %d for column %s\n", schema.ColType(),
+			schema.ColId))
+	}
+
+	if !valid {
+		return errors.New(fmt.Sprintf("Invalid data for column %s of type %d: %v\n",
+			schema.ColId, schema.ColType(), data))
+	}
+	return nil
+}
+
+// -------
+// Helpers
+// -------
+
+// Replaces the schema of the same column in schemas with schema, or appends it if the column has
+// no schema yet.
+func setColSchema(schemas []*ColSchema, schema *ColSchema) []*ColSchema {
+	for i, s := range schemas {
+		if bytes.Equal(s.ColId, schema.ColId) {
+			schemas[i] = schema
+			return schemas
+		}
+	}
+	return append(schemas, schema)
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getColSchema(colType ColType, maxLength int64, nullable bool) *ColSchema {
+	return &ColSchema{
+		ColId:     []byte("wheels"),
+		Type:      intToBigInt(int(colType)),
+		MaxLength: big.NewInt(maxLength),
+		Nullable:  nullable,
+	}
+}
+
+func TestColSchemaCheck(t *testing.T) {
+	assert.Nil(t, getColSchema(COL_TYPE_BYTES, 0, false).Check([]byte{0, 255}))
+	assert.Nil(t, getColSchema(COL_TYPE_INT64, 0, false).Check([]byte("-42")))
+	assert.Nil(t, getColSchema(COL_TYPE_STRING, 0, false).Check([]byte("räder")))
+	assert.Nil(t, getColSchema(COL_TYPE_BOOL, 0, false).Check([]byte("false")))
+	assert.Nil(t, getColSchema(COL_TYPE_JSON, 0, false).Check([]byte(`{"wheels": [4]}`)))
+}
+
+func TestColSchemaCheckInvalid(t *testing.T) {
+	err := errors.New("")
+	assert.IsType(t, err, getColSchema(COL_TYPE_INT64, 0, false).Check([]byte{4}))
+	assert.IsType(t, err, getColSchema(COL_TYPE_INT64, 0, false).Check(
+		[]byte("9223372036854775808")))
+	assert.IsType(t, err, getColSchema(COL_TYPE_STRING, 0, false).Check([]byte{0xff, 0xfe}))
+	assert.IsType(t, err, getColSchema(COL_TYPE_BOOL, 0, false).Check([]byte("yes")))
+	assert.IsType(t, err, getColSchema(COL_TYPE_JSON, 0, false).Check([]byte(`{"wheels": `)))
+	assert.IsType(t, err, getColSchema(ColType(100), 0, false).Check([]byte{4}))
+}
+
+func TestColSchemaCheckMaxLength(t *testing.T) {
+	assert.Nil(t, getColSchema(COL_TYPE_BYTES, 3, false).Check([]byte{1, 2, 3}))
+	assert.IsType(t, errors.New(""), getColSchema(COL_TYPE_BYTES, 3, false).Check(
+		[]byte{1, 2, 3, 4}))
+	assert.IsType(t, errors.New(""), getColSchema(COL_TYPE_STRING, 3, false).Check(
+		[]byte("four")))
+}
+
+func TestColSchemaCheckNull(t *testing.T) {
+	assert.IsType(t, errors.New(""), getColSchema(COL_TYPE_BYTES, 0, false).Check(nil))
+	assert.IsType(t, errors.New(""), getColSchema(COL_TYPE_STRING, 0, false).Check([]byte{}))
+	assert.Nil(t, getColSchema(COL_TYPE_INT64, 0, true).Check(nil))
+}
+
+func TestColSchemaValidate(t *testing.T) {
+	assert.Nil(t, getColSchema(COL_TYPE_JSON, 10, true).Validate())
+	assert.Nil(t, (&ColSchema{ColId: []byte("wheels")}).Validate())
+
+	assert.IsType(t, errors.New(""), getColSchema(ColType(5), 0, false).Validate())
+	assert.IsType(t, errors.New(""), getColSchema(ColType(-1), 0, false).Validate())
+	assert.IsType(t, errors.New(""), getColSchema(COL_TYPE_BYTES, -1, false).Validate())
+	assert.IsType(t, errors.New(""), (&ColSchema{}).Validate())
+}
+
+func TestSetColSchema(t *testing.T) {
+	schemas := setColSchema(nil, getColSchema(COL_TYPE_INT64, 0, false))
+	schemas = setColSchema(schemas, &ColSchema{ColId: []byte("doors")})
+	schemas = setColSchema(schemas, getColSchema(COL_TYPE_STRING, 0, false))
+
+	assert.Equal(t, []*ColSchema{
+		getColSchema(COL_TYPE_STRING, 0, false),
+		&ColSchema{ColId: []byte("doors")},
+	}, schemas)
+}
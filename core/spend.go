@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// An input spends the output it links to. Outputs grant rights that can be used over and over, but
+// only by one transaction at a time: while a transaction that spends an output is in an UNDECIDED
+// block, the block builder keeps other transactions that spend the same output in the backlog
+// until that block is decided. Voters only look at the UNDECIDED blocks that come before the block
+// they vote on in the order of the blockchain, so blocks that are built at the same time do not
+// make each other invalid. Within a block, only one transaction can spend each output, which the
+// block builder ensures with ResolveConflicts and voters check with getBlockConflicts. This way
+// the transactions that spend an output are always ordered.
+
+// Returns the inputs of other transactions in ACCEPTED blocks that spend the same outputs as the
+// inputs of the transaction, by output id. inputResponses are the inputs that spend those outputs.
+// Returns SpentOutputsError if another transaction in an UNDECIDED block spends any of them. When
+// votedBlock is set, only UNDECIDED blocks that come before it count.
+func getSpentInputs(tx *Transaction, txHash []byte, inputResponses []*meddb.InputRes,
+	votedBlock *meddb.Block) (map[string][]Input, error) {
+
+	spentInputs := make(map[string][]Input)
+	conflictingOutputIds := make([][]byte, 0)
+	conflicting := make(map[string]bool)
+	for _, inputRes := range inputResponses {
+		// The same transaction can end up in more than one block when it is reassigned
+		if bytes.Equal(txHash, inputRes.TxHash) {
+			continue
+		}
+
+		dbInput := inputRes.Input
+		input, err := NewInput(InputType(dbInput.Type), dbInput.OutputHash, dbInput.Data)
+		if err != nil {
+			return nil, err
+		}
+		outputStrId := input.OutputHash().String()
+
+		switch BlockState(inputRes.Block.State) {
+		case BLOCK_STATE_UNDECIDED:
+			if votedBlock != nil && !isDBBlockBefore(inputRes.Block, votedBlock) {
+				continue
+			}
+			if !conflicting[outputStrId] {
+				conflicting[outputStrId] = true
+				conflictingOutputIds = append(conflictingOutputIds, input.OutputHash().Bytes())
+			}
+		case BLOCK_STATE_ACCEPTED:
+			spentInputs[outputStrId] = append(spentInputs[outputStrId], input)
+		}
+	}
+
+	if len(conflictingOutputIds) > 0 {
+		return nil, &SpentOutputsError{OutputIds: conflictingOutputIds}
+	}
+	return spentInputs, nil
+}
+
+// Returns whether block b comes before the other block in the order of the blockchain.
+func isDBBlockBefore(b, other *meddb.Block) bool {
+	if c := compareBigInts(b.Height, other.Height); c != 0 {
+		return c < 0
+	}
+	return bytes.Compare(b.Hash, other.Hash) < 0
+}
+
+// Splits transactions that go into the same block into transactions that can be in the block and
+// transactions that spend an output that another transaction in the block already spends.
+// Transactions are considered in the order of their hashes and the first one to spend an output
+// wins, so every node resolves the same conflicts the same way. The returned transactions are in
+// that order.
+func ResolveConflicts(txs []*Transaction) ([]*Transaction, []*Transaction) {
+	sorted := sortByHash(txs)
+	valid := make([]*Transaction, 0, len(sorted))
+	conflicting := make([]*Transaction, 0)
+	spent := make(map[string]bool)
+	for _, tx := range sorted {
+		if len(spendOutputs(tx, spent)) > 0 {
+			conflicting = append(conflicting, tx)
+			continue
+		}
+		valid = append(valid, tx)
+	}
+	return valid, conflicting
+}
+
+// Returns a SpentOutputsError for every transaction of the block that spends an output that a
+// transaction before it in the block already spends. Blocks built with ResolveConflicts have none.
+func getBlockConflicts(txs []*Transaction) []error {
+	errs := make([]error, 0)
+	spent := make(map[string]bool)
+	for _, tx := range txs {
+		if outputIds := spendOutputs(tx, spent); len(outputIds) > 0 {
+			errs = append(errs, &SpentOutputsError{OutputIds: outputIds})
+		}
+	}
+	return errs
+}
+
+// Marks the outputs that the transaction spends as spent, unless any of them is spent already.
+// Returns the ids of the outputs that are spent already.
+func spendOutputs(tx *Transaction, spent map[string]bool) [][]byte {
+	outputIds := make([][]byte, 0)
+	for _, input := range tx.Inputs {
+		if spent[input.OutputHash().String()] {
+			outputIds = append(outputIds, input.OutputHash().Bytes())
+		}
+	}
+	if len(outputIds) > 0 {
+		return outputIds
+	}
+
+	for _, input := range tx.Inputs {
+		spent[input.OutputHash().String()] = true
+	}
+	return outputIds
+}
+
+// Returns a copy of the transactions sorted by their hashes, the order that transactions have in a
+// block.
+func sortByHash(txs []*Transaction) []*Transaction {
+	sorted := make([]*Transaction, len(txs))
+	copy(sorted, txs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Hash().Bytes(), sorted[j].Hash().Bytes()) < 0
+	})
+	return sorted
+}
+
+// Returns whether the transactions are sorted by their hashes, without two of the same.
+func isSortedByHash(txs []*Transaction) bool {
+	for i := 1; i < len(txs); i++ {
+		if bytes.Compare(txs[i-1].Hash().Bytes(), txs[i].Hash().Bytes()) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/crypto"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Builds an UPDATE_TABLE transaction on the cars table that is signed by the admin.
+func getSpendTransaction(t *testing.T, admin *Node, colName string) *Transaction {
+	adminOutput := &AdminOutput{
+		TableNameMixin: &TableNameMixin{[]byte("cars")},
+		PubKey:         admin.PubKey,
+	}
+	tx := &Transaction{
+		Type:      TRANSACTION_TYPE_UPDATE_TABLE,
+		TableName: []byte("cars"),
+		Outputs: []Output{
+			&ColAllowedOutput{TableNameMixin: &TableNameMixin{[]byte("cars")},
+				ColName: []byte(colName)},
+		},
+		Inputs: []Input{&AdminInput{InputLink: InputLink{HashOutput(adminOutput)}}},
+	}
+
+	sig, err := crypto.Sign(tx.Hash().Bytes(), admin.PrivKey)
+	assert.Nil(t, err)
+	tx.Inputs[0].FromData(sig)
+	return tx
+}
+
+func writeSpendBlock(t testing.TB, db meddb.BlockchainDB, state BlockState,
+	txs ...*Transaction) {
+
+	b := &Block{Transactions: txs, Height: big.NewInt(0), State: state}
+	assert.Nil(t, db.WriteBlock(b.toDBBlock()))
+}
+
+func getSpendBlockchain(t *testing.T) (*Blockchain, *meddb.MemoryBlockchainDB, *Node) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	admin := NewNode(priv)
+
+	createTx := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: []byte("cars"),
+		Outputs: []Output{
+			&TableExistsOutput{&TableNameMixin{[]byte("cars")}},
+			&AdminOutput{TableNameMixin: &TableNameMixin{[]byte("cars")}, PubKey: admin.PubKey},
+		},
+	}
+	writeSpendBlock(t, db, BLOCK_STATE_ACCEPTED, createTx)
+
+	return NewBlockchain(db, nil, admin, []*Node{admin}), db, admin
+}
+
+// -----
+// Tests
+// -----
+
+func TestValidateTransactionUnspent(t *testing.T) {
+	bc, _, admin := getSpendBlockchain(t)
+
+	tx := getSpendTransaction(t, admin, "wheels")
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+func TestValidateTransactionSpentInAcceptedBlock(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	// Rights can be used again once the transaction that used them is decided
+	writeSpendBlock(t, db, BLOCK_STATE_ACCEPTED, getSpendTransaction(t, admin, "doors"))
+	tx := getSpendTransaction(t, admin, "wheels")
+	assert.Nil(t, bc.ValidateTransaction(tx))
+
+	inputResponses, err := db.GetInputsByOutput([][]byte{tx.Inputs[0].OutputHash().Bytes()})
+	assert.Nil(t, err)
+	spentInputs, err := getSpentInputs(tx, tx.Hash().Bytes(), inputResponses, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(spentInputs))
+	assert.Equal(t, 1, len(spentInputs[tx.Inputs[0].OutputHash().String()]))
+}
+
+func TestValidateTransactionSpentInUndecidedBlock(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	writeSpendBlock(t, db, BLOCK_STATE_UNDECIDED, getSpendTransaction(t, admin, "doors"))
+	tx := getSpendTransaction(t, admin, "wheels")
+	assert.Equal(t, &SpentOutputsError{OutputIds: [][]byte{tx.Inputs[0].OutputHash().Bytes()}},
+		bc.ValidateTransaction(tx))
+}
+
+func TestValidateTransactionSpentInRejectedBlock(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	writeSpendBlock(t, db, BLOCK_STATE_REJECTED, getSpendTransaction(t, admin, "doors"))
+	tx := getSpendTransaction(t, admin, "wheels")
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+func TestValidateTransactionSpentBySameTransaction(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	// The block that the transaction itself is in does not count
+	tx := getSpendTransaction(t, admin, "wheels")
+	writeSpendBlock(t, db, BLOCK_STATE_UNDECIDED, tx)
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+func TestValidateVotedBlockSpentInUndecidedBlock(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	// Blocks that were built at the same time spend the same output
+	first := &Block{
+		Transactions: []*Transaction{getSpendTransaction(t, admin, "doors")},
+		Height:       big.NewInt(1),
+	}
+	second := &Block{
+		Transactions: []*Transaction{getSpendTransaction(t, admin, "wheels")},
+		Height:       big.NewInt(1),
+	}
+	if second.IsBefore(first) {
+		first, second = second, first
+	}
+	assert.Nil(t, db.WriteBlock(first.toDBBlock()))
+	assert.Nil(t, db.WriteBlock(second.toDBBlock()))
+	spentErr := &SpentOutputsError{
+		OutputIds: [][]byte{first.Transactions[0].Inputs[0].OutputHash().Bytes()},
+	}
+
+	// Block builder waits for the other block to be decided
+	assert.Equal(t, spentErr, bc.ValidateTransaction(first.Transactions[0]))
+
+	// Voters only wait for the blocks that come before
+	errs, err := bc.validateTransactions(first.Transactions, first)
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil}, errs)
+	errs, err = bc.validateTransactions(second.Transactions, second)
+	assert.Nil(t, err)
+	assert.Equal(t, []error{spentErr}, errs)
+}
+
+func TestResolveConflicts(t *testing.T) {
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	admin := NewNode(priv)
+
+	first := getSpendTransaction(t, admin, "doors")
+	second := getSpendTransaction(t, admin, "wheels")
+	other := &Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("trucks")}
+	if bytes.Compare(first.Hash().Bytes(), second.Hash().Bytes()) > 0 {
+		first, second = second, first
+	}
+
+	valid, conflicting := ResolveConflicts([]*Transaction{second, other, first})
+	assert.Equal(t, 2, len(valid))
+	assert.Contains(t, valid, first)
+	assert.Contains(t, valid, other)
+	assert.Equal(t, []*Transaction{second}, conflicting)
+
+	// Same result in any order
+	valid2, conflicting2 := ResolveConflicts([]*Transaction{first, other, second})
+	assert.Equal(t, valid, valid2)
+	assert.Equal(t, conflicting, conflicting2)
+}
+
+func TestValidateBlockConflicts(t *testing.T) {
+	bc, _, admin := getSpendBlockchain(t)
+
+	first := getSpendTransaction(t, admin, "doors")
+	second := getSpendTransaction(t, admin, "wheels")
+	// Built by another creator without resolving the conflicts
+	b, err := bc.BuildBlock([]*Transaction{first, second})
+	assert.Nil(t, err)
+
+	err = bc.ValidateBlock(b)
+	assert.Equal(t, &TransactionErrors{
+		BlockId: b.Hash(),
+		Errors: []error{
+			&SpentOutputsError{OutputIds: [][]byte{first.Inputs[0].OutputHash().Bytes()}},
+		},
+	}, err)
+
+	valid, _ := ResolveConflicts([]*Transaction{first, second})
+	b, err = bc.BuildBlock(valid)
+	assert.Nil(t, err)
+	assert.Nil(t, bc.ValidateBlock(b))
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Name of the table that keeps track of which blocks have been applied to the bigtable
+const STATE_TABLE = "state"
+
+const (
+	stateCursorRow      = "cursor"
+	stateCursorCol      = "last_applied"
+	stateFederationRow  = "federation"
+	stateFederationCol  = "members"
+	applyBlockBatchSize = 10
+	// Versions of the cells that a block writes start at its height times this, see applyVersion
+	blockVersionSpan = 1 << 20
+)
+
+// Points to the last block that was applied to the bigtable. Blocks are applied in the order of
+// the blockchain, so every block before the cursor has been applied.
+type StateCursor struct {
+	Height  *big.Int
+	BlockId []byte
+}
+
+// Creates the tables that the state of the blockchain is applied to.
+// Tables that already exist are left untouched.
+func (bc *Blockchain) SetupState() error {
+	for _, tableName := range []string{STATE_TABLE, TABLE_METADATA_TABLE} {
+		if err := bc.bt.CreateTable([]byte(tableName)); err != nil {
+			if _, ok := err.(*meddb.TableAlreadyExists); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reads the state cursor from bigtable.
+// Returns nil if no block has been applied yet.
+func (bc *Blockchain) GetStateCursor() (*StateCursor, error) {
+	op := meddb.NewGetOpLimit([]byte(stateCursorRow), [][]byte{[]byte(stateCursorCol)}, 1)
+	res, err := bc.bt.Get([]byte(STATE_TABLE), op)
+	if err != nil {
+		return nil, err
+	}
+
+	cells, ok := res[stateCursorCol]
+	if !ok || len(cells) == 0 {
+		return nil, nil
+	}
+
+	cursor := new(StateCursor)
+	if err := rlpDecode(cells[0].Data, cursor); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Writes the state cursor to bigtable.
+func (bc *Blockchain) writeStateCursor(cursor *StateCursor) error {
+	data, err := rlpEncode(cursor)
+	if err != nil {
+		return err
+	}
+
+	op := meddb.NewPutOp([]byte(stateCursorRow))
+	op.AddCol([]byte(stateCursorCol), data)
+	return bc.bt.Put([]byte(STATE_TABLE), op)
+}
+
+// Applies the transactions of all ACCEPTED blocks after the state cursor to the bigtable, in the
+// order of the blockchain. REJECTED blocks are skipped and application stops at the first
+// UNDECIDED block, since the blocks after it cannot be applied before it is decided.
+// The cursor is moved after every block, so this can be resumed after a crash. Applying a block
+// is idempotent, so a block that was applied right before a crash can safely be applied again.
+// Blocks that are written late and sort before the cursor, or that have no height, are skipped.
+// Voters reject those, since their height is not above every decided block (see ValidateBlock).
+// Returns the number of blocks that were applied.
+func (bc *Blockchain) ApplyBlocks() (int, error) {
+	cursor, err := bc.GetStateCursor()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		height  int64 = -1
+		blockId Hash
+	)
+	if cursor != nil {
+		height = cursor.Height.Int64()
+		blockId = BytesToHash(cursor.BlockId)
+	}
+
+	applied := 0
+	for {
+		bs, err := bc.GetBlocksAfter(height, blockId, applyBlockBatchSize)
+		if err != nil {
+			return applied, err
+		}
+
+		for _, b := range bs {
+			switch b.State {
+			case BLOCK_STATE_UNDECIDED:
+				return applied, nil
+			case BLOCK_STATE_ACCEPTED:
+				if err := bc.ApplyBlock(b); err != nil {
+					return applied, err
+				}
+				applied++
+			}
+
+			height, blockId = b.Height.Int64(), b.Hash()
+			cursor = &StateCursor{Height: big.NewInt(height), BlockId: blockId.Bytes()}
+			if err := bc.writeStateCursor(cursor); err != nil {
+				return applied, err
+			}
+		}
+
+		if len(bs) < applyBlockBatchSize {
+			return applied, nil
+		}
+	}
+}
+
+// Applies the transactions of the block to the bigtable in order.
+// Assumes that the block has been ACCEPTED.
+func (bc *Blockchain) ApplyBlock(b *Block) error {
+	for i, tx := range b.Transactions {
+		if err := bc.applyTransaction(tx, applyVersion(b, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gets the version of the cells that the i-th transaction of the block writes, unless they have a
+// VerId of their own. Versions follow the order in which transactions are applied rather than the
+// clock of the node that created the block, so a delete always hides the cells without VerIds
+// that were written before it and never the ones written after it. Applying the same block again
+// writes the exact same cells.
+func applyVersion(b *Block, i int) int64 {
+	return b.Height.Int64()*blockVersionSpan + int64(i)
+}
+
+// Applies a single transaction to the bigtable, writing its cells with the given version.
+// PUT_CELLS keeps the VerIds that its cells have, so a delete hides those cells only when their
+// VerId is not above the version of the delete. Deletes write tombstones that hide all versions
+// of the column up to their version, DELETE_ROW deletes every column that the row has when
+// applied except for restricted columns, since it is validated without knowing the columns of the
+// row and so without col writer inputs. Cells of restricted columns have to be deleted by
+// DELETE_CELLS with col writer inputs.
+func (bc *Blockchain) applyTransaction(tx *Transaction, verId int64) error {
+	switch tx.Type {
+	case TRANSACTION_TYPE_CREATE_TABLE:
+		if err := bc.bt.CreateTable(tx.TableName); err != nil {
+			if _, ok := err.(*meddb.TableAlreadyExists); !ok {
+				return err
+			}
+		}
+		tm := &TableMetadata{TableName: tx.TableName, Generation: big.NewInt(0)}
+		if err := tm.Read(bc.bt, TABLE_METADATA_ALL); err != nil {
+			return err
+		}
+		if generation := getTxGeneration(tx); tm.Generation.Cmp(generation) != 0 {
+			// Created again after it was dropped, so the rights and column schemas of the dropped
+			// generation do not carry over. Empty lists overwrite the ones that were written.
+			tm = &TableMetadata{
+				TableName:      tx.TableName,
+				Admins:         [][]byte{},
+				Writers:        [][]byte{},
+				ColRules:       &ColRules{AllowedColIds: [][]byte{}},
+				Generation:     generation,
+				ColSchemas:     []*ColSchema{},
+				RestrictedCols: [][]byte{},
+			}
+		}
+		tm.addOutputs(tx.Outputs)
+		return tm.Write(bc.bt, TABLE_METADATA_ALL)
+
+	case TRANSACTION_TYPE_UPDATE_TABLE:
+		tm := &TableMetadata{TableName: tx.TableName}
+		if err := tm.Read(bc.bt, TABLE_METADATA_ALL); err != nil {
+			return err
+		}
+		tm.addOutputs(tx.Outputs)
+		return tm.Write(bc.bt, TABLE_METADATA_ALL)
+
+	case TRANSACTION_TYPE_PUT_CELLS:
+		op := meddb.NewPutOp(tx.RowId)
+		for colId, cell := range tx.Cols {
+			cellVerId := verId
+			if cell.VerId != nil {
+				cellVerId = cell.VerId.Int64()
+			}
+			if err := op.AddColVer([]byte(colId), cellVerId, cell.Data); err != nil {
+				return err
+			}
+		}
+		return bc.bt.Put(tx.TableName, op)
+
+	case TRANSACTION_TYPE_DELETE_CELLS:
+		op := meddb.NewPutOp(tx.RowId)
+		for colId := range tx.Cols {
+			if err := op.AddTombstoneVer([]byte(colId), verId); err != nil {
+				return err
+			}
+		}
+		return bc.bt.Put(tx.TableName, op)
+
+	case TRANSACTION_TYPE_DELETE_ROW:
+		colIds, err := bc.getUnrestrictedColIds(tx)
+		if err != nil {
+			return err
+		}
+		if len(colIds) == 0 {
+			return nil
+		}
+
+		op := meddb.NewPutOp(tx.RowId)
+		for _, colId := range colIds {
+			if err := op.AddTombstoneVer(colId, verId); err != nil {
+				return err
+			}
+		}
+		return bc.bt.Put(tx.TableName, op)
+
+	case TRANSACTION_TYPE_DROP_TABLE:
+		// The metadata is kept, so that the next generation knows which generation it follows
+		if err := bc.bt.DropTable(tx.TableName); err != nil {
+			if _, ok := err.(*meddb.TableNotFoundError); !ok {
+				return err
+			}
+		}
+		return nil
+
+	case TRANSACTION_TYPE_ADD_NODE, TRANSACTION_TYPE_REMOVE_NODE:
+		return bc.applyMembershipChange(tx)
+	}
+
+	// Other transactions (i.e. genesis) do not change the state
+	return nil
+}
+
+// -------
+// Helpers
+// -------
+
+// Gets the ids of the columns that the row of the transaction has, leaving out restricted columns.
+func (bc *Blockchain) getUnrestrictedColIds(tx *Transaction) ([][]byte, error) {
+	colIds, err := bc.bt.GetColIds(tx.TableName, tx.RowId)
+	if err != nil {
+		return nil, err
+	}
+	if len(colIds) == 0 {
+		return colIds, nil
+	}
+
+	tm := &TableMetadata{TableName: tx.TableName}
+	if err := tm.Read(bc.bt, TABLE_METADATA_RESTRICTED_COLS); err != nil {
+		return nil, err
+	}
+	restricted := make(map[string]bool)
+	for _, colId := range tm.RestrictedCols {
+		restricted[string(colId)] = true
+	}
+
+	unrestrictedColIds := make([][]byte, 0, len(colIds))
+	for _, colId := range colIds {
+		if !restricted[string(colId)] {
+			unrestrictedColIds = append(unrestrictedColIds, colId)
+		}
+	}
+	return unrestrictedColIds, nil
+}
+
+// Gets the generation of the table that a CREATE_TABLE transaction creates, 0 unless it has a
+// TABLE_GENERATION output.
+func getTxGeneration(tx *Transaction) *big.Int {
+	for _, output := range tx.Outputs {
+		if o, ok := output.(*TableGenerationOutput); ok {
+			return big.NewInt(o.Generation.Int64())
+		}
+	}
+	return big.NewInt(0)
+}
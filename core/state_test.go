@@ -0,0 +1,531 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+func getStateBlockchain(t *testing.T) (*Blockchain, *meddb.MemoryBlockchainDB,
+	*meddb.MemoryBigtable) {
+
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bt, err := meddb.NewMemoryBigtable()
+	assert.Nil(t, err)
+
+	bc := NewBlockchain(db, bt, nil, nil)
+	err = bc.SetupState()
+	assert.Nil(t, err)
+	return bc, db, bt
+}
+
+func writeStateBlock(t *testing.T, db meddb.BlockchainDB, height, createdAt int64,
+	state BlockState, txs ...*Transaction) *Block {
+
+	b := &Block{
+		Transactions: txs,
+		Height:       big.NewInt(height),
+		CreatedAt:    big.NewInt(createdAt),
+		State:        state,
+	}
+	err := db.WriteBlock(b.toDBBlock())
+	assert.Nil(t, err)
+	return b
+}
+
+func getCells(t *testing.T, bt meddb.Bigtable, tableName, rowId, colId string) []*meddb.Cell {
+	res, err := bt.Get([]byte(tableName), meddb.NewGetOp([]byte(rowId), [][]byte{[]byte(colId)}))
+	assert.Nil(t, err)
+	return res[colId]
+}
+
+func TestSetupStateTwice(t *testing.T) {
+	bc, _, _ := getStateBlockchain(t)
+	assert.Nil(t, bc.SetupState())
+}
+
+func TestApplyBlocks(t *testing.T) {
+	bc, db, bt := getStateBlockchain(t)
+
+	createTx := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: []byte("cars"),
+		Outputs: []Output{
+			&TableExistsOutput{&TableNameMixin{[]byte("cars")}},
+			&AdminOutput{TableNameMixin: &TableNameMixin{[]byte("cars")}, PubKey: []byte("me")},
+		},
+	}
+	updateTx := &Transaction{
+		Type:      TRANSACTION_TYPE_UPDATE_TABLE,
+		TableName: []byte("cars"),
+		Outputs: []Output{
+			&WriterOutput{TableNameMixin: &TableNameMixin{[]byte("cars")}, PubKey: []byte("you")},
+		},
+	}
+	putTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols: map[string]*Cell{
+			"wheels": &Cell{Data: []byte("4")},
+			"doors":  &Cell{Data: []byte("2"), VerId: big.NewInt(7)},
+		},
+	}
+	rejectedTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("ford"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("3")}},
+	}
+
+	writeStateBlock(t, db, 0, 10, BLOCK_STATE_ACCEPTED, createTx, updateTx)
+	writeStateBlock(t, db, 1, 20, BLOCK_STATE_REJECTED, rejectedTx)
+	last := writeStateBlock(t, db, 2, 30, BLOCK_STATE_ACCEPTED, putTx)
+
+	applied, err := bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, applied)
+
+	tm := &TableMetadata{TableName: []byte("cars")}
+	err = tm.Read(bt, TABLE_METADATA_ALL)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("me")}, tm.Admins)
+	assert.Equal(t, [][]byte{[]byte("you")}, tm.Writers)
+
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(2*blockVersionSpan, []byte("4"))},
+		getCells(t, bt, "cars", "tesla", "wheels"))
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(7, []byte("2"))},
+		getCells(t, bt, "cars", "tesla", "doors"))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "ford", "wheels")))
+
+	cursor, err := bc.GetStateCursor()
+	assert.Nil(t, err)
+	assert.Equal(t, &StateCursor{Height: big.NewInt(2), BlockId: last.Hash().Bytes()}, cursor)
+
+	// Nothing new to apply
+	applied, err = bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, applied)
+}
+
+func TestApplyBlocksStopsAtUndecided(t *testing.T) {
+	bc, db, bt := getStateBlockchain(t)
+
+	createTx := &Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("cars")}
+	putTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+	}
+	otherPutTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("3")}},
+	}
+
+	writeStateBlock(t, db, 0, 10, BLOCK_STATE_ACCEPTED, createTx)
+	undecided := writeStateBlock(t, db, 1, 20, BLOCK_STATE_UNDECIDED, putTx)
+	writeStateBlock(t, db, 2, 30, BLOCK_STATE_ACCEPTED, otherPutTx)
+
+	applied, err := bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+
+	err = bc.UpdateBlockState(undecided.Hash(), BLOCK_STATE_ACCEPTED)
+	assert.Nil(t, err)
+
+	applied, err = bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, applied)
+	assert.Equal(t, []*meddb.Cell{
+		meddb.NewCellVer(2*blockVersionSpan, []byte("3")),
+		meddb.NewCellVer(blockVersionSpan, []byte("4")),
+	}, getCells(t, bt, "cars", "tesla", "wheels"))
+}
+
+func TestApplyBlocksOrderedByHeight(t *testing.T) {
+	bc, db, bt := getStateBlockchain(t)
+
+	createTx := &Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("cars")}
+	putTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+	}
+
+	// Clock of the creator of the first block was ahead
+	writeStateBlock(t, db, 1, 10, BLOCK_STATE_ACCEPTED, putTx)
+	writeStateBlock(t, db, 0, 20, BLOCK_STATE_ACCEPTED, createTx)
+
+	applied, err := bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, applied)
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(blockVersionSpan, []byte("4"))},
+		getCells(t, bt, "cars", "tesla", "wheels"))
+}
+
+func TestApplyBlocksSameHeight(t *testing.T) {
+	bc, db, _ := getStateBlockchain(t)
+
+	// More blocks with the same height than fit in a batch
+	for i := 0; i < applyBlockBatchSize*2+1; i++ {
+		tx := &Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte{byte(i)}}
+		writeStateBlock(t, db, 0, 10, BLOCK_STATE_ACCEPTED, tx)
+	}
+
+	applied, err := bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, applyBlockBatchSize*2+1, applied)
+}
+
+func TestApplyBlockIdempotent(t *testing.T) {
+	bc, _, bt := getStateBlockchain(t)
+
+	b := &Block{
+		Height:    big.NewInt(0),
+		CreatedAt: big.NewInt(10),
+		Transactions: []*Transaction{
+			&Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("cars")},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_PUT_CELLS,
+				TableName: []byte("cars"),
+				RowId:     []byte("tesla"),
+				Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+			},
+		},
+	}
+
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(1, []byte("4"))},
+		getCells(t, bt, "cars", "tesla", "wheels"))
+}
+
+func TestApplyDeleteCells(t *testing.T) {
+	bc, _, bt := getStateBlockchain(t)
+
+	b := &Block{
+		Height:    big.NewInt(0),
+		CreatedAt: big.NewInt(10),
+		Transactions: []*Transaction{
+			&Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("cars")},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_PUT_CELLS,
+				TableName: []byte("cars"),
+				RowId:     []byte("tesla"),
+				Cols: map[string]*Cell{
+					"wheels": &Cell{Data: []byte("4"), VerId: big.NewInt(5)},
+					"doors":  &Cell{Data: []byte("2")},
+				},
+			},
+		},
+	}
+	deleteB := &Block{
+		Height:    big.NewInt(1),
+		CreatedAt: big.NewInt(20),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_DELETE_CELLS,
+				TableName: []byte("cars"),
+				RowId:     []byte("tesla"),
+				Cols:      map[string]*Cell{"wheels": &Cell{}},
+			},
+		},
+	}
+
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Nil(t, bc.ApplyBlock(deleteB))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(1, []byte("2"))},
+		getCells(t, bt, "cars", "tesla", "doors"))
+
+	op := meddb.NewGetOp([]byte("tesla"), [][]byte{[]byte("wheels")}).WithHistory()
+	cells, err := bc.GetCells([]byte("cars"), op)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Cell{
+		&Cell{VerId: big.NewInt(blockVersionSpan), Tombstone: true},
+		&Cell{Data: []byte("4"), VerId: big.NewInt(5)},
+	}, cells["wheels"])
+}
+
+func TestApplyDeleteRow(t *testing.T) {
+	bc, _, bt := getStateBlockchain(t)
+
+	b := &Block{
+		Height:    big.NewInt(0),
+		CreatedAt: big.NewInt(10),
+		Transactions: []*Transaction{
+			&Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("cars")},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_PUT_CELLS,
+				TableName: []byte("cars"),
+				RowId:     []byte("tesla"),
+				Cols: map[string]*Cell{
+					"wheels": &Cell{Data: []byte("4")},
+					"doors":  &Cell{Data: []byte("2")},
+				},
+			},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_DELETE_ROW,
+				TableName: []byte("cars"),
+				RowId:     []byte("tesla"),
+			},
+			// Deleting a row without any columns does nothing
+			&Transaction{
+				Type:      TRANSACTION_TYPE_DELETE_ROW,
+				TableName: []byte("cars"),
+				RowId:     []byte("ford"),
+			},
+		},
+	}
+	putB := &Block{
+		Height:    big.NewInt(1),
+		CreatedAt: big.NewInt(20),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_PUT_CELLS,
+				TableName: []byte("cars"),
+				RowId:     []byte("tesla"),
+				Cols:      map[string]*Cell{"doors": &Cell{Data: []byte("4")}},
+			},
+		},
+	}
+
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "doors")))
+
+	// Cells written after the delete are visible again
+	assert.Nil(t, bc.ApplyBlock(putB))
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(blockVersionSpan, []byte("4"))},
+		getCells(t, bt, "cars", "tesla", "doors"))
+}
+
+func TestApplyDeleteClockBehind(t *testing.T) {
+	bc, db, bt := getStateBlockchain(t)
+
+	createTx := &Transaction{Type: TRANSACTION_TYPE_CREATE_TABLE, TableName: []byte("cars")}
+	putTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+	}
+	deleteTx := &Transaction{
+		Type:      TRANSACTION_TYPE_DELETE_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{}},
+	}
+	otherPutTx := &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte("tesla"),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("3")}},
+	}
+
+	writeStateBlock(t, db, 0, 30, BLOCK_STATE_ACCEPTED, createTx)
+	writeStateBlock(t, db, 1, 20, BLOCK_STATE_ACCEPTED, putTx)
+	// Clock of the creator of the delete is behind, which does not matter for the order
+	writeStateBlock(t, db, 2, 10, BLOCK_STATE_ACCEPTED, deleteTx)
+
+	applied, err := bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, applied)
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+
+	// A put that follows a delete in the same block is visible and both keep their version
+	writeStateBlock(t, db, 3, 0, BLOCK_STATE_ACCEPTED, deleteTx, otherPutTx)
+
+	applied, err = bc.ApplyBlocks()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, []*meddb.Cell{meddb.NewCellVer(3*blockVersionSpan+1, []byte("3"))},
+		getCells(t, bt, "cars", "tesla", "wheels"))
+
+	op := meddb.NewGetOp([]byte("tesla"), [][]byte{[]byte("wheels")}).WithHistory()
+	cells, err := bc.GetCells([]byte("cars"), op)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Cell{
+		&Cell{Data: []byte("3"), VerId: big.NewInt(3*blockVersionSpan + 1)},
+		&Cell{VerId: big.NewInt(3 * blockVersionSpan), Tombstone: true},
+		&Cell{VerId: big.NewInt(2 * blockVersionSpan), Tombstone: true},
+		&Cell{Data: []byte("4"), VerId: big.NewInt(blockVersionSpan)},
+	}, cells["wheels"])
+}
+
+func TestApplyDeleteRowRestricted(t *testing.T) {
+	bc, _, bt := getStateBlockchain(t)
+
+	tableName := []byte("cars")
+	deleteRowTx := &Transaction{
+		Type:      TRANSACTION_TYPE_DELETE_ROW,
+		TableName: tableName,
+		RowId:     []byte("tesla"),
+	}
+	b := &Block{
+		Height: big.NewInt(0),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_CREATE_TABLE,
+				TableName: tableName,
+				Outputs: []Output{&RestrictedColOutput{
+					TableNameMixin: &TableNameMixin{tableName},
+					ColName:        []byte("price"),
+				}},
+			},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_PUT_CELLS,
+				TableName: tableName,
+				RowId:     []byte("tesla"),
+				Cols: map[string]*Cell{
+					"wheels": &Cell{Data: []byte("4")},
+					"price":  &Cell{Data: []byte("100")},
+				},
+			},
+			deleteRowTx,
+		},
+	}
+	deleteB := &Block{
+		Height: big.NewInt(1),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_DELETE_CELLS,
+				TableName: tableName,
+				RowId:     []byte("tesla"),
+				Cols:      map[string]*Cell{"price": &Cell{}},
+			},
+			deleteRowTx,
+		},
+	}
+
+	// Only the cells of unrestricted columns are deleted
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+	assert.Equal(t, 1, len(getCells(t, bt, "cars", "tesla", "price")))
+
+	// Restricted cells are deleted by their col writers
+	assert.Nil(t, bc.ApplyBlock(deleteB))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "price")))
+}
+
+func TestApplyDropTable(t *testing.T) {
+	bc, _, bt := getStateBlockchain(t)
+
+	tableName := []byte("cars")
+	b := &Block{
+		Height:    big.NewInt(0),
+		CreatedAt: big.NewInt(10),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_CREATE_TABLE,
+				TableName: tableName,
+				Outputs: []Output{
+					&AdminOutput{TableNameMixin: &TableNameMixin{tableName}, PubKey: []byte("me")},
+					&WriterOutput{TableNameMixin: &TableNameMixin{tableName}, PubKey: []byte("me")},
+				},
+			},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_PUT_CELLS,
+				TableName: tableName,
+				RowId:     []byte("tesla"),
+				Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+			},
+		},
+	}
+	dropB := &Block{
+		Height:    big.NewInt(1),
+		CreatedAt: big.NewInt(20),
+		Transactions: []*Transaction{
+			&Transaction{Type: TRANSACTION_TYPE_DROP_TABLE, TableName: tableName},
+		},
+	}
+	recreateB := &Block{
+		Height:    big.NewInt(2),
+		CreatedAt: big.NewInt(30),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_CREATE_TABLE,
+				TableName: tableName,
+				Outputs: []Output{
+					&AdminOutput{TableNameMixin: &TableNameMixin{tableName}, PubKey: []byte("you")},
+					&TableGenerationOutput{
+						TableNameMixin: &TableNameMixin{tableName},
+						Generation:     big.NewInt(1),
+					},
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, bc.ApplyBlock(b))
+	assert.Nil(t, bc.ApplyBlock(dropB))
+	// Dropping again does nothing
+	assert.Nil(t, bc.ApplyBlock(dropB))
+	_, err := bt.Get(tableName, meddb.NewGetOp([]byte("tesla"), [][]byte{[]byte("wheels")}))
+	assert.IsType(t, &meddb.TableNotFoundError{}, err)
+
+	assert.Nil(t, bc.ApplyBlock(recreateB))
+	assert.Equal(t, 0, len(getCells(t, bt, "cars", "tesla", "wheels")))
+
+	tm := &TableMetadata{TableName: tableName}
+	assert.Nil(t, tm.Read(bt, TABLE_METADATA_ALL))
+	assert.Equal(t, big.NewInt(1), tm.Generation)
+	// Rights of the dropped generation are gone
+	assert.Equal(t, [][]byte{[]byte("you")}, tm.Admins)
+	assert.Equal(t, [][]byte{}, tm.Writers)
+
+	// Applying the same block again keeps the rights of the new generation
+	assert.Nil(t, bc.ApplyBlock(recreateB))
+	assert.Nil(t, tm.Read(bt, TABLE_METADATA_ALL))
+	assert.Equal(t, [][]byte{[]byte("you")}, tm.Admins)
+}
+
+func TestApplyColSchemas(t *testing.T) {
+	bc, _, bt := getStateBlockchain(t)
+
+	tableName := []byte("cars")
+	schemaOutput := func(colName string, colType ColType) *ColSchemaOutput {
+		return &ColSchemaOutput{
+			TableNameMixin: &TableNameMixin{tableName},
+			ColName:        []byte(colName),
+			ColType:        intToBigInt(int(colType)),
+		}
+	}
+	b := &Block{
+		Height:    big.NewInt(0),
+		CreatedAt: big.NewInt(10),
+		Transactions: []*Transaction{
+			&Transaction{
+				Type:      TRANSACTION_TYPE_CREATE_TABLE,
+				TableName: tableName,
+				Outputs: []Output{
+					schemaOutput("wheels", COL_TYPE_INT64),
+					schemaOutput("doors", COL_TYPE_INT64),
+				},
+			},
+			&Transaction{
+				Type:      TRANSACTION_TYPE_UPDATE_TABLE,
+				TableName: tableName,
+				Outputs:   []Output{schemaOutput("doors", COL_TYPE_STRING)},
+			},
+		},
+	}
+	assert.Nil(t, bc.ApplyBlock(b))
+
+	tm := &TableMetadata{TableName: tableName}
+	assert.Nil(t, tm.Read(bt, TABLE_METADATA_COL_SCHEMAS))
+	assert.Equal(t, []*ColSchema{
+		schemaOutput("wheels", COL_TYPE_INT64).Schema(),
+		schemaOutput("doors", COL_TYPE_STRING).Schema(),
+	}, tm.ColSchemas)
+}
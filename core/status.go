@@ -0,0 +1,93 @@
+package core
+
+import (
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+type TransactionState int
+
+const (
+	TRANSACTION_STATE_UNKNOWN   TransactionState = iota // UNKNOWN   = 0
+	TRANSACTION_STATE_BACKLOG                           // BACKLOG   = 1
+	TRANSACTION_STATE_UNDECIDED                         // UNDECIDED = 2
+	TRANSACTION_STATE_ACCEPTED                          // ACCEPTED  = 3
+	TRANSACTION_STATE_REJECTED                          // REJECTED  = 4
+	TRANSACTION_STATE_INVALID                           // INVALID   = 5
+)
+
+// Where a transaction is in its lifecycle.
+type TransactionStatus struct {
+	State     TransactionState
+	BlockId   *Hash      // Block that decides the state, nil if the transaction is not in a block
+	Rejection *Rejection // Why the transaction is INVALID, nil in all other states
+}
+
+// Returns the lifecycle status of the transaction with the given hash.
+// A transaction can end up in several blocks, for example when it is put back into the backlog
+// after its block was rejected. The block that decides the state is picked in this order:
+// 1) An ACCEPTED block.
+// 2) An UNDECIDED block.
+// 3) If the transaction is still in the backlog, it is in BACKLOG and has no block.
+// 4) The most recent of the REJECTED blocks and the rejection record. Transactions with a newer
+// rejection record were dropped from the backlog as INVALID.
+// Transactions that are in neither the backlog nor a block and have no rejection record (i.e.
+// because it is past retention) are UNKNOWN.
+func (bc *Blockchain) GetTransactionStatus(txHash Hash) (*TransactionStatus, error) {
+	dbBs, err := bc.db.GetTransactionBlocks(txHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	bs := fromDBBlocks(dbBs)
+
+	if b := latestBlockInState(bs, BLOCK_STATE_ACCEPTED); b != nil {
+		return newBlockTransactionStatus(TRANSACTION_STATE_ACCEPTED, b), nil
+	}
+	if b := latestBlockInState(bs, BLOCK_STATE_UNDECIDED); b != nil {
+		return newBlockTransactionStatus(TRANSACTION_STATE_UNDECIDED, b), nil
+	}
+
+	if _, err := bc.db.GetBacklogTransaction(txHash.Bytes()); err == nil {
+		return &TransactionStatus{State: TRANSACTION_STATE_BACKLOG}, nil
+	} else if _, ok := err.(*meddb.NotFoundError); !ok {
+		return nil, err
+	}
+
+	rej, err := bc.GetRejection(txHash)
+	if err != nil {
+		return nil, err
+	}
+	b := latestBlockInState(bs, BLOCK_STATE_REJECTED)
+	if rej != nil && (b == nil || b.CreatedAt == nil ||
+		(rej.RejectedAt != nil && rej.RejectedAt.Cmp(b.CreatedAt) >= 0)) {
+		return &TransactionStatus{State: TRANSACTION_STATE_INVALID, Rejection: rej}, nil
+	}
+	if b != nil {
+		return newBlockTransactionStatus(TRANSACTION_STATE_REJECTED, b), nil
+	}
+	return &TransactionStatus{State: TRANSACTION_STATE_UNKNOWN}, nil
+}
+
+// -------
+// Helpers
+// -------
+
+func newBlockTransactionStatus(state TransactionState, b *Block) *TransactionStatus {
+	blockId := b.Hash()
+	return &TransactionStatus{State: state, BlockId: &blockId}
+}
+
+// Returns the block with the latest CreatedAt out of the blocks in the given state, nil if there
+// are none.
+func latestBlockInState(bs []*Block, state BlockState) *Block {
+	var latest *Block = nil
+	for _, b := range bs {
+		if b.State != state {
+			continue
+		}
+		if latest == nil || (b.CreatedAt != nil &&
+			(latest.CreatedAt == nil || b.CreatedAt.Cmp(latest.CreatedAt) > 0)) {
+			latest = b
+		}
+	}
+	return latest
+}
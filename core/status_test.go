@@ -0,0 +1,112 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+func getStatusTransaction(rowId string) *Transaction {
+	return &Transaction{
+		Type:      TRANSACTION_TYPE_PUT_CELLS,
+		TableName: []byte("cars"),
+		RowId:     []byte(rowId),
+		Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte("4")}},
+	}
+}
+
+func writeStatusBlock(t *testing.T, db meddb.BlockchainDB, creator string, createdAt int64,
+	state BlockState, txs ...*Transaction) Hash {
+
+	b := &Block{
+		Transactions: txs,
+		CreatedAt:    big.NewInt(createdAt),
+		Creator:      []byte(creator),
+		State:        state,
+	}
+	assert.Nil(t, db.WriteBlock(b.toDBBlock()))
+	return b.Hash()
+}
+
+func TestTransactionStatusUnknown(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bc := NewBlockchain(db, nil, nil, nil)
+
+	status, err := bc.GetTransactionStatus(getStatusTransaction("tesla").Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_UNKNOWN}, status)
+}
+
+func TestTransactionStatusBacklog(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bc := NewBlockchain(db, nil, nil, nil)
+	tx := getStatusTransaction("tesla")
+	assert.Nil(t, db.WriteTransaction(tx.toDBTransaction()))
+
+	status, err := bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_BACKLOG}, status)
+}
+
+func TestTransactionStatusBlocks(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bc := NewBlockchain(db, nil, nil, nil)
+	tx := getStatusTransaction("tesla")
+	otherTx := getStatusTransaction("ford")
+
+	rejected := writeStatusBlock(t, db, "me", 10, BLOCK_STATE_REJECTED, tx)
+	status, err := bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_REJECTED, BlockId: &rejected},
+		status)
+
+	// Put back into the backlog after the rejection
+	assert.Nil(t, db.WriteTransaction(tx.toDBTransaction()))
+	status, err = bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_BACKLOG}, status)
+
+	undecided := writeStatusBlock(t, db, "you", 20, BLOCK_STATE_UNDECIDED, otherTx, tx)
+	status, err = bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_UNDECIDED, BlockId: &undecided},
+		status)
+
+	err = bc.UpdateBlockState(undecided, BLOCK_STATE_ACCEPTED)
+	assert.Nil(t, err)
+	status, err = bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_ACCEPTED, BlockId: &undecided},
+		status)
+}
+
+func TestTransactionStatusInvalid(t *testing.T) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(t, err)
+	bc := NewBlockchain(db, nil, &Node{PubKey: []byte("me")}, nil)
+	tx := getStatusTransaction("tesla")
+
+	rejected := writeStatusBlock(t, db, "me", 10, BLOCK_STATE_REJECTED, tx)
+
+	rej := bc.NewRejection(tx, &MissingOutputsError{OutputIds: [][]byte{[]byte("output")}})
+	rej.RejectedAt = big.NewInt(20)
+	assert.Nil(t, bc.WriteRejections([]*Rejection{rej}))
+
+	status, err := bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_INVALID, Rejection: rej}, status)
+
+	// A rejected block that is newer than the rejection decides the state
+	rejectedAgain := writeStatusBlock(t, db, "you", 30, BLOCK_STATE_REJECTED, tx)
+	status, err = bc.GetTransactionStatus(tx.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, &TransactionStatus{State: TRANSACTION_STATE_REJECTED, BlockId: &rejectedAgain},
+		status)
+	assert.NotEqual(t, rejected, rejectedAgain)
+}
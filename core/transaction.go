@@ -15,11 +15,21 @@ const (
 	TRANSACTION_TYPE_CREATE_TABLE TransactionType = iota // CREATE_TABLE = 0
 	TRANSACTION_TYPE_UPDATE_TABLE                        // UPDATE_TABLE = 1
 	TRANSACTION_TYPE_PUT_CELLS                           // PUT_CELLS = 2
+	TRANSACTION_TYPE_ADD_NODE                            // ADD_NODE = 3
+	TRANSACTION_TYPE_REMOVE_NODE                         // REMOVE_NODE = 4
+	TRANSACTION_TYPE_DELETE_CELLS                        // DELETE_CELLS = 5
+	TRANSACTION_TYPE_DELETE_ROW                          // DELETE_ROW = 6
+	TRANSACTION_TYPE_DROP_TABLE                          // DROP_TABLE = 7
 )
 
+// Column of ADD_NODE and REMOVE_NODE transactions that holds the public key of the node that
+// joins or leaves the federation.
+const NODE_COL = "node"
+
 type Cell struct {
-	Data  []byte
-	VerId *big.Int
+	Data      []byte
+	VerId     *big.Int
+	Tombstone bool // Only set on cells read from bigtable with their history
 }
 
 type Transaction struct {
@@ -38,14 +48,19 @@ var rulesets = map[TransactionType][]Rule{
 		&TableMissingRule{},
 		&ValidOutputTypesRule{validTypes: map[OutputType]bool{
 			OUTPUT_TYPE_TABLE_EXISTS:     true,
+			OUTPUT_TYPE_TABLE_GENERATION: true,
 			OUTPUT_TYPE_COL_ALLOWED:      true,
 			OUTPUT_TYPE_ALL_COLS_ALLOWED: true,
 			OUTPUT_TYPE_ALL_ADMINS:       true,
 			OUTPUT_TYPE_ADMIN:            true,
 			OUTPUT_TYPE_ALL_WRITERS:      true,
 			OUTPUT_TYPE_WRITER:           true,
+			OUTPUT_TYPE_COL_SCHEMA:       true,
+			OUTPUT_TYPE_RESTRICTED_COL:   true,
+			OUTPUT_TYPE_COL_WRITER:       true,
 		}},
 		&HasTableExistsRule{},
+		&ColSchemaRule{},
 	},
 	TRANSACTION_TYPE_UPDATE_TABLE: []Rule{
 		&TableExistsRule{},
@@ -57,17 +72,60 @@ var rulesets = map[TransactionType][]Rule{
 			OUTPUT_TYPE_ADMIN:            true,
 			OUTPUT_TYPE_ALL_WRITERS:      true,
 			OUTPUT_TYPE_WRITER:           true,
+			OUTPUT_TYPE_COL_SCHEMA:       true,
+			OUTPUT_TYPE_RESTRICTED_COL:   true,
+			OUTPUT_TYPE_COL_WRITER:       true,
 		}},
+		&ColSchemaRule{},
 	},
 	TRANSACTION_TYPE_PUT_CELLS: []Rule{
 		&TableExistsRule{},
 		&ColsAllowedRule{},
 		&WriterRule{},
+		&ColWriterRule{},
 		&RowRule{},
 		&ValidOutputTypesRule{validTypes: map[OutputType]bool{
 			OUTPUT_TYPE_ALL_ROW_WRITERS: true,
 			OUTPUT_TYPE_ROW_WRITER:      true,
 		}},
+		&ColSchemaRule{},
+	},
+	// Both also get a FederationRule from the Blockchain, since they depend on the federation
+	TRANSACTION_TYPE_ADD_NODE: []Rule{
+		&NodeColRule{},
+		&ValidOutputTypesRule{validTypes: map[OutputType]bool{
+			OUTPUT_TYPE_NODE: true,
+		}},
+		&HasNodeOutputRule{},
+	},
+	TRANSACTION_TYPE_REMOVE_NODE: []Rule{
+		&NodeColRule{},
+		&ValidOutputTypesRule{validTypes: map[OutputType]bool{}},
+	},
+	TRANSACTION_TYPE_DELETE_CELLS: []Rule{
+		&TableExistsRule{},
+		&ColsAllowedRule{},
+		&WriterRule{},
+		&ColWriterRule{},
+		&RowRule{},
+		&DeleteRule{},
+		&VerIdRule{},
+		&ValidOutputTypesRule{validTypes: map[OutputType]bool{}},
+	},
+	TRANSACTION_TYPE_DELETE_ROW: []Rule{
+		&TableExistsRule{},
+		&WriterRule{},
+		&RowRule{},
+		&DeleteRule{},
+		&ValidOutputTypesRule{validTypes: map[OutputType]bool{}},
+	},
+	TRANSACTION_TYPE_DROP_TABLE: []Rule{
+		&TableExistsRule{},
+		&AdminRule{},
+		&ValidOutputTypesRule{validTypes: map[OutputType]bool{
+			OUTPUT_TYPE_TABLE_DROPPED: true,
+		}},
+		&HasTableDroppedRule{},
 	},
 }
 
@@ -77,7 +135,13 @@ var rulesets = map[TransactionType][]Rule{
 
 type colCell struct {
 	ColId []byte
-	Cell  *Cell
+	Cell  *cellBody
+}
+
+// Part of cell used in hash
+type cellBody struct {
+	Data  []byte
+	VerId *big.Int
 }
 
 // Part of transaction used in hash
@@ -101,7 +165,10 @@ func (tx *Transaction) Hash() Hash {
 		cols = make([]*colCell, len(tx.Cols))
 		i := 0
 		for colId, cell := range tx.Cols {
-			cols[i] = &colCell{ColId: []byte(colId), Cell: cell}
+			cols[i] = &colCell{
+				ColId: []byte(colId),
+				Cell:  &cellBody{Data: cell.Data, VerId: cell.VerId},
+			}
 			i++
 		}
 
@@ -114,7 +181,8 @@ func (tx *Transaction) Hash() Hash {
 	if tx.Outputs != nil {
 		outputHashes = make([][]byte, len(tx.Outputs))
 		for i, output := range tx.Outputs {
-			outputHashes[i] = HashOutput(output).Bytes()
+			// Keyed outputs share their id with other versions, so the data is hashed instead
+			outputHashes[i] = hashOutputData(output).Bytes()
 		}
 	}
 
@@ -150,6 +218,16 @@ func (tx *Transaction) Validate(linkedOutputs map[string]Output,
 	if err != nil {
 		return err
 	}
+	return tx.validateRuleset(ruleset, linkedOutputs, spentInputs)
+}
+
+// Returns whether the transaction changes the federation.
+func (tx *Transaction) IsMembershipChange() bool {
+	return tx.Type == TRANSACTION_TYPE_ADD_NODE || tx.Type == TRANSACTION_TYPE_REMOVE_NODE
+}
+
+func (tx *Transaction) validateRuleset(ruleset []Rule, linkedOutputs map[string]Output,
+	spentInputs map[string][]Input) error {
 
 	errs := make([]error, 0)
 	for _, rule := range ruleset {
@@ -277,8 +355,9 @@ func toDBCell(cell *Cell) *meddb.Cell {
 		verId = big.NewInt(cell.VerId.Int64())
 	}
 	return &meddb.Cell{
-		Data:  cell.Data,
-		VerId: verId,
+		Data:      cell.Data,
+		VerId:     verId,
+		Tombstone: cell.Tombstone,
 	}
 }
 
@@ -288,7 +367,8 @@ func fromDBCell(cell *meddb.Cell) *Cell {
 		verId = big.NewInt(cell.VerId.Int64())
 	}
 	return &Cell{
-		Data:  cell.Data,
-		VerId: verId,
+		Data:      cell.Data,
+		VerId:     verId,
+		Tombstone: cell.Tombstone,
 	}
 }
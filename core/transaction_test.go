@@ -43,14 +43,14 @@ func TestTransactionHash(t *testing.T) {
 		Cols: []*colCell{
 			&colCell{
 				ColId: []byte{69},
-				Cell: &Cell{
+				Cell: &cellBody{
 					VerId: big.NewInt(126),
 					Data:  []byte{127},
 				},
 			},
 			&colCell{
 				ColId: []byte{125},
-				Cell: &Cell{
+				Cell: &cellBody{
 					VerId: big.NewInt(69),
 					Data:  []byte{70},
 				},
@@ -97,7 +97,7 @@ func TestDBTransactionMapper(t *testing.T) {
 		Cols: []*colCell{
 			&colCell{
 				ColId: []byte{125},
-				Cell: &Cell{
+				Cell: &cellBody{
 					VerId: big.NewInt(126),
 					Data:  []byte{127},
 				},
@@ -0,0 +1,380 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Most transactions that are validated at the same time by ValidateTransactions
+const MAX_VALIDATION_WORKERS = 8
+
+// Everything that is needed to validate a transaction once its outputs have been fetched
+type txValidation struct {
+	tx         *Transaction
+	txHash     []byte
+	ruleset    []Rule
+	outputReqs map[string]OutputRequirement
+	droppedId  string // Output that dropped the last generation of the table, empty if none
+}
+
+// Validates transactions in a batch.
+// Looks up the generations of the tables of the transactions, then gets the outputs requested by
+// all transactions in one GetOutputs call and the inputs that spend them in one GetInputsByOutput
+// call, then validates the transactions with a pool of at most MAX_VALIDATION_WORKERS workers.
+// Returns an error for every transaction, nil when validation of that transaction is successful.
+// Returns a separate error if reading from the databases fails, in which case nothing is known
+// about the validity of the transactions.
+// Transactions are validated for a new block, see validateTransactions.
+func (bc *Blockchain) ValidateTransactions(txs []*Transaction) ([]error, error) {
+	return bc.validateTransactions(txs, nil)
+}
+
+// -------
+// Helpers
+// -------
+
+// Validates transactions in a batch, either to build a new block out of them when votedBlock is
+// nil, or as the transactions of votedBlock. See getSpentInputs for the difference.
+func (bc *Blockchain) validateTransactions(txs []*Transaction, votedBlock *Block) ([]error,
+	error) {
+
+	errs := make([]error, len(txs))
+	vals := make([]*txValidation, len(txs))
+
+	inputOutputIds := make([][]byte, 0)
+	seenOutputs := make(map[string]bool) // Whether the output has to be read past the cache
+	seenInputOutputs := make(map[string]bool)
+
+	generations, err := bc.getTableGenerations(txs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tx := range txs {
+		tableName := string(tx.TableName)
+		val, err := bc.newTxValidation(tx, generations[tableName])
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		vals[i] = val
+
+		// Outputs of a table that was dropped may have copies from every generation, and the
+		// cache only holds the first accepted one, which belongs to a dropped generation
+		uncached := generations[tableName] > 0
+		for outputStrId, _ := range val.outputReqs {
+			seenOutputs[outputStrId] = seenOutputs[outputStrId] || uncached
+		}
+		for _, input := range tx.Inputs {
+			outputStrId := input.OutputHash().String()
+			if !seenInputOutputs[outputStrId] {
+				seenInputOutputs[outputStrId] = true
+				inputOutputIds = append(inputOutputIds, []byte(outputStrId))
+			}
+		}
+	}
+
+	outputIds := make([][]byte, 0)
+	uncachedOutputIds := make([][]byte, 0)
+	for outputStrId, uncached := range seenOutputs {
+		if uncached {
+			uncachedOutputIds = append(uncachedOutputIds, []byte(outputStrId))
+		} else {
+			outputIds = append(outputIds, []byte(outputStrId))
+		}
+	}
+
+	outputsById, inputsById, err := bc.getOutputsAndInputs(outputIds, uncachedOutputIds,
+		inputOutputIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbVotedBlock *meddb.Block = nil
+	if votedBlock != nil {
+		dbVotedBlock = votedBlock.toDBBlock()
+	}
+
+	numWorkers := MAX_VALIDATION_WORKERS
+	if len(txs) < numWorkers {
+		numWorkers = len(txs)
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = vals[i].validate(outputsById, inputsById, dbVotedBlock)
+			}
+		}()
+	}
+	for i, val := range vals {
+		if val != nil {
+			indices <- i
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	return errs, nil
+}
+
+// Gets the ruleset of the transaction for the given generation of its table and the outputs that it
+// requests.
+func (bc *Blockchain) newTxValidation(tx *Transaction, generation int64) (*txValidation, error) {
+
+	outputReqs := map[string]OutputRequirement{}
+	for _, input := range tx.Inputs {
+		// Linked outputs are required
+		outputReqs[input.OutputHash().String()] = OUTPUT_REQUIREMENT_REQUIRED
+	}
+
+	ruleset, err := bc.getRuleset(tx)
+	if err != nil {
+		return nil, err
+	}
+	ruleset = withGeneration(ruleset, generation)
+	for _, rule := range ruleset {
+		ruleOutputReqs := rule.RequestedOutputIds(tx)
+		for outputStrId, outputReq := range ruleOutputReqs {
+			// We want the strictest requirement in the map
+			if oldOutputReq, ok := outputReqs[outputStrId]; !ok || outputReq > oldOutputReq {
+				outputReqs[outputStrId] = outputReq
+			}
+		}
+	}
+
+	droppedId := ""
+	if generation > 0 {
+		// Tells which outputs of the table belong to the generations that were dropped
+		droppedId = HashOutput(&TableDroppedOutput{
+			TableNameMixin: &TableNameMixin{tx.TableName},
+			Generation:     big.NewInt(generation - 1),
+		}).String()
+		if _, ok := outputReqs[droppedId]; !ok {
+			outputReqs[droppedId] = OUTPUT_REQUIREMENT_NONE
+		}
+	}
+
+	// TODO: Replace with transaction level caching of hash
+	return &txValidation{
+		tx:         tx,
+		txHash:     tx.Hash().Bytes(),
+		ruleset:    ruleset,
+		outputReqs: outputReqs,
+		droppedId:  droppedId,
+	}, nil
+}
+
+// Returns the current generation of the tables of the transactions by table name, which is the
+// number of times the table has been dropped in ACCEPTED blocks. Generations are looked up together
+// for all tables, one GetOutputs call for every generation that the most dropped table went
+// through.
+func (bc *Blockchain) getTableGenerations(txs []*Transaction) (map[string]int64, error) {
+	generations := make(map[string]int64)
+	pending := make([][]byte, 0)
+	for _, tx := range txs {
+		if tx.IsMembershipChange() {
+			// Not tied to a table
+			continue
+		}
+		if _, ok := generations[string(tx.TableName)]; !ok {
+			generations[string(tx.TableName)] = 0
+			pending = append(pending, tx.TableName)
+		}
+	}
+
+	for len(pending) > 0 {
+		outputIds := make([][]byte, len(pending))
+		for i, tableName := range pending {
+			outputIds[i] = HashOutput(&TableDroppedOutput{
+				TableNameMixin: &TableNameMixin{tableName},
+				Generation:     big.NewInt(generations[string(tableName)]),
+			}).Bytes()
+		}
+
+		outputResponses, err := bc.db.GetOutputs(outputIds)
+		if err != nil {
+			return nil, err
+		}
+		dropped := make(map[string]bool)
+		for _, outputRes := range outputResponses {
+			if BlockState(outputRes.Block.State) == BLOCK_STATE_ACCEPTED {
+				dropped[string(outputRes.Output.Hash)] = true
+			}
+		}
+
+		nextPending := make([][]byte, 0)
+		for i, tableName := range pending {
+			if dropped[string(outputIds[i])] {
+				generations[string(tableName)]++
+				nextPending = append(nextPending, tableName)
+			}
+		}
+		pending = nextPending
+	}
+
+	return generations, nil
+}
+
+// Gets the given outputs and the inputs that spend the outputs with inputOutputIds from database,
+// both by output id. Outputs with uncachedOutputIds are read past the output cache.
+func (bc *Blockchain) getOutputsAndInputs(outputIds, uncachedOutputIds, inputOutputIds [][]byte) (
+	map[string][]*meddb.OutputRes, map[string][]*meddb.InputRes, error) {
+
+	outputsById := make(map[string][]*meddb.OutputRes)
+	if len(outputIds) > 0 {
+		outputResponses, err := bc.db.GetOutputs(outputIds)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, outputRes := range outputResponses {
+			outputStrId := string(outputRes.Output.Hash)
+			outputsById[outputStrId] = append(outputsById[outputStrId], outputRes)
+		}
+	}
+	if len(uncachedOutputIds) > 0 {
+		outputResponses, err := bc.getUncachedOutputs(uncachedOutputIds)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, outputRes := range outputResponses {
+			outputStrId := string(outputRes.Output.Hash)
+			outputsById[outputStrId] = append(outputsById[outputStrId], outputRes)
+		}
+	}
+
+	inputsById := make(map[string][]*meddb.InputRes)
+	if len(inputOutputIds) > 0 {
+		inputResponses, err := bc.db.GetInputsByOutput(inputOutputIds)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, inputRes := range inputResponses {
+			outputStrId := string(inputRes.Input.OutputHash)
+			inputsById[outputStrId] = append(inputsById[outputStrId], inputRes)
+		}
+	}
+
+	return outputsById, inputsById, nil
+}
+
+// Gets the given outputs from database, past the output cache if there is one.
+func (bc *Blockchain) getUncachedOutputs(outputIds [][]byte) ([]*meddb.OutputRes, error) {
+	if cache, ok := bc.db.(*meddb.OutputCacheDB); ok {
+		return cache.GetUncachedOutputs(outputIds)
+	}
+	return bc.db.GetOutputs(outputIds)
+}
+
+// Validates the transaction against the outputs and inputs that were fetched for the batch.
+// votedBlock is the block that the transaction is in, nil if it is validated for a new block.
+// Outputs of the table of the transaction that are in blocks up to the one that dropped its last
+// generation are ignored, so rights on a dropped table do not carry over to the table that is
+// created again under the same name.
+// Of the accepted versions of a keyed output, the one that was applied last holds.
+func (val *txValidation) validate(outputsById map[string][]*meddb.OutputRes,
+	inputsById map[string][]*meddb.InputRes, votedBlock *meddb.Block) error {
+
+	var droppedBlock *meddb.Block = nil
+	for _, outputRes := range outputsById[val.droppedId] {
+		if BlockState(outputRes.Block.State) == BLOCK_STATE_ACCEPTED {
+			droppedBlock = outputRes.Block
+		}
+	}
+
+	// Get the state of all outputs.
+	acceptedOutputs := make(map[string]Output)
+	acceptedResponses := make(map[string]*meddb.OutputRes)
+	undecidedOutputs := make(map[string]Output)
+	for outputStrId, _ := range val.outputReqs {
+		for _, outputRes := range outputsById[outputStrId] {
+			// Want to ignore outputs from the same transaction
+			if bytes.Equal(val.txHash, outputRes.Transaction.Hash) {
+				continue
+			}
+			dbOutput := outputRes.Output
+			output, err := NewOutput(OutputType(dbOutput.Type), dbOutput.Data)
+			// TODO: Probably just ignore the output here.
+			if err != nil {
+				return err
+			}
+			if droppedBlock != nil && bytes.Equal(output.TableName(), val.tx.TableName) &&
+				!isDBBlockBefore(droppedBlock, outputRes.Block) {
+				// Belongs to a generation of the table that was dropped
+				continue
+			}
+			switch BlockState(outputRes.Block.State) {
+			case BLOCK_STATE_UNDECIDED:
+				undecidedOutputs[HashOutput(output).String()] = output
+			case BLOCK_STATE_ACCEPTED:
+				outputStrId := HashOutput(output).String()
+				if prevRes, ok := acceptedResponses[outputStrId]; ok &&
+					isOutputResBefore(outputRes, prevRes) {
+					continue
+				}
+				acceptedOutputs[outputStrId] = output
+				acceptedResponses[outputStrId] = outputRes
+			}
+		}
+	}
+
+	// Look at output requirements and make sure that they are met.
+	// The strategy for this is optimisitic. I.E. if there exists an accepted and undecided version
+	// of some output, it will take the accepted version.
+	undecidedOutputIds := make([][]byte, 0)
+	rejectedOutputIds := make([][]byte, 0) // rejected or missing
+	for outputStr, req := range val.outputReqs {
+		if _, ok := acceptedOutputs[outputStr]; !ok {
+			if _, undecidedOk := undecidedOutputs[outputStr]; undecidedOk {
+				// At least as strict as DECIDED
+				if req >= OUTPUT_REQUIREMENT_DECIDED {
+					undecidedOutputIds = append(undecidedOutputIds, []byte(outputStr))
+				}
+			} else {
+				// At least as strict as REQUIRED
+				if req >= OUTPUT_REQUIREMENT_REQUIRED {
+					rejectedOutputIds = append(rejectedOutputIds, []byte(outputStr))
+				}
+			}
+		}
+	}
+
+	if len(rejectedOutputIds) > 0 { // rejected or missing and were required
+		return &MissingOutputsError{OutputIds: rejectedOutputIds}
+	} else if len(undecidedOutputIds) > 0 { // in undecided block but was required to be decided
+		return &UndecidedOutputsError{OutputIds: undecidedOutputIds}
+	}
+
+	// Outputs can only be spent by one transaction that is not decided yet
+	inputResponses := make([]*meddb.InputRes, 0)
+	seen := make(map[string]bool)
+	for _, input := range val.tx.Inputs {
+		outputStrId := input.OutputHash().String()
+		if !seen[outputStrId] {
+			seen[outputStrId] = true
+			inputResponses = append(inputResponses, inputsById[outputStrId]...)
+		}
+	}
+	spentInputs, err := getSpentInputs(val.tx, val.txHash, inputResponses, votedBlock)
+	if err != nil {
+		return err
+	}
+
+	return val.tx.validateRuleset(val.ruleset, acceptedOutputs, spentInputs)
+}
+
+// Returns whether the output res was applied before the other one. Blocks are applied by height and
+// block id, transactions in a block in their order, which is by hash (see ValidateBlock).
+func isOutputResBefore(res, other *meddb.OutputRes) bool {
+	if !bytes.Equal(res.Block.Hash, other.Block.Hash) {
+		return isDBBlockBefore(res.Block, other.Block)
+	}
+	return bytes.Compare(res.Transaction.Hash, other.Transaction.Hash) < 0
+}
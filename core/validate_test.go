@@ -0,0 +1,368 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/crypto"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// Fails all reads of outputs
+type failingOutputsDB struct {
+	meddb.BlockchainDB
+	err error
+}
+
+func (db *failingOutputsDB) GetOutputs(outputIds [][]byte) ([]*meddb.OutputRes, error) {
+	return nil, db.err
+}
+
+// Creates the cars table with a writer and builds n PUT_CELLS transactions on different rows that
+// are signed by the writer.
+func getValidateTransactions(tb testing.TB, n int) (*Blockchain, []*Transaction) {
+	db, err := meddb.NewMemoryBlockchainDB()
+	assert.Nil(tb, err)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(tb, err)
+	writer := NewNode(priv)
+
+	tableName := []byte("cars")
+	writerOutput := &WriterOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		PubKey:         writer.PubKey,
+	}
+	createTx := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: tableName,
+		Outputs: []Output{
+			&TableExistsOutput{&TableNameMixin{tableName}},
+			&AllColsAllowedOutput{&TableNameMixin{tableName}},
+			writerOutput,
+		},
+	}
+	for i := 0; i < n; i++ {
+		createTx.Outputs = append(createTx.Outputs, &AllRowWritersOutput{
+			TableNameMixin: &TableNameMixin{tableName},
+			RowId:          []byte(fmt.Sprintf("row%d", i)),
+		})
+	}
+	writeSpendBlock(tb, db, BLOCK_STATE_ACCEPTED, createTx)
+
+	txs := make([]*Transaction, n)
+	for i := range txs {
+		txs[i] = &Transaction{
+			Type:      TRANSACTION_TYPE_PUT_CELLS,
+			TableName: tableName,
+			RowId:     []byte(fmt.Sprintf("row%d", i)),
+			Cols:      map[string]*Cell{"wheels": &Cell{Data: []byte{4}}},
+			Inputs:    []Input{&WriterInput{InputLink: InputLink{HashOutput(writerOutput)}}},
+		}
+		sig, err := crypto.Sign(txs[i].Hash().Bytes(), priv)
+		assert.Nil(tb, err)
+		txs[i].Inputs[0].FromData(sig)
+	}
+
+	return NewBlockchain(db, nil, writer, []*Node{writer}), txs
+}
+
+// Builds a DROP_TABLE transaction for the given generation of the cars table that is signed by the
+// admin.
+func getDropTransaction(t *testing.T, admin *Node, generation int64) *Transaction {
+	adminOutput := &AdminOutput{
+		TableNameMixin: &TableNameMixin{[]byte("cars")},
+		PubKey:         admin.PubKey,
+	}
+	tx := &Transaction{
+		Type:      TRANSACTION_TYPE_DROP_TABLE,
+		TableName: []byte("cars"),
+		Outputs: []Output{
+			&TableDroppedOutput{
+				TableNameMixin: &TableNameMixin{[]byte("cars")},
+				Generation:     big.NewInt(generation),
+			},
+		},
+		Inputs: []Input{&AdminInput{InputLink: InputLink{HashOutput(adminOutput)}}},
+	}
+
+	sig, err := crypto.Sign(tx.Hash().Bytes(), admin.PrivKey)
+	assert.Nil(t, err)
+	tx.Inputs[0].FromData(sig)
+	return tx
+}
+
+// -----
+// Tests
+// -----
+
+func TestValidateTransactions(t *testing.T) {
+	bc, txs := getValidateTransactions(t, 20)
+
+	// Broken signature
+	txs[3].Inputs[0].FromData([]byte{1, 2, 3})
+	// Writer output does not exist
+	txs[7].Inputs[0] = &WriterInput{InputLink: InputLink{StringToHash("missing")}}
+	// Invalid type
+	txs[11].Type = TransactionType(100)
+
+	errs, err := bc.ValidateTransactions(txs)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), len(errs))
+	for i, tx := range txs {
+		// Same result as validating one by one
+		assert.Equal(t, bc.ValidateTransaction(tx), errs[i], "Transaction %d", i)
+		if i == 3 {
+			assert.IsType(t, &RuleErrors{}, errs[i])
+		} else if i == 7 {
+			assert.IsType(t, &MissingOutputsError{}, errs[i])
+		} else if i == 11 {
+			assert.NotNil(t, errs[i])
+		} else {
+			assert.Nil(t, errs[i])
+		}
+	}
+}
+
+func TestValidateTransactionsEmpty(t *testing.T) {
+	bc, _ := getValidateTransactions(t, 0)
+	errs, err := bc.ValidateTransactions([]*Transaction{})
+	assert.Nil(t, err)
+	assert.Equal(t, []error{}, errs)
+}
+
+func TestValidateTransactionsDBError(t *testing.T) {
+	bc, txs := getValidateTransactions(t, 2)
+	// The transactions spend the same writer output, so only one of them fits in a block
+	b, err := bc.BuildBlock(txs[:1])
+	assert.Nil(t, err)
+
+	dbErr := errors.New("db is down")
+	bc.db = &failingOutputsDB{BlockchainDB: bc.db, err: dbErr}
+
+	// Not an error of any transaction
+	errs, err := bc.ValidateTransactions(txs)
+	assert.Nil(t, errs)
+	assert.Equal(t, dbErr, err)
+	assert.Equal(t, dbErr, bc.ValidateTransaction(txs[0]))
+
+	// Voters do not vote on the block
+	assert.Equal(t, dbErr, bc.ValidateBlock(b))
+}
+
+func TestValidateDropTable(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	drop := getDropTransaction(t, admin, 0)
+	assert.Nil(t, bc.ValidateTransaction(drop))
+
+	// Only the current generation can be dropped
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(getDropTransaction(t, admin, 1)))
+
+	// Not an admin
+	other := getDropTransaction(t, admin, 0)
+	other.Inputs = nil
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(other))
+
+	// Table waits for the drop to be decided
+	writeSpendBlock(t, db, BLOCK_STATE_UNDECIDED, drop)
+	assert.IsType(t, &UndecidedOutputsError{},
+		bc.ValidateTransaction(getSpendTransaction(t, admin, "wheels")))
+}
+
+func TestValidateDroppedTable(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+	writeStateBlock(t, db, 1, 0, BLOCK_STATE_ACCEPTED, getDropTransaction(t, admin, 0))
+
+	// Admin of the dropped generation is gone along with the table
+	assert.IsType(t, &MissingOutputsError{},
+		bc.ValidateTransaction(getSpendTransaction(t, admin, "wheels")))
+	assert.IsType(t, &MissingOutputsError{},
+		bc.ValidateTransaction(getDropTransaction(t, admin, 0)))
+	assert.IsType(t, &MissingOutputsError{},
+		bc.ValidateTransaction(getDropTransaction(t, admin, 1)))
+
+	// Table does not exist either
+	other := getSpendTransaction(t, admin, "wheels")
+	other.Inputs = nil
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(other))
+}
+
+func TestValidateRecreateTable(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+
+	priv, err := crypto.NewPrivateKey()
+	assert.Nil(t, err)
+	other := NewNode(priv)
+
+	tableName := []byte("cars")
+	create := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: tableName,
+		Outputs:   []Output{&TableExistsOutput{&TableNameMixin{tableName}}},
+	}
+	recreate := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: tableName,
+		Outputs: []Output{
+			&TableExistsOutput{&TableNameMixin{tableName}},
+			&TableGenerationOutput{
+				TableNameMixin: &TableNameMixin{tableName},
+				Generation:     big.NewInt(1),
+			},
+			&AdminOutput{TableNameMixin: &TableNameMixin{tableName}, PubKey: other.PubKey},
+		},
+	}
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(create))
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(recreate))
+
+	writeStateBlock(t, db, 1, 0, BLOCK_STATE_ACCEPTED, getDropTransaction(t, admin, 0))
+
+	// Has to be created under the next generation
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(create))
+	assert.Nil(t, bc.ValidateTransaction(recreate))
+
+	writeStateBlock(t, db, 2, 0, BLOCK_STATE_ACCEPTED, recreate)
+
+	assert.Nil(t, bc.ValidateTransaction(getSpendTransaction(t, other, "wheels")))
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(getDropTransaction(t, other, 0)))
+	assert.Nil(t, bc.ValidateTransaction(getDropTransaction(t, other, 1)))
+
+	// Admin of the dropped generation is not an admin of the new one
+	assert.IsType(t, &MissingOutputsError{},
+		bc.ValidateTransaction(getSpendTransaction(t, admin, "wheels")))
+	assert.IsType(t, &MissingOutputsError{},
+		bc.ValidateTransaction(getDropTransaction(t, admin, 1)))
+}
+
+func TestValidateRecreateTableSameAdmin(t *testing.T) {
+	bc, db, admin := getSpendBlockchain(t)
+	// Reads through the cache like in production, which holds the admin of the first generation
+	bc.db = newOutputCacheDB(db)
+	assert.Nil(t, bc.ValidateTransaction(getSpendTransaction(t, admin, "wheels")))
+
+	tableName := []byte("cars")
+	recreate := &Transaction{
+		Type:      TRANSACTION_TYPE_CREATE_TABLE,
+		TableName: tableName,
+		Outputs: []Output{
+			&TableExistsOutput{&TableNameMixin{tableName}},
+			&TableGenerationOutput{
+				TableNameMixin: &TableNameMixin{tableName},
+				Generation:     big.NewInt(1),
+			},
+			&AdminOutput{TableNameMixin: &TableNameMixin{tableName}, PubKey: admin.PubKey},
+		},
+	}
+	writeStateBlock(t, db, 1, 0, BLOCK_STATE_ACCEPTED, getDropTransaction(t, admin, 0))
+	writeStateBlock(t, db, 2, 0, BLOCK_STATE_ACCEPTED, recreate)
+
+	// Granted again under the same output id by the new generation
+	assert.Nil(t, bc.ValidateTransaction(getSpendTransaction(t, admin, "wheels")))
+	assert.Nil(t, bc.ValidateTransaction(getDropTransaction(t, admin, 1)))
+}
+
+func TestValidateColSchemas(t *testing.T) {
+	bc, txs := getValidateTransactions(t, 2)
+	// Reads through the cache like in production, which must not hold on to older schemas
+	bc.db = newOutputCacheDB(bc.db)
+	schemaTx := func(schema *ColSchema) *Transaction {
+		return &Transaction{
+			Type:      TRANSACTION_TYPE_UPDATE_TABLE,
+			TableName: []byte("cars"),
+			Outputs: []Output{&ColSchemaOutput{
+				TableNameMixin: &TableNameMixin{[]byte("cars")},
+				ColName:        schema.ColId,
+				ColType:        schema.Type,
+				MaxLength:      schema.MaxLength,
+				Nullable:       schema.Nullable,
+			}},
+		}
+	}
+
+	// No schemas yet
+	errs, err := bc.ValidateTransactions(txs)
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil, nil}, errs)
+
+	// Schemas that are not decided yet might apply once they are
+	writeStateBlock(t, bc.db, 1, 0, BLOCK_STATE_UNDECIDED,
+		schemaTx(getColSchema(COL_TYPE_INT64, 0, false)))
+	errs, err = bc.ValidateTransactions(txs)
+	assert.Nil(t, err)
+	assert.IsType(t, &UndecidedOutputsError{}, errs[0])
+	assert.IsType(t, &UndecidedOutputsError{}, errs[1])
+
+	writeStateBlock(t, bc.db, 2, 0, BLOCK_STATE_ACCEPTED,
+		schemaTx(getColSchema(COL_TYPE_INT64, 0, false)))
+	errs, err = bc.ValidateTransactions(txs)
+	assert.Nil(t, err)
+	assert.IsType(t, &RuleErrors{}, errs[0])
+	assert.IsType(t, &RuleErrors{}, errs[1])
+
+	// Latest schema of the column holds
+	writeStateBlock(t, bc.db, 3, 0, BLOCK_STATE_ACCEPTED,
+		schemaTx(getColSchema(COL_TYPE_BYTES, 1, false)))
+	errs, err = bc.ValidateTransactions(txs)
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil, nil}, errs)
+}
+
+func TestValidateColWriters(t *testing.T) {
+	bc, txs := getValidateTransactions(t, 1)
+	tableName := []byte("cars")
+	colWriter := &ColWriterOutput{
+		TableNameMixin: &TableNameMixin{tableName},
+		ColName:        []byte("wheels"),
+		PubKey:         bc.me.PubKey,
+	}
+	updateTx := &Transaction{
+		Type:      TRANSACTION_TYPE_UPDATE_TABLE,
+		TableName: tableName,
+		Outputs: []Output{
+			&RestrictedColOutput{
+				TableNameMixin: &TableNameMixin{tableName},
+				ColName:        []byte("wheels"),
+			},
+			colWriter,
+		},
+	}
+	writeSpendBlock(t, bc.db, BLOCK_STATE_ACCEPTED, updateTx)
+
+	assert.IsType(t, &RuleErrors{}, bc.ValidateTransaction(txs[0]))
+
+	tx := txs[0]
+	tx.Inputs = append(tx.Inputs, &ColWriterInput{InputLink: InputLink{HashOutput(colWriter)}})
+	sig, err := crypto.Sign(tx.Hash().Bytes(), bc.me.PrivKey)
+	assert.Nil(t, err)
+	for _, input := range tx.Inputs {
+		input.FromData(sig)
+	}
+	assert.Nil(t, bc.ValidateTransaction(tx))
+}
+
+// ----------
+// Benchmarks
+// ----------
+
+func BenchmarkValidateTransactionSequential(b *testing.B) {
+	bc, txs := getValidateTransactions(b, 1000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, tx := range txs {
+			bc.ValidateTransaction(tx)
+		}
+	}
+}
+
+func BenchmarkValidateTransactionsBatched(b *testing.B) {
+	bc, txs := getValidateTransactions(b, 1000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		bc.ValidateTransactions(txs)
+	}
+}
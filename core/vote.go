@@ -15,6 +15,15 @@ type Vote struct {
 	Value     bool
 }
 
+// Fraction of the voters of a block that have to agree on a value for the block to be decided.
+type Quorum struct {
+	Numerator   int
+	Denominator int
+}
+
+// Two thirds of the federation have to agree for a block to be decided.
+var DEFAULT_QUORUM = &Quorum{Numerator: 2, Denominator: 3}
+
 // --------
 // Vote API
 // --------
@@ -35,6 +44,58 @@ func (v *Vote) Hash() Hash {
 	})
 }
 
+// Returns the number of votes required to reach the quorum given the total number of voters.
+func (q *Quorum) Threshold(numVoters int) int {
+	// Ceiling of numVoters * Numerator / Denominator
+	return (numVoters*q.Numerator + q.Denominator - 1) / q.Denominator
+}
+
+// Counts the votes on the given block and returns the state that the block should be in.
+// Only votes from the voters of the block count and every voter counts at most once. A voter that
+// cast both a valid and an invalid vote on the same block is not counted at all.
+// The block is ACCEPTED once the quorum voted valid and REJECTED once the quorum voted invalid or
+// once there are not enough voters left to reach the quorum of valid votes.
+func TallyVotes(b *Block, vs []*Vote, q *Quorum) BlockState {
+	blockId := b.Hash()
+	voters := make(map[string]bool)
+	for _, voter := range b.Voters {
+		voters[string(voter)] = true
+	}
+
+	values := make(map[string]bool)
+	conflicting := make(map[string]bool)
+	for _, v := range vs {
+		voter := string(v.Voter)
+		if !voters[voter] || v.NextBlock != blockId {
+			continue
+		}
+		if value, ok := values[voter]; ok && value != v.Value {
+			conflicting[voter] = true
+		}
+		values[voter] = v.Value
+	}
+
+	numValid, numInvalid := 0, 0
+	for voter, value := range values {
+		if conflicting[voter] {
+			continue
+		}
+		if value {
+			numValid++
+		} else {
+			numInvalid++
+		}
+	}
+
+	threshold := q.Threshold(len(voters))
+	if numValid >= threshold {
+		return BLOCK_STATE_ACCEPTED
+	} else if numInvalid >= threshold || numInvalid > len(voters)-threshold {
+		return BLOCK_STATE_REJECTED
+	}
+	return BLOCK_STATE_UNDECIDED
+}
+
 func (v *Vote) toDBVote() *meddb.Vote {
 	var votedAt *big.Int = nil
 	if v.VotedAt != nil {
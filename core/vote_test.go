@@ -62,3 +62,69 @@ func TestDBVoteMapperEmpty(t *testing.T) {
 	back := fromDBVote(actual)
 	assert.Equal(t, v, back)
 }
+
+func TestQuorumThreshold(t *testing.T) {
+	assert.Equal(t, 1, DEFAULT_QUORUM.Threshold(1))
+	assert.Equal(t, 2, DEFAULT_QUORUM.Threshold(2))
+	assert.Equal(t, 2, DEFAULT_QUORUM.Threshold(3))
+	assert.Equal(t, 3, DEFAULT_QUORUM.Threshold(4))
+	assert.Equal(t, 4, DEFAULT_QUORUM.Threshold(6))
+	assert.Equal(t, 3, (&Quorum{Numerator: 1, Denominator: 2}).Threshold(5))
+}
+
+func getTallyBlock() *Block {
+	return &Block{
+		Transactions: []*Transaction{&Transaction{TableName: []byte("cars")}},
+		Voters:       [][]byte{[]byte{1}, []byte{2}, []byte{3}, []byte{4}},
+	}
+}
+
+func getTallyVote(b *Block, voter byte, value bool) *Vote {
+	return &Vote{Voter: []byte{voter}, NextBlock: b.Hash(), Value: value}
+}
+
+func TestTallyVotesAccepted(t *testing.T) {
+	b := getTallyBlock()
+	vs := []*Vote{
+		getTallyVote(b, 1, true),
+		getTallyVote(b, 2, true),
+		getTallyVote(b, 3, true),
+		getTallyVote(b, 4, false),
+	}
+	assert.Equal(t, BLOCK_STATE_ACCEPTED, TallyVotes(b, vs, DEFAULT_QUORUM))
+}
+
+func TestTallyVotesRejected(t *testing.T) {
+	b := getTallyBlock()
+	vs := []*Vote{
+		getTallyVote(b, 1, false),
+		getTallyVote(b, 2, false),
+	}
+	// Only 2 voters left, so 3 valid votes can no longer be reached
+	assert.Equal(t, BLOCK_STATE_REJECTED, TallyVotes(b, vs, DEFAULT_QUORUM))
+}
+
+func TestTallyVotesUndecided(t *testing.T) {
+	b := getTallyBlock()
+	vs := []*Vote{
+		getTallyVote(b, 1, true),
+		getTallyVote(b, 2, true),
+		getTallyVote(b, 3, false),
+	}
+	assert.Equal(t, BLOCK_STATE_UNDECIDED, TallyVotes(b, vs, DEFAULT_QUORUM))
+}
+
+func TestTallyVotesIgnoresInvalidVotes(t *testing.T) {
+	b := getTallyBlock()
+	otherB := &Block{Transactions: []*Transaction{&Transaction{TableName: []byte("bikes")}}}
+	vs := []*Vote{
+		getTallyVote(b, 1, true),
+		getTallyVote(b, 1, true),       // Duplicate vote
+		getTallyVote(b, 2, true),       // Conflicting votes
+		getTallyVote(b, 2, false),      // Conflicting votes
+		getTallyVote(b, 5, true),       // Not a voter
+		getTallyVote(otherB, 3, true),  // Vote on other block
+		getTallyVote(otherB, 4, false), // Vote on other block
+	}
+	assert.Equal(t, BLOCK_STATE_UNDECIDED, TallyVotes(b, vs, DEFAULT_QUORUM))
+}
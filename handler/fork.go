@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/wojtechnology/glacier/core"
+)
+
+// --------------------
+// JSON Data Structures
+// --------------------
+
+type VoteChainData struct {
+	Voter  string   `json:"voter"`  // Hex encoded
+	Blocks []string `json:"blocks"` // Hex encoded, in the order they were voted on
+}
+
+type ForkBranchData struct {
+	PrevBlock string   `json:"prev_block"` // Hex encoded, all zeros for the start of a chain
+	Voters    []string `json:"voters"`     // Hex encoded
+}
+
+type ForkData struct {
+	BlockId  string            `json:"block_id"` // Hex encoded
+	Branches []*ForkBranchData `json:"branches"` // Sorted by decreasing number of voters
+}
+
+type ForkReportData struct {
+	Chains         []*VoteChainData `json:"chains"`
+	Forks          []*ForkData      `json:"forks"`
+	CanonicalOrder []string         `json:"canonical_order"` // Hex encoded
+	Settled        int64            `json:"settled"`         // Blocks before canonical_order
+}
+
+// --------
+// Handlers
+// --------
+
+// Reports the vote chains of all voters and the forks between them as of the last fork check:
+// GET /forks
+func handleForks(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/forks" || r.Method != "GET" {
+		notFound(w)
+		return
+	}
+
+	// Checking for forks reads the votes of many blocks, so the fork detection loop does that in
+	// the background
+	report := blockchain.LastForkReport()
+	if report == nil {
+		unavailable(w)
+		return
+	}
+
+	jsonEncode(w, fromCoreForkReport(report))
+}
+
+// ---------------------------
+// JSON Data Structure Mappers
+// ---------------------------
+
+func fromCoreForkReport(report *core.ForkReport) *ForkReportData {
+	chains := make([]*VoteChainData, len(report.Chains))
+	for i, chain := range report.Chains {
+		chains[i] = &VoteChainData{
+			Voter:  hex.EncodeToString(chain.Voter),
+			Blocks: hashesToHex(chain.Blocks),
+		}
+	}
+
+	forks := make([]*ForkData, len(report.Forks))
+	for i, fork := range report.Forks {
+		branches := make([]*ForkBranchData, len(fork.Branches))
+		for j, branch := range fork.Branches {
+			voters := make([]string, len(branch.Voters))
+			for k, voter := range branch.Voters {
+				voters[k] = hex.EncodeToString(voter)
+			}
+			branches[j] = &ForkBranchData{
+				PrevBlock: hex.EncodeToString(branch.PrevBlock.Bytes()),
+				Voters:    voters,
+			}
+		}
+		forks[i] = &ForkData{
+			BlockId:  hex.EncodeToString(fork.BlockId.Bytes()),
+			Branches: branches,
+		}
+	}
+
+	return &ForkReportData{
+		Chains:         chains,
+		Forks:          forks,
+		CanonicalOrder: hashesToHex(report.CanonicalOrder),
+		Settled:        report.Settled,
+	}
+}
+
+func hashesToHex(hashes []core.Hash) []string {
+	hexes := make([]string, len(hashes))
+	for i, hash := range hashes {
+		hexes[i] = hex.EncodeToString(hash.Bytes())
+	}
+	return hexes
+}
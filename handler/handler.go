@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
+	"strings"
 
 	"github.com/wojtechnology/glacier/core"
 	"github.com/wojtechnology/glacier/logging"
@@ -19,6 +22,9 @@ func SetBlockchain(bc *core.Blockchain) {
 
 func SetupRoutes() {
 	http.HandleFunc("/transaction/", handleTransaction)
+	http.HandleFunc("/table/", handleTable)
+	http.HandleFunc("/scan/", handleScan)
+	http.HandleFunc("/forks", handleForks)
 }
 
 // --------------------
@@ -26,8 +32,9 @@ func SetupRoutes() {
 // --------------------
 
 type CellData struct {
-	Data  string   `json:"data"` // Base64 encoded
-	VerId *big.Int `json:"ver_id"`
+	Data      string   `json:"data"` // Base64 encoded
+	VerId     *big.Int `json:"ver_id"`
+	Tombstone bool     `json:"tombstone,omitempty"` // Only set when reading with history
 }
 
 type OutputData struct {
@@ -50,6 +57,33 @@ type TransactionData struct {
 	Outputs   []*OutputData        `json:"outputs"`
 }
 
+type TransactionHashData struct {
+	Hash string `json:"hash"` // Hex encoded
+}
+
+type RejectionData struct {
+	ErrorType  string   `json:"error_type"`
+	Message    string   `json:"message"`
+	Node       string   `json:"node"` // Hex encoded
+	RejectedAt *big.Int `json:"rejected_at"`
+}
+
+type TransactionStatusData struct {
+	Hash      string         `json:"hash"`                // Hex encoded
+	State     string         `json:"state"`               // See TRANSACTION_STATE_NAMES
+	BlockId   string         `json:"block_id,omitempty"`  // Hex encoded, empty if not in a block
+	Rejection *RejectionData `json:"rejection,omitempty"` // Only set if INVALID
+}
+
+var TRANSACTION_STATE_NAMES = map[core.TransactionState]string{
+	core.TRANSACTION_STATE_UNKNOWN:   "UNKNOWN",
+	core.TRANSACTION_STATE_BACKLOG:   "BACKLOG",
+	core.TRANSACTION_STATE_UNDECIDED: "UNDECIDED",
+	core.TRANSACTION_STATE_ACCEPTED:  "ACCEPTED",
+	core.TRANSACTION_STATE_REJECTED:  "REJECTED",
+	core.TRANSACTION_STATE_INVALID:   "INVALID",
+}
+
 // --------
 // Handlers
 // --------
@@ -60,34 +94,70 @@ func badRequest(w http.ResponseWriter, err error) {
 	fmt.Fprintf(w, "bad request\n")
 }
 
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(404)
+	fmt.Fprintf(w, "not found\n")
+}
+
+func unavailable(w http.ResponseWriter) {
+	w.WriteHeader(503)
+	fmt.Fprintf(w, "service unavailable\n")
+}
+
+func serverError(w http.ResponseWriter, err error) {
+	logging.Error(err.Error())
+	w.WriteHeader(500)
+	fmt.Fprintf(w, "internal server error\n")
+}
+
+// Adds a transaction: POST /transaction/
+// Responds with the hash of the transaction, which can be used to look up its status.
+//
+// Reads the status of a transaction: GET /transaction/{hash}
 func handleTransaction(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/transaction/" {
-		w.WriteHeader(404)
-		fmt.Fprintf(w, "not found\n")
+	if r.URL.Path == "/transaction/" && r.Method == "POST" {
+		postTransaction(w, r)
+	} else if r.URL.Path != "/transaction/" && r.Method == "GET" {
+		getTransactionStatus(w, r)
+	} else {
+		notFound(w)
+	}
+}
+
+func postTransaction(w http.ResponseWriter, r *http.Request) {
+	var tr TransactionData
+	defer r.Body.Close()
+	if err := jsonDecode(r, &tr); err != nil {
+		badRequest(w, err)
+		return
+	}
+	tx, err := tr.toCoreTransaction()
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	if err := blockchain.AddTransaction(tx); err != nil {
+		badRequest(w, err)
 		return
 	}
 
-	switch r.Method {
-	case "POST":
-		var tr TransactionData
-		defer r.Body.Close()
-		if err := jsonDecode(r, &tr); err != nil {
-			return
-		}
-		tx, err := tr.toCoreTransaction()
-		if err != nil {
-			badRequest(w, err)
-			return
-		}
-		if err := blockchain.AddTransaction(tx); err != nil {
-			badRequest(w, err)
-			return
-		}
+	jsonEncode(w, &TransactionHashData{Hash: hex.EncodeToString(tx.Hash().Bytes())})
+}
 
-	default:
-		w.WriteHeader(404)
-		fmt.Fprintf(w, "not found\n")
+func getTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	txHash, err := parseHash(strings.TrimPrefix(r.URL.Path, "/transaction/"))
+	if err != nil {
+		badRequest(w, err)
+		return
 	}
+
+	status, err := blockchain.GetTransactionStatus(txHash)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+
+	jsonEncode(w, fromCoreTransactionStatus(txHash, status))
 }
 
 // -------
@@ -103,6 +173,26 @@ func jsonDecode(r *http.Request, o interface{}) error {
 	return nil
 }
 
+// Parses a hex encoded hash.
+func parseHash(s string) (core.Hash, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return core.Hash{}, err
+	}
+	if len(b) != core.HashLength {
+		return core.Hash{}, errors.New(fmt.Sprintf("Invalid hash length %d\n", len(b)))
+	}
+	return core.BytesToHash(b), nil
+}
+
+func jsonEncode(w http.ResponseWriter, o interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(o); err != nil {
+		logging.Error(err.Error())
+	}
+}
+
 // ---------------------------
 // JSON Data Structure Mappers
 // ---------------------------
@@ -128,8 +218,9 @@ func fromCoreCell(c *core.Cell) *CellData {
 		verId = big.NewInt(c.VerId.Int64())
 	}
 	return &CellData{
-		Data:  base64.StdEncoding.EncodeToString(c.Data),
-		VerId: verId,
+		Data:      base64.StdEncoding.EncodeToString(c.Data),
+		VerId:     verId,
+		Tombstone: c.Tombstone,
 	}
 }
 
@@ -259,3 +350,35 @@ func FromCoreTransaction(tx *core.Transaction) *TransactionData {
 		Inputs:    inputs,
 	}
 }
+
+func fromCoreTransactionStatus(txHash core.Hash,
+	status *core.TransactionStatus) *TransactionStatusData {
+
+	blockId := ""
+	if status.BlockId != nil {
+		blockId = hex.EncodeToString(status.BlockId.Bytes())
+	}
+	var rejection *RejectionData = nil
+	if status.Rejection != nil {
+		rejection = fromCoreRejection(status.Rejection)
+	}
+	return &TransactionStatusData{
+		Hash:      hex.EncodeToString(txHash.Bytes()),
+		State:     TRANSACTION_STATE_NAMES[status.State],
+		BlockId:   blockId,
+		Rejection: rejection,
+	}
+}
+
+func fromCoreRejection(rej *core.Rejection) *RejectionData {
+	var rejectedAt *big.Int = nil
+	if rej.RejectedAt != nil {
+		rejectedAt = big.NewInt(rej.RejectedAt.Int64())
+	}
+	return &RejectionData{
+		ErrorType:  rej.ErrorType,
+		Message:    rej.Message,
+		Node:       hex.EncodeToString(rej.Node),
+		RejectedAt: rejectedAt,
+	}
+}
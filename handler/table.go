@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+// --------------------
+// JSON Data Structures
+// --------------------
+
+// Most rows that a page of a scan can have
+const maxScanPageSize = 1000
+
+type RowData struct {
+	TableName string                 `json:"table_name"`
+	RowId     string                 `json:"row_id"`
+	Cols      map[string][]*CellData `json:"cols"` // Sorted by decreasing ver_id
+}
+
+type ScanData struct {
+	TableName     string     `json:"table_name"`
+	Rows          []*RowData `json:"rows"`                      // Sorted by row_id
+	NextPageToken string     `json:"next_page_token,omitempty"` // Base64 encoded
+}
+
+// --------
+// Handlers
+// --------
+
+// Reads cells of a single row: GET /table/{tableName}/row/{rowId}
+//
+// Query parameters:
+//
+//	col              Column to read, can be given multiple times. At least one is required.
+//	ver              Only read the cells with exactly this version.
+//	min_ver, max_ver Only read the cells with versions in this range (inclusive).
+//	limit            Read at most this many of the newest versions of each column, 0 reads all.
+//	history          If true, deleted cells and the tombstones that deleted them are read too.
+//
+// If none of ver, min_ver/max_ver and limit are given, only the newest version is read.
+func handleTable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		notFound(w)
+		return
+	}
+
+	tableName, rowId, err := parseRowPath(r.URL)
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	op, err := parseGetOp(rowId, r.URL.Query())
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	cells, err := blockchain.GetCells(tableName, op)
+	if err != nil {
+		if _, ok := err.(*meddb.TableNotFoundError); ok {
+			notFound(w)
+			return
+		}
+		serverError(w, err)
+		return
+	}
+
+	jsonEncode(w, fromCoreRow(tableName, &core.Row{RowId: rowId, Cols: cells}))
+}
+
+// Reads a page of the rows of a table: GET /scan/{tableName}
+//
+// Query parameters:
+//
+//	col        Column to read, can be given multiple times. Reads all columns if none are given.
+//	start, end Only read the rows from start up to, but not including, end.
+//	prefix     Only read the rows that start with the prefix.
+//	limit      Read at most this many of the newest versions of each column, 0 reads all.
+//	history    If true, deleted cells and the tombstones that deleted them are read too.
+//	page_size  Read at most this many rows, at most 1000. Defaults to 100.
+//	page_token Continue at the next_page_token of the previous page.
+//
+// If limit is not given, only the newest version is read. The response has no next_page_token on
+// the last page.
+func handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		notFound(w)
+		return
+	}
+
+	tableName, err := parseScanPath(r.URL)
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	op, err := parseScanOp(r.URL.Query())
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	rows, pageToken, err := blockchain.ScanRows(tableName, op)
+	if err != nil {
+		if _, ok := err.(*meddb.TableNotFoundError); ok {
+			notFound(w)
+			return
+		}
+		serverError(w, err)
+		return
+	}
+
+	sd := &ScanData{TableName: string(tableName), Rows: make([]*RowData, len(rows))}
+	for i, row := range rows {
+		sd.Rows[i] = fromCoreRow(tableName, row)
+	}
+	if pageToken != nil {
+		sd.NextPageToken = base64.StdEncoding.EncodeToString(pageToken)
+	}
+	jsonEncode(w, sd)
+}
+
+// -------
+// Helpers
+// -------
+
+// Parses table name and row id out of a path of the form /table/{tableName}/row/{rowId}.
+// Both the table name and the row id may be url escaped.
+func parseRowPath(u *url.URL) ([]byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/table/"), "/")
+	if len(parts) != 3 || parts[1] != "row" || parts[0] == "" || parts[2] == "" {
+		return nil, nil, errors.New(fmt.Sprintf("Invalid row path: %s\n", u.Path))
+	}
+
+	tableName, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	rowId, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return nil, nil, err
+	}
+	return []byte(tableName), []byte(rowId), nil
+}
+
+// Parses the table name out of a path of the form /scan/{tableName}.
+// The table name may be url escaped.
+func parseScanPath(u *url.URL) ([]byte, error) {
+	tableName := strings.TrimPrefix(u.EscapedPath(), "/scan/")
+	if tableName == "" || strings.Contains(tableName, "/") {
+		return nil, errors.New(fmt.Sprintf("Invalid scan path: %s\n", u.Path))
+	}
+
+	tableName, err := url.PathUnescape(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(tableName), nil
+}
+
+// Builds the ScanOp matching the given query parameters.
+func parseScanOp(query url.Values) (*meddb.ScanOp, error) {
+	var colIds [][]byte = nil
+	if len(query["col"]) > 0 {
+		colIds = make([][]byte, len(query["col"]))
+		for i, colId := range query["col"] {
+			colIds[i] = []byte(colId)
+		}
+	}
+	op := meddb.NewScanOp(colIds).WithLimit(1)
+
+	var start, end []byte = nil, nil
+	if s := query.Get("start"); s != "" {
+		start = []byte(s)
+	}
+	if e := query.Get("end"); e != "" {
+		end = []byte(e)
+	}
+	op.WithRange(start, end)
+
+	if prefix := query.Get("prefix"); prefix != "" {
+		op.WithPrefix([]byte(prefix))
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		op.WithLimit(uint32(n))
+	}
+
+	if history := query.Get("history"); history != "" {
+		withHistory, err := strconv.ParseBool(history)
+		if err != nil {
+			return nil, err
+		}
+		if withHistory {
+			op.WithHistory()
+		}
+	}
+
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		n, err := strconv.ParseUint(pageSize, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || n > maxScanPageSize {
+			return nil, errors.New(fmt.Sprintf("page_size must be between 1 and %d\n",
+				maxScanPageSize))
+		}
+		op.WithPageSize(uint32(n))
+	}
+
+	if pageToken := query.Get("page_token"); pageToken != "" {
+		token, err := base64.StdEncoding.DecodeString(pageToken)
+		if err != nil {
+			return nil, err
+		}
+		op.WithPageToken(token)
+	}
+
+	return op, nil
+}
+
+// Builds the GetOp matching the given query parameters.
+func parseGetOp(rowId []byte, query url.Values) (*meddb.GetOp, error) {
+	colIds := make([][]byte, len(query["col"]))
+	for i, colId := range query["col"] {
+		colIds[i] = []byte(colId)
+	}
+	if len(colIds) == 0 {
+		return nil, errors.New("At least one col is required\n")
+	}
+
+	op, err := parseGetOpVersions(rowId, colIds, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if history := query.Get("history"); history != "" {
+		withHistory, err := strconv.ParseBool(history)
+		if err != nil {
+			return nil, err
+		}
+		if withHistory {
+			op.WithHistory()
+		}
+	}
+	return op, nil
+}
+
+// Builds the GetOp for the versions selected by the query parameters.
+func parseGetOpVersions(rowId []byte, colIds [][]byte, query url.Values) (*meddb.GetOp, error) {
+
+	if ver := query.Get("ver"); ver != "" {
+		verId, err := strconv.ParseInt(ver, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return meddb.NewGetOpVer(rowId, colIds, verId), nil
+	}
+
+	minVer, maxVer := query.Get("min_ver"), query.Get("max_ver")
+	if minVer != "" || maxVer != "" {
+		if minVer == "" || maxVer == "" {
+			return nil, errors.New("Both min_ver and max_ver are required for a range\n")
+		}
+		min, err := strconv.ParseInt(minVer, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		max, err := strconv.ParseInt(maxVer, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return meddb.NewGetOpRange(rowId, colIds, min, max), nil
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return meddb.NewGetOp(rowId, colIds), nil
+		}
+		return meddb.NewGetOpLimit(rowId, colIds, uint32(n)), nil
+	}
+
+	return meddb.NewGetOpLimit(rowId, colIds, 1), nil
+}
+
+func fromCoreRow(tableName []byte, row *core.Row) *RowData {
+	rd := &RowData{
+		TableName: string(tableName),
+		RowId:     string(row.RowId),
+		Cols:      make(map[string][]*CellData),
+	}
+	for colId, col := range row.Cols {
+		rd.Cols[colId] = make([]*CellData, len(col))
+		for i, cell := range col {
+			rd.Cols[colId][i] = fromCoreCell(cell)
+		}
+	}
+	return rd
+}
@@ -0,0 +1,48 @@
+package loop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/logging"
+)
+
+// Applies the transactions of accepted blocks to the bigtable every time a block is decided.
+func ApplyBlocksLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
+	cursor, err := bc.GetBlockChangefeed()
+	if err != nil {
+		errChannel <- err
+		return
+	}
+	defer closeWhenDone(ctx, cursor)()
+
+	// Catch up on blocks that were decided while this node was down
+	if err := applyBlocks(bc); err != nil {
+		errChannel <- err
+		return
+	}
+
+	var res core.BlockChange
+	for cursor.Next(&res) {
+		if res.NewBlock != nil && res.NewBlock.State != core.BLOCK_STATE_UNDECIDED {
+			if err := applyBlocks(bc); err != nil {
+				// Restarting catches up on the blocks that were not applied
+				errChannel <- err
+				return
+			}
+		}
+	}
+
+	if ctx.Err() == nil {
+		errChannel <- errors.New("For some reason the block changefeed stopped...\n")
+	}
+}
+
+func applyBlocks(bc *core.Blockchain) error {
+	applied, err := bc.ApplyBlocks()
+	if applied > 0 {
+		logging.Info("Applied %d blocks", applied)
+	}
+	return err
+}
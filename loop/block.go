@@ -1,6 +1,7 @@
 package loop
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"time"
@@ -10,13 +11,6 @@ import (
 	"github.com/wojtechnology/glacier/logging"
 )
 
-// TODO: This should be in config
-const (
-	blockLoopWaitMS      = 1000 // Wait time between polling for stale transactions
-	blockMinTransactions = 100
-	blockLongestWaitMS   = 5000
-)
-
 // TODO: Make better abstraction for loops sort of like map/reduce
 type blockLoopState struct {
 	lastBlockMS int64
@@ -66,17 +60,23 @@ func (state *blockLoopState) removeTransactions(txs []*core.Transaction) {
 	}
 }
 
-func AddBlockLoop(bc *core.Blockchain, errChannel chan<- error) {
+func AddBlockLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
 	s := newBlockLoopState()
 
 	cursor, err := bc.GetMyTransactionChangefeed()
 	if err != nil {
 		errChannel <- err
+		return
 	}
+	defer closeWhenDone(ctx, cursor)()
 
 	txChannel := make(chan bool, 1)
+	stopChannel := make(chan bool)
+	stoppedChannel := make(chan bool)
 
 	go func(bc *core.Blockchain, s *blockLoopState, txChannel chan bool, errChannel chan<- error) {
+		defer close(stoppedChannel)
+
 		// Get transactions that were assigned previously.
 		txs, err := bc.GetMyTransactions()
 		if err != nil {
@@ -85,16 +85,19 @@ func AddBlockLoop(bc *core.Blockchain, errChannel chan<- error) {
 		s.addTransactions(txs)
 
 		// Trigger an attempted addBlock at the beginning
-		txChannel <- true
+		triggerAddBlock(txChannel)
 
-		tickerChannel := getTickerChannel()
+		ticker := getTicker()
+		defer ticker.Stop()
 		for {
 			var err error
 			select {
-			case <-tickerChannel:
+			case <-ticker.C:
 				err = addBlock(bc, s)
 			case <-txChannel:
 				err = addBlock(bc, s)
+			case <-stopChannel:
+				return
 			}
 			if err != nil {
 				errChannel <- err
@@ -108,15 +111,30 @@ func AddBlockLoop(bc *core.Blockchain, errChannel chan<- error) {
 		if res.NewTransaction != nil {
 			// Update or insert (not delete)
 			s.addTransactions([]*core.Transaction{res.NewTransaction})
-			txChannel <- true
+			triggerAddBlock(txChannel)
 		}
 	}
 
-	errChannel <- errors.New("For some reason the transaction changefeed stopped...\n")
+	// Block that is being built is finished first, so its transactions are not left half done
+	close(stopChannel)
+	<-stoppedChannel
+
+	if ctx.Err() == nil {
+		errChannel <- errors.New("For some reason the transaction changefeed stopped...\n")
+	}
+}
+
+// Triggers an attempted addBlock, unless one is pending already. The pending attempt sees all
+// transactions added until then.
+func triggerAddBlock(txChannel chan<- bool) {
+	select {
+	case txChannel <- true:
+	default:
+	}
 }
 
-func getTickerChannel() <-chan time.Time {
-	return time.NewTicker(time.Millisecond * blockLoopWaitMS).C
+func getTicker() *time.Ticker {
+	return time.NewTicker(time.Millisecond * time.Duration(config.BlockLoopWaitMS))
 }
 
 func addBlock(bc *core.Blockchain, s *blockLoopState) error {
@@ -124,8 +142,8 @@ func addBlock(bc *core.Blockchain, s *blockLoopState) error {
 
 	nowMS := common.Now()
 	timePassed := time.Unix(0, nowMS-s.lastBlockMS)
-	if len(txs) == 0 || (len(txs) < blockMinTransactions &&
-		timePassed.Before(time.Unix(0, blockLongestWaitMS))) {
+	if len(txs) == 0 || (len(txs) < config.BlockMinTransactions &&
+		timePassed.Before(time.Unix(0, config.BlockLongestWaitMS))) {
 
 		return nil
 	}
@@ -134,19 +152,26 @@ func addBlock(bc *core.Blockchain, s *blockLoopState) error {
 	validTxs := make([]*core.Transaction, 0)
 	invalidTxs := make([]*core.Transaction, 0)
 	undecidedTxs := make([]*core.Transaction, 0)
+	rejections := make([]*core.Rejection, 0)
 
-	// Validate transactions
-	for _, tx := range txs {
-		err := bc.ValidateTransaction(tx)
+	// Validate transactions. If the databases cannot be read, the transactions stay in the state
+	// and are validated again on the next attempt.
+	errs, err := bc.ValidateTransactions(txs)
+	if err != nil {
+		return err
+	}
+	for i, tx := range txs {
+		err := errs[i]
 		if err != nil {
 			logging.Error(err.Error())
-			if _, ok := err.(*core.UndecidedOutputsError); ok {
+			switch err.(type) {
+			case *core.UndecidedOutputsError, *core.SpentOutputsError:
 				// Could be decided later so put these back into backlog
 				undecidedTxs = append(undecidedTxs, tx)
-			} else {
+			default:
 				// Some other error occurred during validation meaning that tx is invalid
-				// TODO: Log error
 				invalidTxs = append(invalidTxs, tx)
+				rejections = append(rejections, bc.NewRejection(tx, err))
 			}
 		} else {
 			// Validation succeeded, add this transactions to the block
@@ -154,6 +179,14 @@ func addBlock(bc *core.Blockchain, s *blockLoopState) error {
 		}
 	}
 
+	// Only one transaction in the block can spend each output, the others wait for the next block
+	validTxs, conflictingTxs := core.ResolveConflicts(validTxs)
+	for _, tx := range conflictingTxs {
+		logging.Info("Transaction %x spends the same outputs as another transaction in the block",
+			tx.Hash().Bytes())
+	}
+	undecidedTxs = append(undecidedTxs, conflictingTxs...)
+
 	if len(validTxs) > 0 {
 		// Only create a block if we actually have valid transactions
 		b, err := bc.BuildBlock(validTxs)
@@ -170,12 +203,14 @@ func addBlock(bc *core.Blockchain, s *blockLoopState) error {
 	// Remove transactions from state, they have all been dealt with at this point
 	s.removeTransactions(txs)
 
-	// Reassign all undecided transactions
-	// TODO: Maybe have a bulk write here
-	for _, tx := range undecidedTxs {
-		// Essentially an update
-		err := bc.AddTransaction(tx)
-		if err != nil {
+	// Reassign all undecided transactions, essentially an update
+	if err := firstError(bc.AddTransactions(undecidedTxs)); err != nil {
+		return err
+	}
+
+	// Record why invalid transactions were rejected before they disappear from the backlog
+	if len(rejections) > 0 {
+		if err := bc.WriteRejections(rejections); err != nil {
 			return err
 		}
 	}
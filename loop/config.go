@@ -0,0 +1,53 @@
+package loop
+
+import "github.com/wojtechnology/glacier/core"
+
+// Settings shared by all loops. All durations are in ms.
+type Config struct {
+	HTTPAddr string // Address the IO loop listens on
+
+	BlockLoopWaitMS      int64 // Wait time between attempts to create a block
+	BlockMinTransactions int   // Number of transactions that creates a block right away
+	BlockLongestWaitMS   int64 // Longest wait for more transactions before creating a block
+
+	ReassignLoopWaitMS int64 // Wait time between polling for stale transactions
+	ReassignStaleAgeMS int64 // Age after which transactions in the backlog are reassigned
+
+	PruneRejectionsLoopWaitMS int64 // Wait time between deleting old rejections
+	RejectionRetentionMS      int64 // Age after which rejections are deleted
+
+	ForkCheckLoopWaitMS int64 // Wait time between checks of the vote chains for forks
+
+	HeartbeatLoopWaitMS int64 // Wait time between heartbeats of this node
+	HeartbeatStaleAgeMS int64 // Age after which nodes without a newer heartbeat are dead
+
+	RestartMinBackoffMS int64 // Wait time before restarting a loop that stopped the first time
+	RestartMaxBackoffMS int64 // Longest wait time before restarting a loop that keeps stopping
+	ShutdownTimeoutMS   int64 // Longest wait for loops to finish their work on shutdown
+}
+
+var config = DefaultConfig()
+
+func DefaultConfig() *Config {
+	return &Config{
+		HTTPAddr:                  ":8000",
+		BlockLoopWaitMS:           1000,
+		BlockMinTransactions:      100,
+		BlockLongestWaitMS:        5000,
+		ReassignLoopWaitMS:        30000,
+		ReassignStaleAgeMS:        30000,
+		PruneRejectionsLoopWaitMS: 3600000,   // 1 hour
+		RejectionRetentionMS:      604800000, // 7 days
+		ForkCheckLoopWaitMS:       60000,
+		HeartbeatLoopWaitMS:       2000,
+		HeartbeatStaleAgeMS:       core.DEFAULT_HEARTBEAT_STALE_AGE_MS,
+		RestartMinBackoffMS:       1000,
+		RestartMaxBackoffMS:       60000,
+		ShutdownTimeoutMS:         10000,
+	}
+}
+
+// Sets the config used by all loops. Must be called before any loop is started.
+func SetConfig(c *Config) {
+	config = c
+}
@@ -0,0 +1,46 @@
+package loop
+
+import (
+	"context"
+	"encoding/hex"
+	"expvar"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/logging"
+)
+
+// Metrics of the last fork check, served by expvar on /debug/vars
+var (
+	forkCount      = expvar.NewInt("forks")
+	canonicalCount = expvar.NewInt("canonical_blocks")
+	voteChainCount = expvar.NewInt("vote_chains")
+)
+
+// Periodically reconstructs the vote chains of all voters and reports forks between them.
+// Every fork that was not seen in an earlier check is logged as an error. The last report is
+// served on GET /forks.
+func ForkDetectionLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
+	reported := make(map[core.Hash]bool)
+	for {
+		report, err := bc.DetectForks()
+		if err != nil {
+			errChannel <- err
+		} else {
+			forkCount.Set(int64(len(report.Forks)))
+			canonicalCount.Set(report.Settled + int64(len(report.CanonicalOrder)))
+			voteChainCount.Set(int64(len(report.Chains)))
+
+			for _, fork := range report.Forks {
+				if reported[fork.BlockId] {
+					continue
+				}
+				reported[fork.BlockId] = true
+				logging.Error("Fork detected at block %s, voters disagree on %d predecessors",
+					hex.EncodeToString(fork.BlockId.Bytes()), len(fork.Branches))
+			}
+		}
+		if !sleep(ctx, config.ForkCheckLoopWaitMS) {
+			return
+		}
+	}
+}
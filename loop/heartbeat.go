@@ -0,0 +1,25 @@
+package loop
+
+import (
+	"context"
+
+	"github.com/wojtechnology/glacier/core"
+)
+
+// Tells the federation that this node is alive, so that transactions keep being assigned to it.
+// Also watches the heartbeats of the other nodes, since their age is measured from when this node
+// sees them change (see Blockchain.LiveNodes).
+func HeartbeatLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
+	for {
+		err := bc.WriteHeartbeat()
+		if err != nil {
+			errChannel <- err
+		}
+		if _, err := bc.LiveNodes(); err != nil {
+			errChannel <- err
+		}
+		if !sleep(ctx, config.HeartbeatLoopWaitMS) {
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package loop
+
+import (
+	"context"
+	"time"
+
+	"github.com/wojtechnology/glacier/core"
+)
+
+// Number of blocks read at a time when catching up on blocks after a restart
+const catchUpBlockBatchSize = 10
+
+// Returns the first error that is not nil, nil if there is none.
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Waits for the given time in ms. Returns false if ctx is done before that.
+func sleep(ctx context.Context, ms int64) bool {
+	timer := time.NewTimer(time.Millisecond * time.Duration(ms))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Calls f with every block after the state cursor, in the order of the blockchain. Every block
+// before the state cursor is decided, so loops that restart catch up on the blocks after it.
+// Stops at the first error.
+func forEachBlockAfterStateCursor(bc *core.Blockchain, f func(b *core.Block) error) error {
+	var (
+		height  int64 = -1
+		blockId core.Hash
+	)
+	stateCursor, err := bc.GetStateCursor()
+	if err != nil {
+		return err
+	}
+	if stateCursor != nil {
+		height, blockId = stateCursor.Height.Int64(), core.BytesToHash(stateCursor.BlockId)
+	}
+
+	for {
+		bs, err := bc.GetBlocksAfter(height, blockId, catchUpBlockBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range bs {
+			height, blockId = b.Height.Int64(), b.Hash()
+			if err := f(b); err != nil {
+				return err
+			}
+		}
+
+		if len(bs) < catchUpBlockBatchSize {
+			return nil
+		}
+	}
+}
+
+type closer interface {
+	Close() error
+}
+
+// Closes the changefeed cursor once ctx is done, which makes a loop that waits on Next return.
+// The returned func closes the cursor right away, loops defer it so that the cursor is also closed
+// when they stop for any other reason.
+func closeWhenDone(ctx context.Context, cursor closer) func() {
+	stopped := make(chan bool)
+	closed := make(chan bool)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopped:
+		}
+		cursor.Close()
+		close(closed)
+	}()
+
+	return func() {
+		close(stopped)
+		<-closed
+	}
+}
@@ -1,14 +1,39 @@
 package loop
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/wojtechnology/glacier/core"
 	"github.com/wojtechnology/glacier/handler"
 )
 
-func IOLoop(bc *core.Blockchain, errChannel chan<- error) {
+// Routes go to the default mux, which panics when they are registered twice
+var setupRoutesOnce sync.Once
+
+// Serves the HTTP API until ctx is done. Requests that are in flight by then are finished before
+// the loop returns, unless that takes longer than the shutdown timeout.
+func IOLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
 	handler.SetBlockchain(bc)
-	handler.SetupRoutes()
-	http.ListenAndServe(":8000", nil)
+	setupRoutesOnce.Do(handler.SetupRoutes)
+
+	server := &http.Server{Addr: config.HTTPAddr}
+	serveErrChannel := make(chan error, 1)
+	go func() {
+		serveErrChannel <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErrChannel:
+		errChannel <- err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(),
+			time.Millisecond*time.Duration(config.ShutdownTimeoutMS))
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			errChannel <- err
+		}
+	}
 }
@@ -1,42 +1,32 @@
 package loop
 
 import (
-	"time"
+	"context"
 
 	"github.com/wojtechnology/glacier/core"
 )
 
-const (
-	reassignLoopWaitMS = 30000
-	reassignStaleAgeMS = 30000
-)
+func ReassignTransactionsLoop(ctx context.Context, bc *core.Blockchain,
+	errChannel chan<- error) {
 
-func ReassignTransactionsLoop(bc *core.Blockchain, errChannel chan<- error) {
-	for true {
+	for {
 		err := reassignTransactions(bc)
 		if err != nil {
 			errChannel <- err
 		}
 		// TODO: Adjust for time spent
-		timeChannel := time.After(time.Millisecond * reassignLoopWaitMS)
-		<-timeChannel
+		if !sleep(ctx, config.ReassignLoopWaitMS) {
+			return
+		}
 	}
 }
 
 func reassignTransactions(bc *core.Blockchain) error {
-	staleTxs, err := bc.GetStaleTransactions(reassignStaleAgeMS)
+	staleTxs, err := bc.GetStaleTransactions(config.ReassignStaleAgeMS)
 	if err != nil {
 		return err
 	}
 
-	// TODO: Maybe have a bulk write here
-	for _, tx := range staleTxs {
-		// Essentially an update
-		err := bc.AddTransaction(tx)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	// Essentially an update
+	return firstError(bc.AddTransactions(staleTxs))
 }
@@ -0,0 +1,20 @@
+package loop
+
+import (
+	"context"
+
+	"github.com/wojtechnology/glacier/core"
+)
+
+// Deletes rejections of invalid transactions once they are older than the retention period.
+func PruneRejectionsLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
+	for {
+		err := bc.DeleteOldRejections(config.RejectionRetentionMS)
+		if err != nil {
+			errChannel <- err
+		}
+		if !sleep(ctx, config.PruneRejectionsLoopWaitMS) {
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wojtechnology/glacier/common"
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/logging"
+)
+
+// Loop of a node. Errors that the loop recovers from are sent to errChannel. Loops return once ctx
+// is done, returning before that means that the loop failed.
+type LoopFunc func(ctx context.Context, bc *core.Blockchain, errChannel chan<- error)
+
+type namedLoop struct {
+	name string
+	run  LoopFunc
+}
+
+// Owns the loops of a node. Loops that fail are restarted, and the wait before the restart doubles
+// every time the same loop fails again soon after.
+type Server struct {
+	bc      *core.Blockchain
+	loops   []*namedLoop
+	onError func(error)
+	now     func() int64                             // Current time in ms
+	sleep   func(ctx context.Context, ms int64) bool // Waits before restarts, see sleep
+}
+
+// Creates a server without any loops. Errors of the loops are logged.
+func NewServer(bc *core.Blockchain) *Server {
+	return &Server{
+		bc:    bc,
+		loops: make([]*namedLoop, 0),
+		onError: func(err error) {
+			logging.Error(err.Error())
+		},
+		now:   common.Now,
+		sleep: sleep,
+	}
+}
+
+// Creates a server with all loops of a node that serves the HTTP API.
+func NewNodeServer(bc *core.Blockchain) *Server {
+	s := NewServer(bc)
+	s.AddLoop("io", IOLoop)
+	s.AddLoop("heartbeat", HeartbeatLoop)
+	s.AddLoop("reassign", ReassignTransactionsLoop)
+	s.AddLoop("block", AddBlockLoop)
+	s.AddLoop("vote", VoteOnBlocksLoop)
+	s.AddLoop("tally", TallyVotesLoop)
+	s.AddLoop("apply", ApplyBlocksLoop)
+	s.AddLoop("prune_rejections", PruneRejectionsLoop)
+	s.AddLoop("fork_detection", ForkDetectionLoop)
+	return s
+}
+
+// Adds a loop to the server. Must be called before Run.
+func (s *Server) AddLoop(name string, run LoopFunc) {
+	s.loops = append(s.loops, &namedLoop{name: name, run: run})
+}
+
+// Sets the func that is called with every error of the loops. Must be called before Run.
+func (s *Server) SetErrorHandler(onError func(error)) {
+	s.onError = onError
+}
+
+// Runs all loops until ctx is done, then waits for them to finish what they are doing. Returns an
+// error if they do not finish within the shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
+	errChannel := make(chan error)
+	handledChannel := make(chan bool)
+	go func() {
+		for err := range errChannel {
+			s.onError(err)
+		}
+		close(handledChannel)
+	}()
+
+	var wg sync.WaitGroup
+	for _, l := range s.loops {
+		wg.Add(1)
+		go func(l *namedLoop) {
+			defer wg.Done()
+			s.supervise(ctx, l, errChannel)
+		}(l)
+	}
+
+	<-ctx.Done()
+	logging.Info("Shutting down, waiting for loops to finish")
+
+	stoppedChannel := make(chan bool)
+	go func() {
+		wg.Wait()
+		// Loops that are still running keep sending errors, so only close once all are done
+		close(errChannel)
+		<-handledChannel
+		close(stoppedChannel)
+	}()
+
+	select {
+	case <-stoppedChannel:
+		return nil
+	case <-time.After(time.Millisecond * time.Duration(config.ShutdownTimeoutMS)):
+		return errors.New(fmt.Sprintf("Loops did not finish within %d ms\n",
+			config.ShutdownTimeoutMS))
+	}
+}
+
+// -------
+// Helpers
+// -------
+
+// Runs the loop until ctx is done, restarting it whenever it returns before that.
+func (s *Server) supervise(ctx context.Context, l *namedLoop, errChannel chan<- error) {
+	backoffMS := config.RestartMinBackoffMS
+	for {
+		startedAt := s.now()
+		l.run(ctx, s.bc, errChannel)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Loop that ran for longer than the longest wait did not fail soon after its last restart
+		if s.now()-startedAt > config.RestartMaxBackoffMS {
+			backoffMS = config.RestartMinBackoffMS
+		}
+		logging.Error("Loop %s stopped, restarting in %d ms", l.name, backoffMS)
+		if !s.sleep(ctx, backoffMS) {
+			return
+		}
+
+		backoffMS *= 2
+		if backoffMS > config.RestartMaxBackoffMS {
+			backoffMS = config.RestartMaxBackoffMS
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/logging"
+)
+
+func init() {
+	logging.InitLoggers(ioutil.Discard, ioutil.Discard)
+}
+
+// Server whose clock only moves when the test moves it and which records the waits before
+// restarts instead of waiting.
+type testServer struct {
+	*Server
+	lock     sync.Mutex
+	nowMS    int64
+	backoffs []int64
+}
+
+func newTestServer(t *testing.T) *testServer {
+	c := DefaultConfig()
+	c.RestartMinBackoffMS = 100
+	c.RestartMaxBackoffMS = 1000
+	c.ShutdownTimeoutMS = 50
+	setTestConfig(t, c)
+
+	s := &testServer{Server: NewServer(nil), backoffs: make([]int64, 0)}
+	s.now = func() int64 {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return s.nowMS
+	}
+	s.sleep = func(ctx context.Context, ms int64) bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.backoffs = append(s.backoffs, ms)
+		return ctx.Err() == nil
+	}
+	return s
+}
+
+func (s *testServer) advance(ms int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nowMS += ms
+}
+
+// Sets the config for the test and restores the default config after it.
+func setTestConfig(t *testing.T, c *Config) {
+	SetConfig(c)
+	t.Cleanup(func() { SetConfig(DefaultConfig()) })
+}
+
+// Builds a loop that runs for the given times in ms, one for every run, and fails after each of
+// them. Once all runs are done, cancels ctx and waits for it.
+func getFailingLoop(s *testServer, cancel context.CancelFunc, runMS ...int64) (LoopFunc, *int) {
+	runs := 0
+	return func(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
+		if runs == len(runMS) {
+			cancel()
+			<-ctx.Done()
+			return
+		}
+		s.advance(runMS[runs])
+		runs++
+		errChannel <- errors.New("loop failed")
+	}, &runs
+}
+
+// -----
+// Tests
+// -----
+
+func TestServerRestartsLoop(t *testing.T) {
+	s := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	run, runs := getFailingLoop(s, cancel, 0, 0, 0)
+	s.AddLoop("failing", run)
+
+	errs := make([]error, 0)
+	s.SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+	assert.Nil(t, s.Run(ctx))
+	assert.Equal(t, 3, *runs)
+	assert.Equal(t, 3, len(errs))
+}
+
+func TestServerBackoff(t *testing.T) {
+	s := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	// Fails soon after every restart, except for one run that is longer than the longest wait
+	run, _ := getFailingLoop(s, cancel, 0, 0, 0, 0, 0, 0, 2000, 0)
+	s.AddLoop("failing", run)
+	s.SetErrorHandler(func(err error) {})
+
+	assert.Nil(t, s.Run(ctx))
+	// Doubles up to the longest wait and starts over after the long run
+	assert.Equal(t, []int64{100, 200, 400, 800, 1000, 1000, 100, 200}, s.backoffs)
+}
+
+func TestServerStopsLoops(t *testing.T) {
+	s := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := false
+	s.AddLoop("waiting", func(ctx context.Context, bc *core.Blockchain,
+		errChannel chan<- error) {
+
+		<-ctx.Done()
+		stopped = true
+	})
+
+	cancel()
+	assert.Nil(t, s.Run(ctx))
+	assert.True(t, stopped)
+	assert.Equal(t, []int64{}, s.backoffs)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	s := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	defer close(done)
+	s.AddLoop("stuck", func(ctx context.Context, bc *core.Blockchain,
+		errChannel chan<- error) {
+
+		// Does not stop when ctx is done
+		<-done
+	})
+
+	cancel()
+	assert.NotNil(t, s.Run(ctx))
+}
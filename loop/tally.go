@@ -0,0 +1,89 @@
+package loop
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/logging"
+)
+
+// Counts the votes on a block every time a vote is cast on it and moves the block from UNDECIDED
+// to ACCEPTED or REJECTED once the quorum is reached.
+func TallyVotesLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
+	cursor, err := bc.GetVoteChangefeed()
+	if err != nil {
+		errChannel <- err
+		return
+	}
+	defer closeWhenDone(ctx, cursor)()
+
+	if err := catchUpTallies(bc); err != nil {
+		errChannel <- err
+		return
+	}
+
+	var res core.VoteChange
+	for cursor.Next(&res) {
+		if res.NewVote != nil {
+			if err := tallyBlock(bc, res.NewVote.NextBlock); err != nil {
+				// Restarting catches up on the votes that were not counted
+				errChannel <- err
+				return
+			}
+		}
+	}
+
+	if ctx.Err() == nil {
+		errChannel <- errors.New("For some reason the vote changefeed stopped...\n")
+	}
+}
+
+// Counts the votes on the UNDECIDED blocks after the state cursor, so that votes that were cast
+// while this node was down or that failed to be counted before a restart are not missed.
+func catchUpTallies(bc *core.Blockchain) error {
+	return forEachBlockAfterStateCursor(bc, func(b *core.Block) error {
+		if b.State != core.BLOCK_STATE_UNDECIDED {
+			return nil
+		}
+		return tallyBlock(bc, b.Hash())
+	})
+}
+
+func tallyBlock(bc *core.Blockchain, blockId core.Hash) error {
+	bs, err := bc.GetBlocks([]core.Hash{blockId})
+	if err != nil {
+		return err
+	}
+
+	b := bs[0]
+	if b.State != core.BLOCK_STATE_UNDECIDED {
+		// Decisions are final, no point in counting again
+		return nil
+	}
+
+	vs, err := bc.GetBlockVotes(blockId)
+	if err != nil {
+		return err
+	}
+
+	// Forged votes and votes from nodes that are not voters of the block do not count
+	vs, offenses := bc.FilterValidVotes(b, vs)
+	if len(offenses) > 0 {
+		for _, o := range offenses {
+			logging.Error("Ignoring invalid vote from %x on block %x: %s",
+				o.Voter, blockId.Bytes(), o.Message)
+		}
+		if err := bc.WriteVoteOffenses(offenses); err != nil {
+			return err
+		}
+	}
+
+	state := bc.TallyVotes(b, vs)
+	if state == core.BLOCK_STATE_UNDECIDED {
+		return nil
+	}
+
+	logging.Info("Block %x decided with state %d", blockId.Bytes(), state)
+	return bc.UpdateBlockState(blockId, state)
+}
@@ -1,54 +1,107 @@
 package loop
 
 import (
+	"context"
+	"errors"
+
 	"github.com/wojtechnology/glacier/core"
 	"github.com/wojtechnology/glacier/logging"
 )
 
-const voteBlockBatchSize = 10
-
 type voteLoopState struct {
 	prevBlockId core.Hash
+	caughtUp    map[string]bool // Blocks voted on during catch up, not voted on again
 }
 
 func newVoteLoopState(genesis *core.Block) *voteLoopState {
-	return &voteLoopState{prevBlockId: genesis.Hash()}
+	return &voteLoopState{prevBlockId: genesis.Hash(), caughtUp: make(map[string]bool)}
 }
 
 // TODO: Better map/reduce type abstraction for this.
-func VoteOnBlocksLoop(bc *core.Blockchain, errChannel chan<- error) {
+func VoteOnBlocksLoop(ctx context.Context, bc *core.Blockchain, errChannel chan<- error) {
 	genesis, err := bc.BuildGenesis()
 	if err != nil {
 		errChannel <- err
+		return
 	}
 
-	s := newVoteLoopState(genesis)
-	newestB, err := getMostRecentVotedOnBlock(bc)
+	// Changefeed is opened before catching up, so that blocks written in the meantime are queued
+	// instead of missed
+	cursor, err := bc.GetBlockChangefeed()
 	if err != nil {
 		errChannel <- err
+		return
 	}
+	defer closeWhenDone(ctx, cursor)()
 
-	if newestB != nil {
-		s.prevBlockId = newestB.Hash()
-	}
-
-	// TODO: Maybe somehow backfill blocks that were missed when the node was down. Look below.
-
-	cursor, err := bc.GetBlockChangefeed()
-	if err != nil {
+	s := newVoteLoopState(genesis)
+	if err := catchUpVotes(bc, s); err != nil {
 		errChannel <- err
+		return
 	}
 
 	var res core.BlockChange
 	for cursor.Next(&res) {
 		if res.NewBlock != nil && res.OldBlock == nil {
 			// Only vote on brand new blocks
-			err := voteOnBlock(bc, s, res.NewBlock)
-			if err != nil {
+			if s.caughtUp[string(res.NewBlock.Hash().Bytes())] {
+				continue
+			}
+			if err := voteOnBlock(bc, s, res.NewBlock); err != nil {
+				// Restarting catches up on the blocks that were not voted on
 				errChannel <- err
+				return
 			}
 		}
 	}
+
+	if ctx.Err() == nil {
+		errChannel <- errors.New("For some reason the block changefeed stopped...\n")
+	}
+}
+
+// Votes on the UNDECIDED blocks that were written while this node was down, in the order of the
+// blockchain starting after the state cursor. Blocks can be written late with a height below the
+// block this node voted on last, so starting there could miss them. Every block before the state
+// cursor is decided, except for late blocks that voters reject anyway. Blocks that this node has
+// already voted on are skipped.
+func catchUpVotes(bc *core.Blockchain, s *voteLoopState) error {
+	newestB, err := getMostRecentVotedOnBlock(bc)
+	if err != nil {
+		return err
+	}
+	if newestB != nil {
+		s.prevBlockId = newestB.Hash()
+	}
+
+	caughtUp := 0
+	err = forEachBlockAfterStateCursor(bc, func(b *core.Block) error {
+		if b.State != core.BLOCK_STATE_UNDECIDED {
+			return nil
+		}
+
+		blockId := b.Hash()
+		voted, err := bc.HasVoted(blockId)
+		if err != nil {
+			return err
+		}
+		if !voted {
+			if err := voteOnBlock(bc, s, b); err != nil {
+				return err
+			}
+			caughtUp++
+		}
+		s.caughtUp[string(blockId.Bytes())] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if caughtUp > 0 {
+		logging.Info("Caught up on votes for %d blocks", caughtUp)
+	}
+	return nil
 }
 
 func voteOnBlock(bc *core.Blockchain, s *voteLoopState, b *core.Block) error {
@@ -58,6 +111,10 @@ func voteOnBlock(bc *core.Blockchain, s *voteLoopState, b *core.Block) error {
 		logging.Error(err.Error())
 		if _, ok := err.(*core.BlockSignatureInvalidError); ok {
 			valid = false
+		} else if _, ok := err.(*core.BlockHeightInvalidError); ok {
+			valid = false
+		} else if _, ok := err.(*core.BlockTransactionsUnorderedError); ok {
+			valid = false
 		} else if _, ok := err.(*core.TransactionErrors); ok {
 			valid = false
 		} else {
@@ -99,7 +156,7 @@ func getMostRecentVotedOnBlock(bc *core.Blockchain) (*core.Block, error) {
 	if len(bs) > 0 {
 		newestB := bs[0]
 		for _, b := range bs[1:] {
-			if b.CreatedAt.Int64() > newestB.CreatedAt.Int64() {
+			if newestB.IsBefore(b) {
 				newestB = b
 			}
 		}
@@ -107,65 +164,3 @@ func getMostRecentVotedOnBlock(bc *core.Blockchain) (*core.Block, error) {
 	}
 	return nil, nil // No most recent block but also no error
 }
-
-// Old way of voting, still might be useful when we write logic to backfill votes in case the node
-// goes down for some time. Not worried about that for now.
-//
-// Comment from before:
-// This is a really shitty way to do this. It's possible that there is clock drift between
-// computers and there is a race condition:
-//     This node votes on block b1 with CreatedAt = 123 NS. Very soon after, another node
-//     creates a block b2 with CreatedAt = 122 NS. That block can no longer be voted on by
-//     this node ever again.
-// Two solutions:
-// 1) Use changefeed (best solution, one used by bigchaindb).
-// 2) Decrease granularity of timestamps to seconds (doesn't really remove the issue but makes
-//    it smaller).
-// We will still need something like this logic in case a node goes down and wants to "catch up" on
-// blocks that it missed while it was down.
-// Alas, this is the way it works for now, but as soon as changefeeds are implemented, we are using
-// those.
-func voteOnBlocksBatched(bc *core.Blockchain) error {
-	var (
-		newestTS    int64
-		prevBlockId core.Hash
-	)
-	newestB, err := getMostRecentVotedOnBlock(bc)
-	if err != nil {
-		return err
-	}
-
-	// Find newest block from those voted on at the same time.
-	if newestB != nil {
-		newestTS = newestB.CreatedAt.Int64()
-		prevBlockId = newestB.Hash()
-	} else {
-		// This is the first time voting.
-		newestTS = 0
-		// TODO: This should actually be the genesis block.
-		prevBlockId = core.BytesToHash([]byte("I AM NOT GENESIS"))
-	}
-
-	// Note that these are sorted by increasing CreatedAt.
-	// TODO: This call should exclude the above blockIds instead of adding +1. Requires change on
-	// DB layer.
-	bs, err := bc.GetOldestBlocks(newestTS+1, voteBlockBatchSize)
-	if err != nil {
-		return err
-	}
-	for _, b := range bs {
-		valid := true
-		// TODO: Validate Transactions
-		// TODO: Validate Block
-		v, err := bc.BuildVote(b.Hash(), prevBlockId, valid)
-		if err != nil {
-			return err
-		}
-		if err := bc.WriteVote(v); err != nil {
-			return err
-		}
-		prevBlockId = b.Hash()
-	}
-
-	return nil
-}
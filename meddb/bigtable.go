@@ -3,10 +3,18 @@ package meddb
 import "time"
 
 type Bigtable interface {
+	// Writes cells to a row. Cells are deleted by putting tombstones, which keeps their history.
 	Put(tableName []byte, op *PutOp) error
+	// Reads cells of a row. Deleted cells are left out, unless the op asks for history.
 	Get(tableName []byte, op *GetOp) (map[string][]*Cell, error)
+	// Returns the ids of all columns that were ever written in a row, including deleted ones.
+	GetColIds(tableName []byte, rowId []byte) ([][]byte, error)
+	// Reads a page of the rows in a range of row ids, sorted by row id. Rows without any cells left
+	// to read are skipped, so a page can have fewer rows than the page size even if more follow.
+	Scan(tableName []byte, op *ScanOp) (*ScanRes, error)
 	CreateTable(tableName []byte) error
-	// TODO(wojtek): Delete
+	// Deletes the table with all of its cells, including their history.
+	DropTable(tableName []byte) error
 }
 
 func curTimeMillis() int64 {
@@ -153,6 +153,216 @@ func testGetRange(t *testing.T, bt Bigtable, tableName []byte) {
 	assertCellsEqual(t, NewCellVer(1, data), res[string(colId)][3])
 }
 
+func testDeleteTombstone(t *testing.T, bt Bigtable, tableName []byte) {
+	rowId := []byte("AYY LMAO")
+	colId := []byte("YO FAM")
+	otherColId := []byte("SUP")
+	data := []byte("OH SHIT WADDUP")
+
+	putVerCells(t, bt, tableName, rowId, colId, []int64{1, 3, 7}, data)
+	putVerCells(t, bt, tableName, rowId, otherColId, []int64{2}, data)
+	putTombstoneVer(t, bt, tableName, rowId, colId, 5)
+
+	// Only versions newer than the tombstone are left
+	res, err := bt.Get(tableName, NewGetOp(rowId, [][]byte{colId, otherColId}))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, 1, len(res[string(colId)]))
+	assertCellsEqual(t, NewCellVer(7, data), res[string(colId)][0])
+	assert.Equal(t, 1, len(res[string(otherColId)]))
+
+	res, err = bt.Get(tableName, NewGetOpLimit(rowId, [][]byte{colId}, 2))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res[string(colId)]))
+	assertCellsEqual(t, NewCellVer(7, data), res[string(colId)][0])
+
+	res, err = bt.Get(tableName, NewGetOpRange(rowId, [][]byte{colId}, 0, 4))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+
+	res, err = bt.Get(tableName, NewGetOpVer(rowId, [][]byte{colId}, 3))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+
+	// Deleting the newest version hides the whole column
+	putTombstoneVer(t, bt, tableName, rowId, colId, 8)
+	res, err = bt.Get(tableName, NewGetOp(rowId, [][]byte{colId}))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
+func testGetHistory(t *testing.T, bt Bigtable, tableName []byte) {
+	rowId := []byte("AYY LMAO")
+	colId := []byte("YO FAM")
+	data := []byte("OH SHIT WADDUP")
+
+	putVerCells(t, bt, tableName, rowId, colId, []int64{1, 3}, data)
+	putTombstoneVer(t, bt, tableName, rowId, colId, 5)
+
+	res, err := bt.Get(tableName, NewGetOp(rowId, [][]byte{colId}).WithHistory())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(res[string(colId)]))
+	assert.Equal(t, NewTombstoneVer(5), res[string(colId)][0])
+	assertCellsEqual(t, NewCellVer(3, data), res[string(colId)][1])
+	assert.False(t, res[string(colId)][1].Tombstone)
+	assertCellsEqual(t, NewCellVer(1, data), res[string(colId)][2])
+
+	res, err = bt.Get(tableName, NewGetOpLimit(rowId, [][]byte{colId}, 1).WithHistory())
+	assert.Nil(t, err)
+	assert.Equal(t, []*Cell{NewTombstoneVer(5)}, res[string(colId)])
+}
+
+func testGetColIds(t *testing.T, bt Bigtable, tableName []byte) {
+	rowId := []byte("AYY LMAO")
+	data := []byte("OH SHIT WADDUP")
+
+	colIds, err := bt.GetColIds(tableName, rowId)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(colIds))
+
+	putVerCells(t, bt, tableName, rowId, []byte("b"), []int64{1, 2}, data)
+	putVerCells(t, bt, tableName, rowId, []byte("a"), []int64{1}, data)
+	putTombstoneVer(t, bt, tableName, rowId, []byte("a"), 2)
+	putVerCells(t, bt, tableName, []byte("other row"), []byte("c"), []int64{1}, data)
+
+	colIds, err = bt.GetColIds(tableName, rowId)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(colIds))
+	assert.Subset(t, colIds, [][]byte{[]byte("a"), []byte("b")})
+
+	_, err = bt.GetColIds([]byte("IAMNOTINTHEDB"), rowId)
+	assert.IsType(t, &TableNotFoundError{}, err)
+}
+
+// Rows of a scan as a map from row id to the data of its cells, newest first.
+func scanData(res *ScanRes) map[string]map[string][]string {
+	data := make(map[string]map[string][]string)
+	for _, row := range res.Rows {
+		data[string(row.RowId)] = make(map[string][]string)
+		for colId, col := range row.Cols {
+			for _, cell := range col {
+				data[string(row.RowId)][colId] = append(data[string(row.RowId)][colId],
+					string(cell.Data))
+			}
+		}
+	}
+	return data
+}
+
+func scanRowIds(res *ScanRes) []string {
+	rowIds := make([]string, len(res.Rows))
+	for i, row := range res.Rows {
+		rowIds[i] = string(row.RowId)
+	}
+	return rowIds
+}
+
+func testScan(t *testing.T, bt Bigtable, tableName []byte) {
+	colId := []byte("wheels")
+	otherColId := []byte("doors")
+	for _, rowId := range []string{"b", "ab", "a", "abc", "c"} {
+		putAndCheckVer(t, bt, tableName, []byte(rowId), colId, 1, []byte(rowId+"1"))
+		putAndCheckVer(t, bt, tableName, []byte(rowId), colId, 2, []byte(rowId+"2"))
+	}
+	putAndCheckVer(t, bt, tableName, []byte("b"), otherColId, 1, []byte("b"))
+
+	res, err := bt.Scan(tableName, NewScanOp(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "ab", "abc", "b", "c"}, scanRowIds(res))
+	assert.Nil(t, res.NextPageToken)
+	assert.Equal(t, map[string][]string{"wheels": {"b2", "b1"}, "doors": {"b"}},
+		scanData(res)["b"])
+
+	res, err = bt.Scan(tableName, NewScanOp([][]byte{otherColId}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]map[string][]string{"b": {"doors": {"b"}}}, scanData(res))
+
+	res, err = bt.Scan(tableName, NewScanOp([][]byte{colId}).WithLimit(1))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"wheels": {"a2"}}, scanData(res)["a"])
+
+	res, err = bt.Scan(tableName, NewScanOp(nil).WithPrefix([]byte("ab")))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ab", "abc"}, scanRowIds(res))
+
+	res, err = bt.Scan(tableName, NewScanOp(nil).WithRange([]byte("ab"), []byte("c")))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ab", "abc", "b"}, scanRowIds(res))
+
+	res, err = bt.Scan(tableName, NewScanOp(nil).WithRange([]byte("c"), []byte("a")))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res.Rows))
+}
+
+func testScanPages(t *testing.T, bt Bigtable, tableName []byte) {
+	colId := []byte("wheels")
+	for _, rowId := range []string{"a", "b", "c", "d", "e"} {
+		putAndCheckVer(t, bt, tableName, []byte(rowId), colId, 1, []byte(rowId))
+	}
+
+	op := NewScanOp(nil).WithRange(nil, []byte("e")).WithPageSize(2)
+	res, err := bt.Scan(tableName, op)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, scanRowIds(res))
+	assert.Equal(t, []byte("c"), res.NextPageToken)
+
+	res, err = bt.Scan(tableName, op.WithPageToken(res.NextPageToken))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"c", "d"}, scanRowIds(res))
+	assert.Nil(t, res.NextPageToken)
+}
+
+func testScanDeleted(t *testing.T, bt Bigtable, tableName []byte) {
+	colId := []byte("wheels")
+	for _, rowId := range []string{"a", "b", "c"} {
+		putAndCheckVer(t, bt, tableName, []byte(rowId), colId, 1, []byte(rowId))
+	}
+	putTombstoneVer(t, bt, tableName, []byte("b"), colId, 2)
+
+	res, err := bt.Scan(tableName, NewScanOp(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "c"}, scanRowIds(res))
+
+	res, err = bt.Scan(tableName, NewScanOp(nil).WithHistory())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, scanRowIds(res))
+	assert.Equal(t, []*Cell{NewTombstoneVer(2), NewCellVer(1, []byte("b"))},
+		res.Rows[1].Cols[string(colId)])
+}
+
+func testScanPagesDeleted(t *testing.T, bt Bigtable, tableName []byte) {
+	colId := []byte("wheels")
+	for _, rowId := range []string{"a", "b", "c", "d", "e", "f"} {
+		putAndCheckVer(t, bt, tableName, []byte(rowId), colId, 1, []byte(rowId))
+	}
+	for _, rowId := range []string{"a", "b", "c", "e"} {
+		putTombstoneVer(t, bt, tableName, []byte(rowId), colId, 2)
+	}
+
+	// Pages are filled past the deleted rows
+	op := NewScanOp(nil).WithPageSize(1)
+	res, err := bt.Scan(tableName, op)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"d"}, scanRowIds(res))
+	assert.Equal(t, []byte("f"), res.NextPageToken)
+
+	res, err = bt.Scan(tableName, op.WithPageToken(res.NextPageToken))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"f"}, scanRowIds(res))
+	assert.Nil(t, res.NextPageToken)
+
+	// Same with selected columns
+	res, err = bt.Scan(tableName, NewScanOp([][]byte{colId}).WithPageSize(2))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"d", "f"}, scanRowIds(res))
+	assert.Nil(t, res.NextPageToken)
+}
+
+func testScanTableNotFound(t *testing.T, bt Bigtable) {
+	_, err := bt.Scan([]byte("IAMNOTINTHEDB"), NewScanOp(nil))
+	assert.IsType(t, &TableNotFoundError{}, err)
+}
+
 func testPutTableNotFound(t *testing.T, bt Bigtable) {
 	err := bt.Put([]byte("IAMNOTINTHEDB"), new(PutOp))
 	assert.IsType(t, &TableNotFoundError{}, err)
@@ -172,3 +382,29 @@ func testCreateTableAlreadyExists(t *testing.T, bt Bigtable) {
 	err = bt.CreateTable(tableName)
 	assert.IsType(t, &TableAlreadyExists{}, err)
 }
+
+func testDropTable(t *testing.T, bt Bigtable) {
+	tableName := []byte("DROPME")
+	rowId := []byte{1}
+	colId := []byte{2}
+
+	assert.Nil(t, bt.CreateTable(tableName))
+	op := NewPutOp(rowId)
+	op.AddColVer(colId, 5, []byte{3})
+	assert.Nil(t, bt.Put(tableName, op))
+
+	assert.Nil(t, bt.DropTable(tableName))
+	_, err := bt.Get(tableName, NewGetOp(rowId, [][]byte{colId}))
+	assert.IsType(t, &TableNotFoundError{}, err)
+
+	// Cells of the dropped table are gone once it is created again
+	assert.Nil(t, bt.CreateTable(tableName))
+	res, err := bt.Get(tableName, NewGetOp(rowId, [][]byte{colId}))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
+func testDropTableNotFound(t *testing.T, bt Bigtable) {
+	err := bt.DropTable([]byte("IAMNOTINTHEDB"))
+	assert.IsType(t, &TableNotFoundError{}, err)
+}
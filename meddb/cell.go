@@ -3,8 +3,9 @@ package meddb
 import "math/big"
 
 type Cell struct {
-	VerId *big.Int
-	Data  []byte
+	VerId     *big.Int
+	Data      []byte
+	Tombstone bool // Deletes this and all older versions of the column, has no data
 }
 
 func NewCell(data []byte) *Cell {
@@ -15,10 +16,19 @@ func NewCellVer(verId int64, data []byte) *Cell {
 	return &Cell{VerId: big.NewInt(verId), Data: data}
 }
 
+func NewTombstone() *Cell {
+	return &Cell{Tombstone: true}
+}
+
+func NewTombstoneVer(verId int64) *Cell {
+	return &Cell{VerId: big.NewInt(verId), Tombstone: true}
+}
+
 func (c *Cell) Clone() *Cell {
 	// TODO(wojtek): check if need to copy all of the []byte
+	var verId *big.Int = nil
 	if c.VerId != nil {
-		return NewCellVer(c.VerId.Int64(), c.Data)
+		verId = big.NewInt(c.VerId.Int64())
 	}
-	return NewCell(c.Data)
+	return &Cell{VerId: verId, Data: c.Data, Tombstone: c.Tombstone}
 }
@@ -8,20 +8,40 @@ type BlockchainDB interface {
 
 	// Writes transaction to backlog table
 	WriteTransaction(*Transaction) error
+	// Writes transactions to backlog table in one batch. Returns BatchWriteError if only some of
+	// them were written
+	WriteTransactions([]*Transaction) error
 	// Returns transactions currently assigned to given node from backlog table
 	GetAssignedTransactions([]byte) ([]*Transaction, error)
+	// Returns the number of transactions in backlog table by the public key they are assigned to
+	CountAssignedTransactions() (map[string]int, error)
 	// Returns transactions older than given time (no order) from backlog table
 	GetStaleTransactions(int64) ([]*Transaction, error)
 	// Deletes given transactions from backlog table
 	DeleteTransactions([]*Transaction) error
+	// Returns transaction with given hash from backlog table, NotFoundError if it is not there
+	GetBacklogTransaction([]byte) (*Transaction, error)
 
 	// Writes block to block table
 	WriteBlock(*Block) error
 	// Returns blocks from block table by block ids
 	GetBlocks([][]byte) ([]*Block, error)
+	// Updates the state of the block with the given block id in block table
+	UpdateBlockState([]byte, int) error
 	// Returns k oldest blocks from block table starting at given timestamp sorted by increasing
 	// CreatedAt timestamp.
 	GetOldestBlocks(int64, int) ([]*Block, error)
+	// Returns k blocks from block table that come after the block with the given height and block
+	// id, sorted by increasing height and then block id. A nil block id starts at the first block
+	// with the given height.
+	GetBlocksAfter(int64, []byte, int) ([]*Block, error)
+	// Returns the greatest height of the blocks in block table, -1 if there are no blocks
+	GetMaxBlockHeight() (int64, error)
+	// Returns the greatest height of the blocks in block table that are not in the given state, -1
+	// if there are no such blocks
+	GetMaxBlockHeightNotInState(int) (int64, error)
+	// Returns all blocks from block table that contain the transaction with given hash
+	GetTransactionBlocks([]byte) ([]*Block, error)
 	// Returns outputs for given output ids
 	GetOutputs([][]byte) ([]*OutputRes, error)
 	// Returns inputs for given output ids
@@ -34,6 +54,25 @@ type BlockchainDB interface {
 	// Returns k most recent votes for given public key from votes table sorted by decreasing
 	// VotedAt timestamp.
 	GetRecentVotes([]byte, int) ([]*Vote, error)
+	// Returns all votes for the given block id (the block that was voted on) from votes table
+	GetBlockVotes([]byte) ([]*Vote, error)
+
+	// Writes rejections to rejection table, replacing older rejections of the same transactions
+	WriteRejections([]*Rejection) error
+	// Returns rejection of the transaction with given hash, NotFoundError if there is none
+	GetRejection([]byte) (*Rejection, error)
+	// Deletes rejections older than given time from rejection table
+	DeleteRejections(int64) error
+
+	// Writes offenses to offense table, replacing older offenses of the same votes
+	WriteVoteOffenses([]*VoteOffense) error
+	// Returns all offenses of the voter with given public key from offense table
+	GetVoteOffenses([]byte) ([]*VoteOffense, error)
+
+	// Writes heartbeat to heartbeat table, replacing the older heartbeat of the same node
+	WriteHeartbeat(*Heartbeat) error
+	// Returns the latest heartbeat of every node from heartbeat table
+	GetHeartbeats() ([]*Heartbeat, error)
 
 	// Returns changefeed for all transactions assigned to the given public key
 	GetAssignedTransactionChangefeed([]byte) (TransactionChangefeed, error)
@@ -70,6 +109,7 @@ type Input struct {
 type Block struct {
 	Hash         []byte
 	Transactions []*Transaction
+	Height       *big.Int
 	CreatedAt    *big.Int
 	Creator      []byte
 	Sig          []byte
@@ -87,6 +127,33 @@ type Vote struct {
 	Value     bool
 }
 
+// Record of a transaction that was dropped from the backlog because it was invalid.
+type Rejection struct {
+	TxHash     []byte
+	ErrorType  string
+	Message    string
+	Node       []byte // Public key of node that rejected the transaction
+	RejectedAt *big.Int
+}
+
+// Record of an invalid vote, i.e. a vote with a forged signature or from a node that is not a
+// voter of the block.
+type VoteOffense struct {
+	VoteHash   []byte
+	Voter      []byte // Public key claimed by the vote
+	BlockId    []byte // Block that was voted on
+	ErrorType  string
+	Message    string
+	Node       []byte // Public key of node that found the offense
+	RecordedAt *big.Int
+}
+
+// Latest sign of life of a node.
+type Heartbeat struct {
+	Node   []byte // Public key of node that is alive
+	BeatAt *big.Int
+}
+
 // Structure used to return the result of the GetOutputs endpoint.
 type OutputRes struct {
 	Block       *Block
@@ -96,8 +163,9 @@ type OutputRes struct {
 
 // Structure used to return the result of GetInputsByOutput endpoint.
 type InputRes struct {
-	Block *Block
-	Input *Input
+	Block  *Block
+	TxHash []byte // Hash of the transaction that has the input
+	Input  *Input
 }
 
 // ----------------
@@ -121,14 +189,20 @@ type VoteChangefeedRes struct {
 
 type BlockChangefeed interface {
 	Next(*BlockChangefeedRes) bool
+	// Stops the changefeed, a blocked Next returns false
+	Close() error
 }
 
 type TransactionChangefeed interface {
 	Next(*TransactionChangefeedRes) bool
+	// Stops the changefeed, a blocked Next returns false
+	Close() error
 }
 
 type VoteChangefeed interface {
 	Next(*VoteChangefeedRes) bool
+	// Stops the changefeed, a blocked Next returns false
+	Close() error
 }
 
 // -------
@@ -198,7 +272,13 @@ func (in *Input) Clone() *Input {
 }
 
 func (b *Block) Clone() *Block {
-	var createdAt *big.Int = nil
+	var (
+		height    *big.Int = nil
+		createdAt *big.Int = nil
+	)
+	if b.Height != nil {
+		height = big.NewInt(b.Height.Int64())
+	}
 	if b.CreatedAt != nil {
 		createdAt = big.NewInt(b.CreatedAt.Int64())
 	}
@@ -206,6 +286,7 @@ func (b *Block) Clone() *Block {
 	return &Block{
 		Hash:         b.Hash,
 		Transactions: b.Transactions,
+		Height:       height,
 		CreatedAt:    createdAt,
 		Creator:      b.Creator,
 		Sig:          b.Sig,
@@ -230,3 +311,47 @@ func (v *Vote) Clone() *Vote {
 		Value:     v.Value,
 	}
 }
+
+func (rej *Rejection) Clone() *Rejection {
+	var rejectedAt *big.Int = nil
+	if rej.RejectedAt != nil {
+		rejectedAt = big.NewInt(rej.RejectedAt.Int64())
+	}
+
+	return &Rejection{
+		TxHash:     rej.TxHash,
+		ErrorType:  rej.ErrorType,
+		Message:    rej.Message,
+		Node:       rej.Node,
+		RejectedAt: rejectedAt,
+	}
+}
+
+func (o *VoteOffense) Clone() *VoteOffense {
+	var recordedAt *big.Int = nil
+	if o.RecordedAt != nil {
+		recordedAt = big.NewInt(o.RecordedAt.Int64())
+	}
+
+	return &VoteOffense{
+		VoteHash:   o.VoteHash,
+		Voter:      o.Voter,
+		BlockId:    o.BlockId,
+		ErrorType:  o.ErrorType,
+		Message:    o.Message,
+		Node:       o.Node,
+		RecordedAt: recordedAt,
+	}
+}
+
+func (hb *Heartbeat) Clone() *Heartbeat {
+	var beatAt *big.Int = nil
+	if hb.BeatAt != nil {
+		beatAt = big.NewInt(hb.BeatAt.Int64())
+	}
+
+	return &Heartbeat{
+		Node:   hb.Node,
+		BeatAt: beatAt,
+	}
+}
@@ -52,3 +52,19 @@ type TableAlreadyExists struct {
 func (e *TableAlreadyExists) Error() string {
 	return fmt.Sprintf("Table \"%v\" already exists", e.TableName)
 }
+
+// Returned by batch writes that failed for some items of the batch only. Has an error for every
+// item of the batch, nil for the items that were written.
+type BatchWriteError struct {
+	Errors []error
+}
+
+func (e *BatchWriteError) Error() string {
+	failed := make([]error, 0)
+	for _, err := range e.Errors {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return fmt.Sprintf("Failed to write %d of %d items: %v", len(failed), len(e.Errors), failed)
+}
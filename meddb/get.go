@@ -8,6 +8,7 @@ type GetOp struct {
 	limit          uint32
 	minVer, maxVer *big.Int
 	verId          *big.Int
+	history        bool // Also return tombstones and the cells they delete
 }
 
 func NewGetOp(rowId []byte, colIds [][]byte) *GetOp {
@@ -32,3 +33,36 @@ func NewGetOpVer(rowId []byte, colIds [][]byte, verId int64) *GetOp {
 	op.verId = big.NewInt(verId)
 	return op
 }
+
+// Makes the get return deleted cells and the tombstones that deleted them as well.
+func (op *GetOp) WithHistory() *GetOp {
+	op.history = true
+	return op
+}
+
+// -------
+// Helpers
+// -------
+
+// Removes tombstones and the cells they delete from the result of a get. deletedAt has the version
+// of the newest tombstone of every column that has one. Columns without any cells left are
+// removed as well.
+func hideDeleted(res map[string][]*Cell, deletedAt map[string]*big.Int) map[string][]*Cell {
+	visible := make(map[string][]*Cell)
+	for colId, col := range res {
+		visibleCol := make([]*Cell, 0, len(col))
+		for _, cell := range col {
+			if cell.Tombstone {
+				continue
+			}
+			if verId, ok := deletedAt[colId]; ok && cell.VerId.Cmp(verId) <= 0 {
+				continue
+			}
+			visibleCol = append(visibleCol, cell)
+		}
+		if len(visibleCol) > 0 {
+			visible[colId] = visibleCol
+		}
+	}
+	return visible
+}
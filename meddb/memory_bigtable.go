@@ -1,7 +1,9 @@
 package meddb
 
 import (
+	"bytes"
 	"math/big"
+	"sort"
 	"sync"
 )
 
@@ -127,6 +129,64 @@ func (bt *MemoryBigtable) Get(tableName []byte, op *GetOp) (map[string][]*Cell,
 		}
 	}
 
+	if !op.history {
+		res = hideDeleted(res, row.deletedAt(op.colIds))
+	}
+	return res, nil
+}
+
+func (bt *MemoryBigtable) GetColIds(tableName []byte, rowId []byte) ([][]byte, error) {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+
+	table, err := bt.getTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	colIds := make([][]byte, 0)
+	row, err := table.getRow(rowId)
+	if err != nil {
+		// Row doesn't exist, so it has no columns
+		return colIds, nil
+	}
+
+	for colId, _ := range row.cols {
+		colIds = append(colIds, []byte(colId))
+	}
+	sort.Slice(colIds, func(i, j int) bool { return bytes.Compare(colIds[i], colIds[j]) < 0 })
+	return colIds, nil
+}
+
+// Iterates over the row ids of the table in sorted order.
+func (bt *MemoryBigtable) Scan(tableName []byte, op *ScanOp) (*ScanRes, error) {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+
+	table, err := bt.getTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := op.rowRange()
+	res := &ScanRes{Rows: make([]*ScanRow, 0)}
+	if isEmptyRange(start, end) {
+		return res, nil
+	}
+
+	rowIds := make([]string, 0, len(table.rows))
+	for rowId, _ := range table.rows {
+		if rowId >= string(start) && (end == nil || rowId < string(end)) {
+			rowIds = append(rowIds, rowId)
+		}
+	}
+	sort.Strings(rowIds)
+
+	for _, rowId := range rowIds {
+		if !res.addRow(op, []byte(rowId), table.rows[rowId].cols) {
+			break
+		}
+	}
 	return res, nil
 }
 
@@ -144,6 +204,18 @@ func (bt *MemoryBigtable) CreateTable(tableName []byte) error {
 	return nil
 }
 
+func (bt *MemoryBigtable) DropTable(tableName []byte) error {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+
+	if _, err := bt.getTable(tableName); err != nil {
+		return err
+	}
+	delete(bt.tables, string(tableName))
+
+	return nil
+}
+
 // -------
 // Helpers
 // -------
@@ -164,6 +236,21 @@ func (t *memoryTable) getRow(rowId []byte) (*memoryRow, error) {
 	return row, nil
 }
 
+// Returns the version of the newest tombstone of every given column that has one.
+func (row *memoryRow) deletedAt(colIds [][]byte) map[string]*big.Int {
+	deletedAt := make(map[string]*big.Int)
+	for _, colId := range colIds {
+		// Cells are sorted by decreasing verId, so the first tombstone is the newest
+		for _, cell := range row.cols[string(colId)] {
+			if cell.Tombstone {
+				deletedAt[string(colId)] = cell.VerId
+				break
+			}
+		}
+	}
+	return deletedAt
+}
+
 // Does binary search on cells (assuming they are sorted by decreasing verId).
 // Returns the index of the cell if it exists, otherwise, returns the index of the cell with
 // the largest verId that is smaller than the target.
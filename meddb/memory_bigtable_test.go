@@ -63,6 +63,54 @@ func TestMemoryGetRange(t *testing.T) {
 	testGetRange(t, bt, memoryCreateTable(t, bt))
 }
 
+func TestMemoryDeleteTombstone(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testDeleteTombstone(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryGetHistory(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testGetHistory(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryGetColIds(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testGetColIds(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryScan(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testScan(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryScanPages(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testScanPages(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryScanDeleted(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testScanDeleted(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryScanPagesDeleted(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testScanPagesDeleted(t, bt, memoryCreateTable(t, bt))
+}
+
+func TestMemoryScanTableNotFound(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testScanTableNotFound(t, bt)
+}
+
 func TestMemoryPutTableNotFound(t *testing.T) {
 	bt, err := NewMemoryBigtable()
 	assert.Nil(t, err)
@@ -81,6 +129,18 @@ func TestMemoryCreateTableAlreadyExists(t *testing.T) {
 	testCreateTableAlreadyExists(t, bt)
 }
 
+func TestMemoryDropTable(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testDropTable(t, bt)
+}
+
+func TestMemoryDropTableNotFound(t *testing.T) {
+	bt, err := NewMemoryBigtable()
+	assert.Nil(t, err)
+	testDropTableNotFound(t, bt)
+}
+
 // ------------
 // Test Helpers
 // ------------
@@ -141,6 +201,14 @@ func putVerCells(t *testing.T, bt Bigtable, tableName, rowId, colId []byte, verI
 	}
 }
 
+func putTombstoneVer(t *testing.T, bt Bigtable, tableName, rowId, colId []byte, verId int64) {
+	putOp := NewPutOp(rowId)
+	putOp.AddTombstoneVer(colId, verId)
+
+	err := bt.Put(tableName, putOp)
+	assert.Nil(t, err)
+}
+
 func memoryCreateTable(t *testing.T, bt *MemoryBigtable) []byte {
 	tableName := []byte("HELLO")
 	err := bt.CreateTable(tableName)
@@ -16,6 +16,15 @@ type MemoryBlockchainDB struct {
 	blockLock    sync.RWMutex
 	voteTable    map[string]*Vote
 	voteLock     sync.RWMutex
+	rejectTable  map[string]*Rejection
+	rejectLock   sync.RWMutex
+	offenseTable map[string]*VoteOffense
+	offenseLock  sync.RWMutex
+	beatTable    map[string]*Heartbeat
+	beatLock     sync.RWMutex
+	backlogFeeds memoryChangefeeds
+	blockFeeds   memoryChangefeeds
+	voteFeeds    memoryChangefeeds
 }
 
 // ----------------------
@@ -27,6 +36,9 @@ func NewMemoryBlockchainDB() (*MemoryBlockchainDB, error) {
 		backlogTable: make(map[string]*Transaction),
 		blockTable:   make(map[string]*Block),
 		voteTable:    make(map[string]*Vote),
+		rejectTable:  make(map[string]*Rejection),
+		offenseTable: make(map[string]*VoteOffense),
+		beatTable:    make(map[string]*Heartbeat),
 	}, nil
 }
 
@@ -38,7 +50,23 @@ func (db *MemoryBlockchainDB) WriteTransaction(tx *Transaction) error {
 	db.backlogLock.Lock()
 	defer db.backlogLock.Unlock()
 
-	db.backlogTable[string(tx.Hash)] = tx.Clone()
+	old := db.backlogTable[string(tx.Hash)]
+	txCopy := tx.Clone()
+	db.backlogTable[string(tx.Hash)] = txCopy
+	db.backlogFeeds.publish(old, txCopy)
+	return nil
+}
+
+func (db *MemoryBlockchainDB) WriteTransactions(txs []*Transaction) error {
+	db.backlogLock.Lock()
+	defer db.backlogLock.Unlock()
+
+	for _, tx := range txs {
+		old := db.backlogTable[string(tx.Hash)]
+		txCopy := tx.Clone()
+		db.backlogTable[string(tx.Hash)] = txCopy
+		db.backlogFeeds.publish(old, txCopy)
+	}
 	return nil
 }
 
@@ -58,6 +86,17 @@ func (db *MemoryBlockchainDB) GetAssignedTransactions(pubKey []byte) ([]*Transac
 }
 
 // Note: This is not performant, do not use in prod
+func (db *MemoryBlockchainDB) CountAssignedTransactions() (map[string]int, error) {
+	db.backlogLock.Lock()
+	defer db.backlogLock.Unlock()
+
+	counts := make(map[string]int)
+	for _, tx := range db.backlogTable {
+		counts[string(tx.AssignedTo)]++
+	}
+	return counts, nil
+}
+
 func (db *MemoryBlockchainDB) GetStaleTransactions(before int64) ([]*Transaction, error) {
 
 	db.backlogLock.Lock()
@@ -78,17 +117,34 @@ func (db *MemoryBlockchainDB) DeleteTransactions(txs []*Transaction) error {
 	defer db.backlogLock.Unlock()
 
 	for _, tx := range txs {
-		delete(db.backlogTable, string(tx.Hash))
+		if old, ok := db.backlogTable[string(tx.Hash)]; ok {
+			delete(db.backlogTable, string(tx.Hash))
+			db.backlogFeeds.publish(old, nil)
+		}
 	}
 
 	return nil
 }
 
+func (db *MemoryBlockchainDB) GetBacklogTransaction(txHash []byte) (*Transaction, error) {
+	db.backlogLock.Lock()
+	defer db.backlogLock.Unlock()
+
+	tx, ok := db.backlogTable[string(txHash)]
+	if !ok {
+		return nil, &NotFoundError{Key: txHash}
+	}
+	return tx.Clone(), nil
+}
+
 func (db *MemoryBlockchainDB) WriteBlock(b *Block) error {
 	db.blockLock.Lock()
 	defer db.blockLock.Unlock()
 
-	db.blockTable[string(b.Hash)] = b.Clone()
+	old := db.blockTable[string(b.Hash)]
+	bCopy := b.Clone()
+	db.blockTable[string(b.Hash)] = bCopy
+	db.blockFeeds.publish(old, bCopy)
 	return nil
 }
 
@@ -106,6 +162,23 @@ func (db *MemoryBlockchainDB) GetBlocks(blockIds [][]byte) ([]*Block, error) {
 	return bs, nil
 }
 
+func (db *MemoryBlockchainDB) UpdateBlockState(blockId []byte, state int) error {
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+
+	b, ok := db.blockTable[string(blockId)]
+	if !ok {
+		return errors.New(fmt.Sprintf("Block not found %v\n", blockId))
+	}
+
+	// Replace instead of updating in place since GetBlocks hands out the stored blocks
+	updated := b.Clone()
+	updated.State = state
+	db.blockTable[string(blockId)] = updated
+	db.blockFeeds.publish(b, updated)
+	return nil
+}
+
 func (db *MemoryBlockchainDB) GetOldestBlocks(start int64, limit int) ([]*Block, error) {
 	db.blockLock.Lock()
 	defer db.blockLock.Unlock()
@@ -127,6 +200,77 @@ func (db *MemoryBlockchainDB) GetOldestBlocks(start int64, limit int) ([]*Block,
 	return candidates, nil
 }
 
+func (db *MemoryBlockchainDB) GetBlocksAfter(height int64, blockId []byte,
+	limit int) ([]*Block, error) {
+
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+
+	candidates := make([]*Block, 0)
+	for _, b := range db.blockTable {
+		if b.Height == nil {
+			continue
+		}
+		h := b.Height.Int64()
+		if h > height || (h == height && bytes.Compare(b.Hash, blockId) > 0) {
+			candidates = append(candidates, b.Clone())
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		// None of the Height will be nil
+		hi, hj := candidates[i].Height.Int64(), candidates[j].Height.Int64()
+		return hi < hj || (hi == hj && bytes.Compare(candidates[i].Hash, candidates[j].Hash) < 0)
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+func (db *MemoryBlockchainDB) GetMaxBlockHeight() (int64, error) {
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+
+	var maxHeight int64 = -1
+	for _, b := range db.blockTable {
+		if b.Height != nil && b.Height.Int64() > maxHeight {
+			maxHeight = b.Height.Int64()
+		}
+	}
+	return maxHeight, nil
+}
+
+func (db *MemoryBlockchainDB) GetMaxBlockHeightNotInState(state int) (int64, error) {
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+
+	var maxHeight int64 = -1
+	for _, b := range db.blockTable {
+		if b.Height != nil && b.State != state && b.Height.Int64() > maxHeight {
+			maxHeight = b.Height.Int64()
+		}
+	}
+	return maxHeight, nil
+}
+
+// Note: This is not performant, do not use in prod
+func (db *MemoryBlockchainDB) GetTransactionBlocks(txHash []byte) ([]*Block, error) {
+	db.blockLock.Lock()
+	defer db.blockLock.Unlock()
+
+	bs := make([]*Block, 0)
+	for _, b := range db.blockTable {
+		for _, tx := range b.Transactions {
+			if bytes.Equal(tx.Hash, txHash) {
+				bs = append(bs, b.Clone())
+				break
+			}
+		}
+	}
+	return bs, nil
+}
+
 func (db *MemoryBlockchainDB) GetOutputs(outputIds [][]byte) ([]*OutputRes, error) {
 	db.blockLock.Lock()
 	defer db.blockLock.Unlock()
@@ -178,8 +322,9 @@ func (db *MemoryBlockchainDB) GetInputsByOutput(outputIds [][]byte) ([]*InputRes
 									bCopy.Transactions = nil
 								}
 								candidates = append(candidates, &InputRes{
-									Block: bCopy,
-									Input: input.Clone(),
+									Block:  bCopy,
+									TxHash: tx.Hash,
+									Input:  input.Clone(),
 								})
 							}
 						}
@@ -196,7 +341,10 @@ func (db *MemoryBlockchainDB) WriteVote(v *Vote) error {
 	db.voteLock.Lock()
 	defer db.voteLock.Unlock()
 
-	db.voteTable[string(v.Hash)] = v.Clone()
+	old := db.voteTable[string(v.Hash)]
+	vCopy := v.Clone()
+	db.voteTable[string(v.Hash)] = vCopy
+	db.voteFeeds.publish(old, vCopy)
 	return nil
 }
 
@@ -241,16 +389,110 @@ func (db *MemoryBlockchainDB) GetRecentVotes(pubKey []byte, limit int) ([]*Vote,
 	return candidates, nil
 }
 
+func (db *MemoryBlockchainDB) GetBlockVotes(blockId []byte) ([]*Vote, error) {
+	db.voteLock.Lock()
+	defer db.voteLock.Unlock()
+
+	vs := make([]*Vote, 0)
+	for _, v := range db.voteTable {
+		if bytes.Equal(v.NextBlock, blockId) {
+			vs = append(vs, v.Clone())
+		}
+	}
+
+	return vs, nil
+}
+
+func (db *MemoryBlockchainDB) WriteRejections(rejs []*Rejection) error {
+	db.rejectLock.Lock()
+	defer db.rejectLock.Unlock()
+
+	for _, rej := range rejs {
+		db.rejectTable[string(rej.TxHash)] = rej.Clone()
+	}
+	return nil
+}
+
+func (db *MemoryBlockchainDB) GetRejection(txHash []byte) (*Rejection, error) {
+	db.rejectLock.Lock()
+	defer db.rejectLock.Unlock()
+
+	rej, ok := db.rejectTable[string(txHash)]
+	if !ok {
+		return nil, &NotFoundError{Key: txHash}
+	}
+	return rej.Clone(), nil
+}
+
+func (db *MemoryBlockchainDB) DeleteRejections(before int64) error {
+	db.rejectLock.Lock()
+	defer db.rejectLock.Unlock()
+
+	for key, rej := range db.rejectTable {
+		if rej.RejectedAt != nil && rej.RejectedAt.Int64() < before {
+			delete(db.rejectTable, key)
+		}
+	}
+	return nil
+}
+
+func (db *MemoryBlockchainDB) WriteVoteOffenses(offenses []*VoteOffense) error {
+	db.offenseLock.Lock()
+	defer db.offenseLock.Unlock()
+
+	for _, o := range offenses {
+		db.offenseTable[string(o.VoteHash)] = o.Clone()
+	}
+	return nil
+}
+
+func (db *MemoryBlockchainDB) GetVoteOffenses(voter []byte) ([]*VoteOffense, error) {
+	db.offenseLock.Lock()
+	defer db.offenseLock.Unlock()
+
+	offenses := make([]*VoteOffense, 0)
+	for _, o := range db.offenseTable {
+		if bytes.Equal(o.Voter, voter) {
+			offenses = append(offenses, o.Clone())
+		}
+	}
+	return offenses, nil
+}
+
+func (db *MemoryBlockchainDB) WriteHeartbeat(hb *Heartbeat) error {
+	db.beatLock.Lock()
+	defer db.beatLock.Unlock()
+
+	db.beatTable[string(hb.Node)] = hb.Clone()
+	return nil
+}
+
+func (db *MemoryBlockchainDB) GetHeartbeats() ([]*Heartbeat, error) {
+	db.beatLock.Lock()
+	defer db.beatLock.Unlock()
+
+	hbs := make([]*Heartbeat, 0, len(db.beatTable))
+	for _, hb := range db.beatTable {
+		hbs = append(hbs, hb.Clone())
+	}
+	return hbs, nil
+}
+
 func (db *MemoryBlockchainDB) GetAssignedTransactionChangefeed(
 	pubKey []byte) (TransactionChangefeed, error) {
 
-	return nil, errors.New("Changefeeds not supported by MemoryBlockchainDB")
+	cf := db.backlogFeeds.open(func(row interface{}) bool {
+		return bytes.Equal(row.(*Transaction).AssignedTo, pubKey)
+	})
+	return &MemoryTransactionChangefeed{cf: cf, cfs: &db.backlogFeeds}, nil
 }
 
 func (db *MemoryBlockchainDB) GetBlockChangefeed() (BlockChangefeed, error) {
-	return nil, errors.New("Changefeeds not supported by MemoryBlockchainDB")
+	cf := db.blockFeeds.open(nil)
+	return &MemoryBlockChangefeed{cf: cf, cfs: &db.blockFeeds}, nil
 }
 
 func (db *MemoryBlockchainDB) GetVoteChangefeed() (VoteChangefeed, error) {
-	return nil, errors.New("Changefeeds not supported by MemoryVotechainDB")
+	cf := db.voteFeeds.open(nil)
+	return &MemoryVoteChangefeed{cf: cf, cfs: &db.voteFeeds}, nil
 }
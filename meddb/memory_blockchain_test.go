@@ -22,6 +22,26 @@ func TestMemoryWriteTransaction(t *testing.T) {
 	assert.Equal(t, tx, db.backlogTable[string(tx.Hash)])
 }
 
+func TestMemoryWriteTransactions(t *testing.T) {
+	db := getMemoryDB(t)
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+
+	err := db.WriteTransactions([]*Transaction{tx, otherTx})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.backlogTable))
+	assert.Equal(t, tx, db.backlogTable[string(tx.Hash)])
+	assert.Equal(t, otherTx, db.backlogTable[string(otherTx.Hash)])
+
+	// Writing again replaces the transactions
+	tx.AssignedTo = []byte{69}
+	err = db.WriteTransactions([]*Transaction{tx})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.backlogTable))
+	assert.Equal(t, tx, db.backlogTable[string(tx.Hash)])
+}
+
 func TestMemoryGetAssignedTransactions(t *testing.T) {
 	db := getMemoryDB(t)
 	pubKey := []byte{69}
@@ -39,6 +59,24 @@ func TestMemoryGetAssignedTransactions(t *testing.T) {
 	assert.Equal(t, otherTx, txs[0])
 }
 
+func TestMemoryCountAssignedTransactions(t *testing.T) {
+	db := getMemoryDB(t)
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+	thirdTx := getTestTransaction()
+	thirdTx.Hash = []byte{23}
+	thirdTx.AssignedTo = []byte{69}
+
+	db.backlogTable[string(tx.Hash)] = tx.Clone()
+	db.backlogTable[string(otherTx.Hash)] = otherTx.Clone()
+	db.backlogTable[string(thirdTx.Hash)] = thirdTx.Clone()
+
+	counts, err := db.CountAssignedTransactions()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{string(tx.AssignedTo): 2, string([]byte{69}): 1}, counts)
+}
+
 func TestMemoryGetStaleTransactions(t *testing.T) {
 	db := getMemoryDB(t)
 	first := getTestTransaction()
@@ -86,6 +124,27 @@ func TestMemoryDeleteTransactions(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestMemoryGetBacklogTransaction(t *testing.T) {
+	db := getMemoryDB(t)
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+
+	db.backlogTable[string(tx.Hash)] = tx.Clone()
+	db.backlogTable[string(otherTx.Hash)] = otherTx.Clone()
+
+	res, err := db.GetBacklogTransaction(otherTx.Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, otherTx, res)
+}
+
+func TestMemoryGetBacklogTransactionNotFound(t *testing.T) {
+	db := getMemoryDB(t)
+
+	_, err := db.GetBacklogTransaction([]byte{22})
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
 func TestMemoryWriteBlock(t *testing.T) {
 	db := getMemoryDB(t)
 	b := getTestBlock()
@@ -126,6 +185,25 @@ func TestMemoryGetBlocksNotFound(t *testing.T) {
 	assert.IsType(t, errors.New(""), err)
 }
 
+func TestMemoryUpdateBlockState(t *testing.T) {
+	db := getMemoryDB(t)
+	b := getTestBlock()
+	db.blockTable[string(b.Hash)] = b.Clone()
+
+	err := db.UpdateBlockState(b.Hash, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, db.blockTable[string(b.Hash)].State)
+	// Block given to the db is untouched
+	assert.Equal(t, 1, b.State)
+}
+
+func TestMemoryUpdateBlockStateNotFound(t *testing.T) {
+	db := getMemoryDB(t)
+
+	err := db.UpdateBlockState([]byte("first"), 2)
+	assert.IsType(t, errors.New(""), err)
+}
+
 func TestMemoryGetOldestBlocks(t *testing.T) {
 	db := getMemoryDB(t)
 	first := getTestBlock()
@@ -162,6 +240,117 @@ func TestMemoryGetOldestBlocksEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(res))
 }
 
+func TestMemoryGetBlocksAfter(t *testing.T) {
+	db := getMemoryDB(t)
+	first := getTestBlock()
+	second := getTestBlock()
+	third := getTestBlock()
+	fourth := getTestBlock()
+	fifth := getTestBlock()
+
+	first.Height = big.NewInt(0)
+	second.Height = big.NewInt(1)
+	third.Height = big.NewInt(1)
+	fourth.Height = big.NewInt(2)
+	fifth.Height = nil
+
+	first.Hash = []byte("a")
+	second.Hash = []byte("c")
+	third.Hash = []byte("b")
+	fourth.Hash = []byte("d")
+	fifth.Hash = []byte("e")
+
+	db.blockTable = map[string]*Block{
+		"a": first,
+		"c": second,
+		"b": third,
+		"d": fourth,
+		"e": fifth,
+	}
+
+	res, err := db.GetBlocksAfter(-1, nil, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Block{first, third, second, fourth}, res)
+
+	res, err = db.GetBlocksAfter(1, nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Block{third, second}, res)
+
+	res, err = db.GetBlocksAfter(1, []byte("b"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Block{second, fourth}, res)
+}
+
+func TestMemoryGetMaxBlockHeight(t *testing.T) {
+	db := getMemoryDB(t)
+	height, err := db.GetMaxBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), height)
+
+	first := getTestBlock()
+	second := getTestBlock()
+	first.Height = big.NewInt(3)
+	second.Height = big.NewInt(7)
+	db.blockTable = map[string]*Block{"first": first, "second": second}
+
+	height, err = db.GetMaxBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), height)
+}
+
+func TestMemoryGetMaxBlockHeightNotInState(t *testing.T) {
+	db := getMemoryDB(t)
+	height, err := db.GetMaxBlockHeightNotInState(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), height)
+
+	first := getTestBlock()
+	second := getTestBlock()
+	first.Height = big.NewInt(3)
+	second.Height = big.NewInt(7)
+	second.State = 0
+	db.blockTable = map[string]*Block{"first": first, "second": second}
+
+	height, err = db.GetMaxBlockHeightNotInState(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), height)
+
+	height, err = db.GetMaxBlockHeightNotInState(1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), height)
+}
+
+func TestMemoryGetTransactionBlocks(t *testing.T) {
+	db := getMemoryDB(t)
+	first := getTestBlock()
+	second := getTestBlock()
+	third := getTestBlock()
+
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+	first.Creator = []byte("me")
+	second.Creator = []byte("you")
+	second.Transactions = append(second.Transactions, otherTx)
+	third.Transactions = []*Transaction{otherTx}
+
+	db.blockTable = map[string]*Block{
+		"first":  first,
+		"second": second,
+		"third":  third,
+	}
+
+	res, err := db.GetTransactionBlocks(getTestTransaction().Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	expected := []*Block{first, second}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+
+	res, err = db.GetTransactionBlocks([]byte{23})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
 func TestMemoryGetOutputs(t *testing.T) {
 	db := getMemoryDB(t)
 	b := getTestBlock()
@@ -190,8 +379,9 @@ func TestMemoryGetInputsByOutput(t *testing.T) {
 	bCopy := b.Clone()
 	bCopy.Transactions = nil
 	expected := []*InputRes{&InputRes{
-		Block: bCopy,
-		Input: b.Transactions[0].Inputs[0].Clone(),
+		Block:  bCopy,
+		TxHash: b.Transactions[0].Hash,
+		Input:  b.Transactions[0].Inputs[0].Clone(),
 	}}
 	actual, err := db.GetInputsByOutput([][]byte{[]byte("output1")})
 	assert.Nil(t, err)
@@ -267,6 +457,255 @@ func TestMemoryGetRecentVotesEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(res))
 }
 
+func TestMemoryGetBlockVotes(t *testing.T) {
+	db := getMemoryDB(t)
+	first := getTestVote()
+	second := getTestVote()
+	third := getTestVote()
+
+	second.Voter = []byte{43}
+	third.NextBlock = []byte{243}
+
+	db.voteTable = map[string]*Vote{
+		"first":  first,
+		"second": second,
+		"third":  third,
+	}
+
+	res, err := db.GetBlockVotes([]byte{242})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	expected := []*Vote{first, second}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+}
+
+func TestMemoryWriteRejections(t *testing.T) {
+	db := getMemoryDB(t)
+	rej := getTestRejection()
+	otherRej := getTestRejection()
+	otherRej.TxHash = []byte{22}
+
+	err := db.WriteRejections([]*Rejection{rej, otherRej})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.rejectTable))
+	assert.Equal(t, rej, db.rejectTable[string(rej.TxHash)])
+	assert.Equal(t, otherRej, db.rejectTable[string(otherRej.TxHash)])
+
+	// Newer rejection of the same transaction replaces the old one
+	newRej := getTestRejection()
+	newRej.Message = "still bad"
+	err = db.WriteRejections([]*Rejection{newRej})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.rejectTable))
+	assert.Equal(t, newRej, db.rejectTable[string(rej.TxHash)])
+}
+
+func TestMemoryGetRejection(t *testing.T) {
+	db := getMemoryDB(t)
+	rej := getTestRejection()
+	db.rejectTable[string(rej.TxHash)] = rej.Clone()
+
+	res, err := db.GetRejection(rej.TxHash)
+	assert.Nil(t, err)
+	assert.Equal(t, rej, res)
+}
+
+func TestMemoryGetRejectionNotFound(t *testing.T) {
+	db := getMemoryDB(t)
+
+	_, err := db.GetRejection([]byte{22})
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestMemoryDeleteRejections(t *testing.T) {
+	db := getMemoryDB(t)
+	first := getTestRejection()
+	second := getTestRejection()
+	third := getTestRejection()
+
+	first.RejectedAt = big.NewInt(69)
+	second.RejectedAt = big.NewInt(70)
+	third.RejectedAt = big.NewInt(71)
+
+	db.rejectTable = map[string]*Rejection{
+		"first":  first,
+		"second": second,
+		"third":  third,
+	}
+
+	err := db.DeleteRejections(70)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*Rejection{"second": second, "third": third}, db.rejectTable)
+}
+
+func TestMemoryWriteVoteOffenses(t *testing.T) {
+	db := getMemoryDB(t)
+	o := getTestVoteOffense()
+	otherO := getTestVoteOffense()
+	otherO.VoteHash = []byte{22}
+
+	err := db.WriteVoteOffenses([]*VoteOffense{o, otherO})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.offenseTable))
+	assert.Equal(t, o, db.offenseTable[string(o.VoteHash)])
+	assert.Equal(t, otherO, db.offenseTable[string(otherO.VoteHash)])
+
+	// Newer offense of the same vote replaces the old one
+	newO := getTestVoteOffense()
+	newO.Message = "still forged"
+	err = db.WriteVoteOffenses([]*VoteOffense{newO})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.offenseTable))
+	assert.Equal(t, newO, db.offenseTable[string(o.VoteHash)])
+}
+
+func TestMemoryGetVoteOffenses(t *testing.T) {
+	db := getMemoryDB(t)
+	o := getTestVoteOffense()
+	otherO := getTestVoteOffense()
+	otherO.VoteHash = []byte{22}
+	otherO.Voter = []byte{23}
+	db.offenseTable[string(o.VoteHash)] = o.Clone()
+	db.offenseTable[string(otherO.VoteHash)] = otherO.Clone()
+
+	res, err := db.GetVoteOffenses(o.Voter)
+	assert.Nil(t, err)
+	assert.Equal(t, []*VoteOffense{o}, res)
+
+	res, err = db.GetVoteOffenses([]byte{24})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
+func TestMemoryWriteHeartbeat(t *testing.T) {
+	db := getMemoryDB(t)
+	hb := getTestHeartbeat()
+	otherHb := getTestHeartbeat()
+	otherHb.Node = []byte{23}
+
+	assert.Nil(t, db.WriteHeartbeat(hb))
+	assert.Nil(t, db.WriteHeartbeat(otherHb))
+	assert.Equal(t, map[string]*Heartbeat{string(hb.Node): hb, string(otherHb.Node): otherHb},
+		db.beatTable)
+
+	// Newer heartbeat of the same node replaces the old one
+	newHb := getTestHeartbeat()
+	newHb.BeatAt = big.NewInt(73)
+	assert.Nil(t, db.WriteHeartbeat(newHb))
+	assert.Equal(t, 2, len(db.beatTable))
+	assert.Equal(t, newHb, db.beatTable[string(hb.Node)])
+}
+
+func TestMemoryGetHeartbeats(t *testing.T) {
+	db := getMemoryDB(t)
+	res, err := db.GetHeartbeats()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+
+	hb := getTestHeartbeat()
+	otherHb := getTestHeartbeat()
+	otherHb.Node = []byte{23}
+	db.beatTable[string(hb.Node)] = hb.Clone()
+	db.beatTable[string(otherHb.Node)] = otherHb.Clone()
+
+	res, err = db.GetHeartbeats()
+	assert.Nil(t, err)
+	expected := []*Heartbeat{hb, otherHb}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+}
+
+func TestMemoryAssignedTransactionChangefeed(t *testing.T) {
+	db := getMemoryDB(t)
+	cf, err := db.GetAssignedTransactionChangefeed([]byte{42})
+	assert.Nil(t, err)
+	defer cf.(*MemoryTransactionChangefeed).Close()
+
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+	otherTx.AssignedTo = []byte{43}
+	reassignedTx := getTestTransaction()
+	reassignedTx.AssignedTo = []byte{43}
+
+	assert.Nil(t, db.WriteTransaction(tx))
+	assert.Nil(t, db.WriteTransaction(otherTx)) // Assigned to someone else
+	assert.Nil(t, db.WriteTransaction(tx))      // Unchanged
+	assert.Nil(t, db.WriteTransaction(reassignedTx))
+	assert.Nil(t, db.WriteTransaction(tx))
+	assert.Nil(t, db.DeleteTransactions([]*Transaction{tx, otherTx}))
+
+	expected := []*TransactionChangefeedRes{
+		&TransactionChangefeedRes{OldVal: nil, NewVal: tx},
+		&TransactionChangefeedRes{OldVal: tx, NewVal: nil},
+		&TransactionChangefeedRes{OldVal: nil, NewVal: tx},
+		&TransactionChangefeedRes{OldVal: tx, NewVal: nil},
+	}
+	for _, e := range expected {
+		var res TransactionChangefeedRes
+		assert.True(t, cf.Next(&res))
+		assert.Equal(t, e, &res)
+	}
+}
+
+func TestMemoryBlockChangefeed(t *testing.T) {
+	db := getMemoryDB(t)
+	cf, err := db.GetBlockChangefeed()
+	assert.Nil(t, err)
+	defer cf.(*MemoryBlockChangefeed).Close()
+
+	b := getTestBlock()
+	updated := b.Clone()
+	updated.State = 2
+
+	assert.Nil(t, db.WriteBlock(b))
+	assert.Nil(t, db.UpdateBlockState(b.Hash, 2))
+
+	expected := []*BlockChangefeedRes{
+		&BlockChangefeedRes{OldVal: nil, NewVal: b},
+		&BlockChangefeedRes{OldVal: b, NewVal: updated},
+	}
+	for _, e := range expected {
+		var res BlockChangefeedRes
+		assert.True(t, cf.Next(&res))
+		assert.Equal(t, e, &res)
+	}
+}
+
+func TestMemoryVoteChangefeed(t *testing.T) {
+	db := getMemoryDB(t)
+	cf, err := db.GetVoteChangefeed()
+	assert.Nil(t, err)
+	defer cf.(*MemoryVoteChangefeed).Close()
+
+	v := getTestVote()
+	assert.Nil(t, db.WriteVote(v))
+
+	var res VoteChangefeedRes
+	assert.True(t, cf.Next(&res))
+	assert.Equal(t, &VoteChangefeedRes{OldVal: nil, NewVal: v}, &res)
+}
+
+func TestMemoryChangefeedClose(t *testing.T) {
+	db := getMemoryDB(t)
+	cf, err := db.GetVoteChangefeed()
+	assert.Nil(t, err)
+
+	done := make(chan bool)
+	go func() {
+		var res VoteChangefeedRes
+		done <- cf.Next(&res)
+	}()
+
+	cf.(*MemoryVoteChangefeed).Close()
+	assert.False(t, <-done)
+
+	// Writing after close does not block
+	assert.Nil(t, db.WriteVote(getTestVote()))
+	cf.(*MemoryVoteChangefeed).Close()
+}
+
 // -------
 // Helpers
 // -------
@@ -306,6 +745,7 @@ func getTestBlock() *Block {
 	return &Block{
 		Hash:         []byte{132},
 		Transactions: []*Transaction{getTestTransaction()},
+		Height:       big.NewInt(152),
 		CreatedAt:    big.NewInt(162),
 		Creator:      []byte{172},
 		Sig:          []byte{173},
@@ -325,3 +765,32 @@ func getTestVote() *Vote {
 		Value:     true,
 	}
 }
+
+func getTestRejection() *Rejection {
+	return &Rejection{
+		TxHash:     []byte{32},
+		ErrorType:  "RuleErrors",
+		Message:    "bad",
+		Node:       []byte{252},
+		RejectedAt: big.NewInt(262),
+	}
+}
+
+func getTestHeartbeat() *Heartbeat {
+	return &Heartbeat{
+		Node:   []byte{22},
+		BeatAt: big.NewInt(72),
+	}
+}
+
+func getTestVoteOffense() *VoteOffense {
+	return &VoteOffense{
+		VoteHash:   []byte{202},
+		Voter:      []byte{212},
+		BlockId:    []byte{242},
+		ErrorType:  "VoteSignatureInvalidError",
+		Message:    "forged",
+		Node:       []byte{252},
+		RecordedAt: big.NewInt(262),
+	}
+}
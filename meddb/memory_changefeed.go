@@ -0,0 +1,215 @@
+package meddb
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Changefeeds for MemoryBlockchainDB that mimic Rethink changefeeds:
+// - Inserts emit {OldVal: nil, NewVal: row}, updates emit {OldVal: old, NewVal: new} and deletes
+//   emit {OldVal: row, NewVal: nil}.
+// - Writes that do not change the row emit nothing.
+// - For filtered changefeeds, a row that stops matching the filter emits {OldVal: row, NewVal: nil}
+//   and a row that starts matching emits {OldVal: nil, NewVal: row}.
+// Writers never block on readers, changes are queued until they are read.
+
+type memoryChange struct {
+	oldVal interface{}
+	newVal interface{}
+}
+
+type memoryChangefeed struct {
+	in     chan *memoryChange // Written to by the db
+	out    chan *memoryChange // Read from by Next
+	filter func(interface{}) bool
+}
+
+func newMemoryChangefeed(filter func(interface{}) bool) *memoryChangefeed {
+	cf := &memoryChangefeed{
+		in:     make(chan *memoryChange),
+		out:    make(chan *memoryChange),
+		filter: filter,
+	}
+	go cf.pump()
+	return cf
+}
+
+// Moves changes from in to out, queueing them in between so that sending to in never waits for
+// the reader. Closes out once in is closed.
+func (cf *memoryChangefeed) pump() {
+	queue := make([]*memoryChange, 0)
+	for {
+		var (
+			out  chan *memoryChange = nil // Sending to nil channel blocks, so only send if queued
+			next *memoryChange      = nil
+		)
+		if len(queue) > 0 {
+			out = cf.out
+			next = queue[0]
+		}
+
+		select {
+		case change, ok := <-cf.in:
+			if !ok {
+				close(cf.out)
+				return
+			}
+			queue = append(queue, change)
+		case out <- next:
+			queue[0] = nil
+			queue = queue[1:]
+		}
+	}
+}
+
+// Blocks until the next change arrives. Returns false once the changefeed is closed.
+func (cf *memoryChangefeed) next() (*memoryChange, bool) {
+	change, ok := <-cf.out
+	return change, ok
+}
+
+// Keeps track of all open changefeeds on a single table.
+type memoryChangefeeds struct {
+	feeds map[*memoryChangefeed]bool
+	lock  sync.Mutex
+}
+
+// Opens a changefeed for rows matching the filter. A nil filter matches all rows.
+func (cfs *memoryChangefeeds) open(filter func(interface{}) bool) *memoryChangefeed {
+	cfs.lock.Lock()
+	defer cfs.lock.Unlock()
+
+	if cfs.feeds == nil {
+		cfs.feeds = make(map[*memoryChangefeed]bool)
+	}
+	cf := newMemoryChangefeed(filter)
+	cfs.feeds[cf] = true
+	return cf
+}
+
+func (cfs *memoryChangefeeds) close(cf *memoryChangefeed) {
+	cfs.lock.Lock()
+	defer cfs.lock.Unlock()
+
+	if _, ok := cfs.feeds[cf]; ok {
+		delete(cfs.feeds, cf)
+		// No more sends can happen since publish holds the lock
+		close(cf.in)
+	}
+}
+
+// Sends the change to all changefeeds whose filter matches the old or new row.
+// Must be called while holding the lock of the table, so that changes arrive in write order.
+// oldVal and newVal must be nil or pointers to rows that are not modified afterwards.
+func (cfs *memoryChangefeeds) publish(oldVal, newVal interface{}) {
+	cfs.lock.Lock()
+	defer cfs.lock.Unlock()
+
+	oldVal, newVal = nilIfNilPtr(oldVal), nilIfNilPtr(newVal)
+	if len(cfs.feeds) == 0 || reflect.DeepEqual(oldVal, newVal) {
+		return
+	}
+
+	for cf, _ := range cfs.feeds {
+		change := &memoryChange{}
+		if oldVal != nil && (cf.filter == nil || cf.filter(oldVal)) {
+			change.oldVal = oldVal
+		}
+		if newVal != nil && (cf.filter == nil || cf.filter(newVal)) {
+			change.newVal = newVal
+		}
+		if change.oldVal != nil || change.newVal != nil {
+			cf.in <- change
+		}
+	}
+}
+
+// Turns nil pointers wrapped in an interface into plain nils.
+func nilIfNilPtr(v interface{}) interface{} {
+	if v != nil && reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	return v
+}
+
+// ------------------------
+// MemoryBlockchainDB feeds
+// ------------------------
+
+type MemoryTransactionChangefeed struct {
+	cf   *memoryChangefeed
+	cfs  *memoryChangefeeds
+	once sync.Once
+}
+
+func (cf *MemoryTransactionChangefeed) Next(res *TransactionChangefeedRes) bool {
+	change, ok := cf.cf.next()
+	if ok {
+		res.OldVal, res.NewVal = nil, nil
+		if change.oldVal != nil {
+			res.OldVal = change.oldVal.(*Transaction).Clone()
+		}
+		if change.newVal != nil {
+			res.NewVal = change.newVal.(*Transaction).Clone()
+		}
+	}
+	return ok
+}
+
+// Stops the changefeed. Queued changes are dropped and Next returns false from then on.
+func (cf *MemoryTransactionChangefeed) Close() error {
+	cf.once.Do(func() { cf.cfs.close(cf.cf) })
+	return nil
+}
+
+type MemoryBlockChangefeed struct {
+	cf   *memoryChangefeed
+	cfs  *memoryChangefeeds
+	once sync.Once
+}
+
+func (cf *MemoryBlockChangefeed) Next(res *BlockChangefeedRes) bool {
+	change, ok := cf.cf.next()
+	if ok {
+		res.OldVal, res.NewVal = nil, nil
+		if change.oldVal != nil {
+			res.OldVal = change.oldVal.(*Block).Clone()
+		}
+		if change.newVal != nil {
+			res.NewVal = change.newVal.(*Block).Clone()
+		}
+	}
+	return ok
+}
+
+// Stops the changefeed. Queued changes are dropped and Next returns false from then on.
+func (cf *MemoryBlockChangefeed) Close() error {
+	cf.once.Do(func() { cf.cfs.close(cf.cf) })
+	return nil
+}
+
+type MemoryVoteChangefeed struct {
+	cf   *memoryChangefeed
+	cfs  *memoryChangefeeds
+	once sync.Once
+}
+
+func (cf *MemoryVoteChangefeed) Next(res *VoteChangefeedRes) bool {
+	change, ok := cf.cf.next()
+	if ok {
+		res.OldVal, res.NewVal = nil, nil
+		if change.oldVal != nil {
+			res.OldVal = change.oldVal.(*Vote).Clone()
+		}
+		if change.newVal != nil {
+			res.NewVal = change.newVal.(*Vote).Clone()
+		}
+	}
+	return ok
+}
+
+// Stops the changefeed. Queued changes are dropped and Next returns false from then on.
+func (cf *MemoryVoteChangefeed) Close() error {
+	cf.once.Do(func() { cf.cfs.close(cf.cf) })
+	return nil
+}
@@ -0,0 +1,152 @@
+package meddb
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// Decorates a BlockchainDB with an LRU cache for GetOutputs.
+// Only outputs in blocks with the accepted state are cached. Accepted is final, so cached outputs
+// are never invalidated. Once an output has been accepted, other copies of it in later blocks do
+// not matter for validation, so those are not looked up again. Outputs of the uncached types are
+// the exception, since their later copies replace the earlier ones, so those are always read from
+// db.
+type OutputCacheDB struct {
+	BlockchainDB
+	size          int
+	acceptedState int
+	uncachedTypes map[int]bool
+	entries       map[string]*list.Element
+	lru           *list.List // Front is the most recently used
+	lock          sync.Mutex
+	hits          uint64
+	misses        uint64
+}
+
+type outputCacheEntry struct {
+	outputId string
+	res      []*OutputRes
+}
+
+// Caches at most size output ids from db. acceptedState is the state of blocks that are accepted.
+// Outputs of the given uncachedTypes are never cached.
+func NewOutputCacheDB(db BlockchainDB, size int, acceptedState int,
+	uncachedTypes ...int) *OutputCacheDB {
+
+	cache := &OutputCacheDB{
+		BlockchainDB:  db,
+		size:          size,
+		acceptedState: acceptedState,
+		uncachedTypes: make(map[int]bool),
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+	for _, outputType := range uncachedTypes {
+		cache.uncachedTypes[outputType] = true
+	}
+	return cache
+}
+
+// Returns the outputs for the given output ids, from the cache when possible.
+func (db *OutputCacheDB) GetOutputs(outputIds [][]byte) ([]*OutputRes, error) {
+	res := make([]*OutputRes, 0)
+	missingIds := make([][]byte, 0)
+	for _, outputId := range outputIds {
+		if cached, ok := db.get(string(outputId)); ok {
+			atomic.AddUint64(&db.hits, 1)
+			res = append(res, cached...)
+		} else {
+			atomic.AddUint64(&db.misses, 1)
+			missingIds = append(missingIds, outputId)
+		}
+	}
+	if len(missingIds) == 0 {
+		return res, nil
+	}
+
+	fetched, err := db.BlockchainDB.GetOutputs(missingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := make(map[string][]*OutputRes)
+	for _, outputRes := range fetched {
+		if outputRes.Block.State == db.acceptedState && !db.uncachedTypes[outputRes.Output.Type] {
+			outputId := string(outputRes.Output.Hash)
+			accepted[outputId] = append(accepted[outputId], outputRes)
+		}
+	}
+	for outputId, outputRes := range accepted {
+		db.put(outputId, outputRes)
+	}
+
+	return append(res, fetched...), nil
+}
+
+// Returns the outputs for the given output ids from db, without reading or filling the cache. For
+// outputs whose later copies matter to the caller.
+func (db *OutputCacheDB) GetUncachedOutputs(outputIds [][]byte) ([]*OutputRes, error) {
+	return db.BlockchainDB.GetOutputs(outputIds)
+}
+
+// Returns the number of output ids that were found in the cache.
+func (db *OutputCacheDB) Hits() uint64 {
+	return atomic.LoadUint64(&db.hits)
+}
+
+// Returns the number of output ids that had to be read from the db.
+func (db *OutputCacheDB) Misses() uint64 {
+	return atomic.LoadUint64(&db.misses)
+}
+
+// -------
+// Helpers
+// -------
+
+func (db *OutputCacheDB) get(outputId string) ([]*OutputRes, bool) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	elem, ok := db.entries[outputId]
+	if !ok {
+		return nil, false
+	}
+	db.lru.MoveToFront(elem)
+	return cloneOutputResponses(elem.Value.(*outputCacheEntry).res), true
+}
+
+func (db *OutputCacheDB) put(outputId string, res []*OutputRes) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.size <= 0 {
+		return
+	}
+	if elem, ok := db.entries[outputId]; ok {
+		db.lru.MoveToFront(elem)
+		return
+	}
+
+	db.entries[outputId] = db.lru.PushFront(&outputCacheEntry{
+		outputId: outputId,
+		res:      cloneOutputResponses(res),
+	})
+	for db.lru.Len() > db.size {
+		oldest := db.lru.Back()
+		db.lru.Remove(oldest)
+		delete(db.entries, oldest.Value.(*outputCacheEntry).outputId)
+	}
+}
+
+func cloneOutputResponses(res []*OutputRes) []*OutputRes {
+	clones := make([]*OutputRes, len(res))
+	for i, outputRes := range res {
+		clones[i] = &OutputRes{
+			Block:       outputRes.Block.Clone(),
+			Transaction: outputRes.Transaction.Clone(),
+			Output:      outputRes.Output.Clone(),
+		}
+	}
+	return clones
+}
@@ -0,0 +1,137 @@
+package meddb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAcceptedState = 1
+
+func getOutputCacheDB(t *testing.T, size int) (*OutputCacheDB, *MemoryBlockchainDB) {
+	db := getMemoryDB(t)
+	return NewOutputCacheDB(db, size, testAcceptedState), db
+}
+
+// -----
+// Tests
+// -----
+
+func TestOutputCacheHit(t *testing.T) {
+	cache, db := getOutputCacheDB(t, 10)
+	b := getTestBlock()
+	db.blockTable = map[string]*Block{"block": b}
+
+	expected, err := db.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+
+	res, err := cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, res)
+	assert.Equal(t, uint64(0), cache.Hits())
+	assert.Equal(t, uint64(1), cache.Misses())
+
+	// Accepted outputs are served from the cache even once they are gone from the db
+	db.blockTable = map[string]*Block{}
+	res, err = cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, res)
+	assert.Equal(t, uint64(1), cache.Hits())
+	assert.Equal(t, uint64(1), cache.Misses())
+}
+
+func TestOutputCacheMixed(t *testing.T) {
+	cache, db := getOutputCacheDB(t, 10)
+	db.blockTable = map[string]*Block{"block": getTestBlock()}
+
+	_, err := cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+
+	res, err := cache.GetOutputs([][]byte{[]byte("output1"), []byte("output2"), []byte("none")})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, uint64(1), cache.Hits())
+	assert.Equal(t, uint64(3), cache.Misses())
+}
+
+func TestOutputCacheSkipsUndecided(t *testing.T) {
+	cache, db := getOutputCacheDB(t, 10)
+	b := getTestBlock()
+	b.State = 0
+	db.blockTable = map[string]*Block{"block": b}
+
+	_, err := cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+
+	// Decided in the meantime
+	db.blockTable["block"].State = testAcceptedState
+	res, err := cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, testAcceptedState, res[0].Block.State)
+	assert.Equal(t, uint64(0), cache.Hits())
+	assert.Equal(t, uint64(2), cache.Misses())
+}
+
+func TestOutputCacheSkipsUncachedTypes(t *testing.T) {
+	db := getMemoryDB(t)
+	cache := NewOutputCacheDB(db, 10, testAcceptedState, 2)
+	db.blockTable = map[string]*Block{"block": getTestBlock()}
+
+	_, err := cache.GetOutputs([][]byte{[]byte("output1"), []byte("output2")})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cache.entries))
+	assert.Contains(t, cache.entries, "output1")
+
+	// Later copies of output2 are seen
+	other := getTestBlock()
+	other.Hash = []byte{133}
+	db.blockTable["other"] = other
+	res, err := cache.GetOutputs([][]byte{[]byte("output2")})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, uint64(0), cache.Hits())
+	assert.Equal(t, uint64(3), cache.Misses())
+}
+
+func TestOutputCacheGetUncached(t *testing.T) {
+	cache, db := getOutputCacheDB(t, 10)
+	db.blockTable = map[string]*Block{"block": getTestBlock()}
+
+	res, err := cache.GetUncachedOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 0, len(cache.entries))
+	assert.Equal(t, uint64(0), cache.Hits())
+	assert.Equal(t, uint64(0), cache.Misses())
+}
+
+func TestOutputCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, db := getOutputCacheDB(t, 1)
+	db.blockTable = map[string]*Block{"block": getTestBlock()}
+
+	_, err := cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	_, err = cache.GetOutputs([][]byte{[]byte("output2")})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cache.entries))
+	assert.Contains(t, cache.entries, "output2")
+
+	_, err = cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), cache.Hits())
+	assert.Equal(t, uint64(3), cache.Misses())
+}
+
+func TestOutputCacheReturnsCopies(t *testing.T) {
+	cache, db := getOutputCacheDB(t, 10)
+	db.blockTable = map[string]*Block{"block": getTestBlock()}
+
+	res, err := cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	res[0].Output.Hash = []byte("changed")
+
+	res, err = cache.GetOutputs([][]byte{[]byte("output1")})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("output1"), res[0].Output.Hash)
+}
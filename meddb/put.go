@@ -27,6 +27,24 @@ func (op *PutOp) AddColVer(colId []byte, verId int64, data []byte) error {
 	return nil
 }
 
+// Deletes the column in this row, the tombstone gets the current time as version.
+func (op *PutOp) AddTombstone(colId []byte) error {
+	if _, ok := op.cols[string(colId)]; ok {
+		return &ColIdAlreadyExists{ColId: colId}
+	}
+	op.cols[string(colId)] = NewTombstone()
+	return nil
+}
+
+// Deletes all versions of the column in this row up to verId.
+func (op *PutOp) AddTombstoneVer(colId []byte, verId int64) error {
+	if _, ok := op.cols[string(colId)]; ok {
+		return &ColIdAlreadyExists{ColId: colId}
+	}
+	op.cols[string(colId)] = NewTombstoneVer(verId)
+	return nil
+}
+
 func (op *PutOp) fillVer(verId int64) {
 	for _, cell := range op.cols {
 		if cell.VerId == nil {
@@ -27,6 +27,19 @@ func TestAddColVer(t *testing.T) {
 	assertCellsEqual(t, NewCellVer(verId, nil), op.cols[string(colId)])
 }
 
+func TestAddTombstone(t *testing.T) {
+	rowId := []byte("LOLLL")
+	colId := []byte("AYY LMAO")
+	op := NewPutOp(rowId)
+
+	err := op.AddTombstoneVer(colId, 123)
+	assert.Nil(t, err)
+	assert.Equal(t, NewTombstoneVer(123), op.cols[string(colId)])
+
+	err = op.AddTombstone(colId)
+	assert.IsType(t, &ColIdAlreadyExists{}, err)
+}
+
 func TestAddColAlreadyExists(t *testing.T) {
 	rowId := []byte("LOLLL")
 	colId := []byte("AYY LMAO")
@@ -18,11 +18,18 @@ type RethinkBigtable struct {
 }
 
 type rethinkCell struct {
-	ID    []byte `gorethink:"id"`
-	RowId []byte `gorethink:"row_id"`
-	ColId []byte `gorethink:"col_id"`
-	VerId []byte `gorethink:"ver_id"`
-	Data  []byte `gorethink:"data"`
+	ID        []byte `gorethink:"id"`
+	RowId     []byte `gorethink:"row_id"`
+	ColId     []byte `gorethink:"col_id"`
+	VerId     []byte `gorethink:"ver_id"`
+	Data      []byte `gorethink:"data"`
+	Tombstone bool   `gorethink:"tombstone"`
+}
+
+// Newest tombstone of a column
+type rethinkDeletedAt struct {
+	ColId []byte       `gorethink:"group"`
+	Cell  *rethinkCell `gorethink:"reduction"`
 }
 
 // -------------------
@@ -137,12 +144,16 @@ func (bt *RethinkBigtable) Get(tableName []byte, op *GetOp) (map[string][]*Cell,
 		for _, group := range groupMap {
 			for _, rowObj := range group["reduction"].([]interface{}) {
 				row := rowObj.(map[string]interface{})
+				// Tombstones have no data and cells written before tombstones have no flag
+				data, _ := row["data"].([]byte)
+				tombstone, _ := row["tombstone"].(bool)
 				rCell := &rethinkCell{
-					ID:    row["id"].([]byte),
-					RowId: row["row_id"].([]byte),
-					ColId: row["col_id"].([]byte),
-					VerId: row["ver_id"].([]byte),
-					Data:  row["data"].([]byte),
+					ID:        row["id"].([]byte),
+					RowId:     row["row_id"].([]byte),
+					ColId:     row["col_id"].([]byte),
+					VerId:     row["ver_id"].([]byte),
+					Data:      data,
+					Tombstone: tombstone,
 				}
 				rows = append(rows, rCell)
 			}
@@ -156,6 +167,7 @@ func (bt *RethinkBigtable) Get(tableName []byte, op *GetOp) (map[string][]*Cell,
 	cells := make(map[string][]*Cell)
 	for _, row := range rows {
 		cell := NewCellVer(bytesToInt64(row.VerId), row.Data)
+		cell.Tombstone = row.Tombstone
 		colString := string(row.ColId)
 
 		if _, ok := cells[colString]; ok {
@@ -165,9 +177,124 @@ func (bt *RethinkBigtable) Get(tableName []byte, op *GetOp) (map[string][]*Cell,
 		}
 	}
 
+	if !op.history {
+		deletedAt, err := bt.getDeletedAt(tableTerm, op.rowId, op.colIds)
+		if err != nil {
+			return nil, err
+		}
+		cells = hideDeleted(cells, deletedAt)
+	}
 	return cells, nil
 }
 
+func (bt *RethinkBigtable) GetColIds(tableName []byte, rowId []byte) ([][]byte, error) {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+
+	res, err := r.DB(bt.database).Table(string(tableName)).GetAllByIndex(
+		"row_id", rowId,
+	).Field("col_id").Distinct().Run(bt.session)
+	if err != nil {
+		if _, ok := err.(r.RQLOpFailedError); ok {
+			return nil, &TableNotFoundError{TableName: tableName}
+		}
+		return nil, err
+	}
+	defer res.Close()
+
+	colIds := make([][]byte, 0)
+	if err := res.All(&colIds); err != nil {
+		return nil, err
+	}
+	return colIds, nil
+}
+
+// Looks up the row ids of the page on the row_id index first, then reads the cells of those rows.
+// The ids built by buildRethinkId are base64 encoded and do not sort like the row ids they start
+// with, so ranges over the primary key would skip rows. Rows that the scan returns no cells of
+// (i.e. deleted rows) are skipped, so more row ids are read until the page is full.
+func (bt *RethinkBigtable) Scan(tableName []byte, op *ScanOp) (*ScanRes, error) {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+
+	start, end := op.rowRange()
+	res := &ScanRes{Rows: make([]*ScanRow, 0)}
+	if isEmptyRange(start, end) {
+		return res, nil
+	}
+
+	var (
+		lo        interface{} = r.MinVal
+		hi        interface{} = r.MaxVal
+		tableTerm r.Term      = r.DB(bt.database).Table(string(tableName))
+	)
+	if start != nil {
+		lo = start
+	}
+	if end != nil {
+		hi = end
+	}
+	selectCols := func(term r.Term) r.Term {
+		if op.colIds == nil {
+			return term
+		}
+		return term.Filter(func(row r.Term) interface{} {
+			return r.Expr(op.colIds).Contains(row.Field("col_id"))
+		})
+	}
+
+	// Walks the row_id index in order, so the query streams and is only read as far as the page
+	// needs. The index can only drop duplicate row ids when no columns are selected, otherwise
+	// cells come ordered by row id and the duplicates are dropped by readRowIds.
+	rowIdsTerm := tableTerm.Between(lo, hi, r.BetweenOpts{
+		Index: "row_id",
+	}).OrderBy(r.OrderByOpts{Index: "row_id"})
+	if op.colIds == nil {
+		rowIdsTerm = rowIdsTerm.Distinct(r.DistinctOpts{Index: "row_id"})
+	} else {
+		rowIdsTerm = selectCols(rowIdsTerm).Field("row_id")
+	}
+	rowIdsRes, err := rowIdsTerm.Run(bt.session)
+	if err != nil {
+		if _, ok := err.(r.RQLOpFailedError); ok {
+			return nil, &TableNotFoundError{TableName: tableName}
+		}
+		return nil, err
+	}
+	defer rowIdsRes.Close()
+
+	// One more row than fits in the page tells where the next page starts
+	batchSize := op.pageSize + 1
+	var lastRowId []byte
+	for {
+		rowIds, err := readRowIds(rowIdsRes, batchSize, lastRowId)
+		if err != nil {
+			return nil, err
+		}
+		if len(rowIds) == 0 {
+			return res, nil
+		}
+		lastRowId = rowIds[len(rowIds)-1]
+
+		ids := make([]interface{}, len(rowIds))
+		for i, rowId := range rowIds {
+			ids[i] = rowId
+		}
+		rows, err := bt.getScanRows(selectCols(tableTerm.GetAllByIndex("row_id", ids...)))
+		if err != nil {
+			return nil, err
+		}
+		for _, rowId := range rowIds {
+			if !res.addRow(op, rowId, rows[string(rowId)]) {
+				return res, nil
+			}
+		}
+		if uint32(len(rowIds)) < batchSize {
+			return res, nil
+		}
+	}
+}
+
 func (bt *RethinkBigtable) CreateTable(tableName []byte) error {
 	bt.lock.Lock()
 	defer bt.lock.Unlock()
@@ -188,12 +315,102 @@ func (bt *RethinkBigtable) CreateTable(tableName []byte) error {
 	return nil
 }
 
+func (bt *RethinkBigtable) DropTable(tableName []byte) error {
+	bt.lock.Lock()
+	defer bt.lock.Unlock()
+
+	_, err := r.DB(bt.database).TableDrop(string(tableName)).RunWrite(bt.session)
+	if err != nil {
+		if _, ok := err.(r.RQLOpFailedError); ok {
+			return &TableNotFoundError{TableName: tableName}
+		}
+		return err
+	}
+
+	return nil
+}
+
 // -------
 // Helpers
 // -------
 
+// Reads at most n more row ids from the cursor, leaving out duplicates. Row ids come sorted, so
+// duplicates follow each other. lastRowId is the last row id that was read before, nil if none.
+func readRowIds(cursor *r.Cursor, n uint32, lastRowId []byte) ([][]byte, error) {
+	rowIds := make([][]byte, 0, n)
+	var rowId []byte
+	for uint32(len(rowIds)) < n && cursor.Next(&rowId) {
+		if lastRowId == nil || !bytes.Equal(lastRowId, rowId) {
+			rowIds = append(rowIds, rowId)
+			lastRowId = rowId
+		}
+		rowId = nil
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return rowIds, nil
+}
+
+// Reads the cells that the term selects, by row id and column id. Cells of every column are sorted
+// by decreasing verId.
+func (bt *RethinkBigtable) getScanRows(term r.Term) (map[string]map[string][]*Cell, error) {
+	cellsRes, err := term.OrderBy(r.Desc("ver_id")).Run(bt.session)
+	if err != nil {
+		return nil, err
+	}
+	defer cellsRes.Close()
+
+	var rCells []*rethinkCell
+	if err := cellsRes.All(&rCells); err != nil {
+		return nil, err
+	}
+
+	// Cells are sorted by decreasing verId, so they stay sorted within every column
+	rows := make(map[string]map[string][]*Cell)
+	for _, rCell := range rCells {
+		cell := NewCellVer(bytesToInt64(rCell.VerId), rCell.Data)
+		cell.Tombstone = rCell.Tombstone
+		if _, ok := rows[string(rCell.RowId)]; !ok {
+			rows[string(rCell.RowId)] = make(map[string][]*Cell)
+		}
+		rows[string(rCell.RowId)][string(rCell.ColId)] = append(
+			rows[string(rCell.RowId)][string(rCell.ColId)], cell)
+	}
+	return rows, nil
+}
+
+// Returns the version of the newest tombstone of every given column in the row that has one.
+func (bt *RethinkBigtable) getDeletedAt(tableTerm r.Term, rowId []byte,
+	colIds [][]byte) (map[string]*big.Int, error) {
+
+	res, err := tableTerm.GetAllByIndex(
+		"row_id", rowId,
+	).Filter(func(row r.Term) interface{} {
+		return r.Expr(colIds).Contains(row.Field("col_id")).And(
+			row.Field("tombstone").Default(false),
+		)
+	}).Group("col_id").Max("ver_id").Ungroup().Run(bt.session)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var rows []*rethinkDeletedAt
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	deletedAt := make(map[string]*big.Int)
+	for _, row := range rows {
+		deletedAt[string(row.ColId)] = big.NewInt(bytesToInt64(row.Cell.VerId))
+	}
+	return deletedAt, nil
+}
+
 func newRethinkCell(rowId, colId []byte, cell *Cell) (*rethinkCell, error) {
-	if rowId == nil || colId == nil || cell.VerId == nil || cell.Data == nil {
+	// Tombstones are the only cells without data
+	if rowId == nil || colId == nil || cell.VerId == nil || (cell.Data == nil && !cell.Tombstone) {
 		return nil, errors.New(fmt.Sprintf(`Cell is missing rowId, colId, verId or data
 			rowId %v
 			colId %v
@@ -206,11 +423,12 @@ func newRethinkCell(rowId, colId []byte, cell *Cell) (*rethinkCell, error) {
 		return nil, err
 	}
 	return &rethinkCell{
-		ID:    id,
-		RowId: rowId,
-		ColId: colId,
-		VerId: int64ToBytes(cell.VerId.Int64()),
-		Data:  cell.Data,
+		ID:        id,
+		RowId:     rowId,
+		ColId:     colId,
+		VerId:     int64ToBytes(cell.VerId.Int64()),
+		Data:      cell.Data,
+		Tombstone: cell.Tombstone,
 	}, nil
 }
 
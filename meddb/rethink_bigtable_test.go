@@ -73,6 +73,61 @@ func TestRethinkGetRange(t *testing.T) {
 	testGetRange(t, bt, []byte(rethinkTableName))
 }
 
+func TestRethinkDeleteTombstone(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testDeleteTombstone(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkGetHistory(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testGetHistory(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkGetColIds(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testGetColIds(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkScan(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testScan(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkScanPages(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testScanPages(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkScanDeleted(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testScanDeleted(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkScanPagesDeleted(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	defer rethinkClearTable(bt, rethinkTableName)
+	testScanPagesDeleted(t, bt, []byte(rethinkTableName))
+}
+
+func TestRethinkScanTableNotFound(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	testScanTableNotFound(t, bt)
+}
+
 func TestRethinkGetTableNotFound(t *testing.T) {
 	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
 	assert.Nil(t, err)
@@ -91,6 +146,18 @@ func TestRethinkCreateTableAlreadyExists(t *testing.T) {
 	testCreateTableAlreadyExists(t, bt)
 }
 
+func TestRethinkDropTable(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	testDropTable(t, bt)
+}
+
+func TestRethinkDropTableNotFound(t *testing.T) {
+	bt, err := NewRethinkBigtable([]string{"127.0.0.1"}, rethinkBigtableDB)
+	assert.Nil(t, err)
+	testDropTableNotFound(t, bt)
+}
+
 // ------------
 // Test Helpers
 // ------------
@@ -1,6 +1,7 @@
 package meddb
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
@@ -14,6 +15,9 @@ const (
 	rethinkBacklogName = "backlog"
 	rethinkBlockName   = "block"
 	rethinkVoteName    = "vote"
+	rethinkRejectName  = "rejection"
+	rethinkOffenseName = "offense"
+	rethinkBeatName    = "heartbeat"
 )
 
 type RethinkBlockchainDB struct {
@@ -55,6 +59,7 @@ type rethinkInput struct {
 type rethinkBlock struct {
 	Hash         []byte                `gorethink:"id"`
 	Transactions []*rethinkTransaction `gorethink:"transactions"`
+	Height       []byte                `gorethink:"height"`
 	CreatedAt    []byte                `gorethink:"created_at"`
 	Creator      []byte                `gorethink:"creator"`
 	Sig          []byte                `gorethink:"sig"`
@@ -72,6 +77,29 @@ type rethinkVote struct {
 	Value     bool   `gorethink:"value"`
 }
 
+type rethinkRejection struct {
+	TxHash     []byte `gorethink:"id"`
+	ErrorType  string `gorethink:"error_type"`
+	Message    string `gorethink:"message"`
+	Node       []byte `gorethink:"node"`
+	RejectedAt []byte `gorethink:"rejected_at"`
+}
+
+type rethinkVoteOffense struct {
+	VoteHash   []byte `gorethink:"id"`
+	Voter      []byte `gorethink:"voter"`
+	BlockId    []byte `gorethink:"block_id"`
+	ErrorType  string `gorethink:"error_type"`
+	Message    string `gorethink:"message"`
+	Node       []byte `gorethink:"node"`
+	RecordedAt []byte `gorethink:"recorded_at"`
+}
+
+type rethinkHeartbeat struct {
+	Node   []byte `gorethink:"id"`
+	BeatAt []byte `gorethink:"beat_at"`
+}
+
 // ----------------------
 // MemoryBlockchainDB API
 // ----------------------
@@ -107,6 +135,18 @@ func (db *RethinkBlockchainDB) SetupTables() error {
 	if err != nil {
 		return err
 	}
+	_, err = r.DB(db.database).TableCreate(rethinkRejectName).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB(db.database).TableCreate(rethinkOffenseName).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB(db.database).TableCreate(rethinkBeatName).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
 	err = db.setupBacklogIndices()
 	if err != nil {
 		return err
@@ -119,6 +159,14 @@ func (db *RethinkBlockchainDB) SetupTables() error {
 	if err != nil {
 		return err
 	}
+	err = db.setupRejectionIndices()
+	if err != nil {
+		return err
+	}
+	err = db.setupOffenseIndices()
+	if err != nil {
+		return err
+	}
 	_, err = db.backlogTable().IndexWait().Run(db.session)
 	if err != nil {
 		return err
@@ -140,6 +188,20 @@ func (db *RethinkBlockchainDB) setupBlockIndices() error {
 	if err != nil {
 		return err
 	}
+	_, err = db.blockTable().IndexCreateFunc("height__id", func(row r.Term) interface{} {
+		return []interface{}{row.Field("height"), row.Field("id")}
+	}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	_, err = db.blockTable().IndexCreateFunc("transactions", func(block r.Term) interface{} {
+		return block.Field("transactions").Map(func(tx r.Term) interface{} {
+			return tx.Field("id")
+		})
+	}, r.IndexCreateOpts{Multi: true}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
 	_, err = db.blockTable().IndexCreateFunc("outputs", func(block r.Term) interface{} {
 		return block.Field("transactions").ConcatMap(func(tx r.Term) interface{} {
 			return tx.Field("outputs").Map(func(output r.Term) interface{} {
@@ -170,6 +232,26 @@ func (db *RethinkBlockchainDB) setupVoteIndices() error {
 	if err != nil {
 		return err
 	}
+	_, err = db.voteTable().IndexCreate("next_block").RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *RethinkBlockchainDB) setupRejectionIndices() error {
+	_, err := db.rejectionTable().IndexCreate("rejected_at").RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *RethinkBlockchainDB) setupOffenseIndices() error {
+	_, err := db.offenseTable().IndexCreate("voter").RunWrite(db.session)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -188,6 +270,32 @@ func (db *RethinkBlockchainDB) WriteTransaction(tx *Transaction) error {
 	return nil
 }
 
+func (db *RethinkBlockchainDB) WriteTransactions(txs []*Transaction) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if len(txs) == 0 {
+		return nil
+	}
+
+	rethinkTxs := make([]*rethinkTransaction, len(txs))
+	for i, tx := range txs {
+		rethinkTxs[i] = newRethinkTransaction(tx)
+	}
+
+	res, err := db.backlogTable().Insert(rethinkTxs, r.InsertOpts{
+		Conflict: "replace",
+	}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+	if res.Errors > 0 {
+		return db.getFailedTransactionWrites(rethinkTxs, res.FirstError)
+	}
+
+	return nil
+}
+
 func (db *RethinkBlockchainDB) GetAssignedTransactions(pubKey []byte) ([]*Transaction, error) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -204,6 +312,32 @@ func (db *RethinkBlockchainDB) GetAssignedTransactions(pubKey []byte) ([]*Transa
 	return fromRethinkTransactions(rows), nil
 }
 
+type rethinkAssignedCount struct {
+	AssignedTo []byte `gorethink:"group"`
+	Count      int    `gorethink:"reduction"`
+}
+
+func (db *RethinkBlockchainDB) CountAssignedTransactions() (map[string]int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.backlogTable().Group("assigned_to").Count().Ungroup().Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*rethinkAssignedCount
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[string(row.AssignedTo)] = row.Count
+	}
+	return counts, nil
+}
+
 func (db *RethinkBlockchainDB) GetStaleTransactions(before int64) ([]*Transaction, error) {
 
 	db.lock.Lock()
@@ -244,6 +378,25 @@ func (db *RethinkBlockchainDB) DeleteTransactions(txs []*Transaction) error {
 	return nil
 }
 
+func (db *RethinkBlockchainDB) GetBacklogTransaction(txHash []byte) (*Transaction, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.backlogTable().Get(txHash).Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+	if res.IsNil() {
+		return nil, &NotFoundError{Key: txHash}
+	}
+
+	var row rethinkTransaction
+	if err := res.One(&row); err != nil {
+		return nil, err
+	}
+	return fromRethinkTransaction(&row), nil
+}
+
 func (db *RethinkBlockchainDB) WriteBlock(b *Block) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -290,6 +443,24 @@ func (db *RethinkBlockchainDB) GetBlocks(blockIds [][]byte) ([]*Block, error) {
 	return fromRethinkBlocks(rows), nil
 }
 
+func (db *RethinkBlockchainDB) UpdateBlockState(blockId []byte, state int) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.blockTable().Get(blockId).Update(map[string]interface{}{
+		"state": state,
+	}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+
+	if res.Skipped > 0 {
+		return errors.New(fmt.Sprintf("Block not found %v\n", blockId))
+	}
+
+	return nil
+}
+
 func (db *RethinkBlockchainDB) GetOldestBlocks(start int64, limit int) ([]*Block, error) {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -311,6 +482,96 @@ func (db *RethinkBlockchainDB) GetOldestBlocks(start int64, limit int) ([]*Block
 	return fromRethinkBlocks(rows), nil
 }
 
+func (db *RethinkBlockchainDB) GetBlocksAfter(height int64, blockId []byte,
+	limit int) ([]*Block, error) {
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var left interface{} = r.MinVal
+	if blockId != nil {
+		left = blockId
+	}
+	res, err := db.blockTable().Between(
+		[]interface{}{int64ToBytes(height), left},
+		[]interface{}{r.MaxVal, r.MaxVal},
+		r.BetweenOpts{Index: "height__id", LeftBound: "open"},
+	).OrderBy(r.OrderByOpts{Index: "height__id"}).Limit(limit).Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*rethinkBlock
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	return fromRethinkBlocks(rows), nil
+}
+
+func (db *RethinkBlockchainDB) GetMaxBlockHeight() (int64, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.blockTable().OrderBy(r.OrderByOpts{
+		Index: r.Desc("height__id"),
+	}).Limit(1).Run(db.session)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []*rethinkBlock
+	if err := res.All(&rows); err != nil {
+		return 0, err
+	}
+
+	if len(rows) == 0 {
+		return -1, nil
+	}
+	return bytesToInt64(rows[0].Height), nil
+}
+
+// Walks the blocks down from the highest one, which only passes the few blocks at the top of the
+// blockchain that are still in the given state.
+func (db *RethinkBlockchainDB) GetMaxBlockHeightNotInState(state int) (int64, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.blockTable().OrderBy(r.OrderByOpts{
+		Index: r.Desc("height__id"),
+	}).Filter(r.Row.Field("state").Ne(state)).Limit(1).Run(db.session)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []*rethinkBlock
+	if err := res.All(&rows); err != nil {
+		return 0, err
+	}
+
+	if len(rows) == 0 {
+		return -1, nil
+	}
+	return bytesToInt64(rows[0].Height), nil
+}
+
+func (db *RethinkBlockchainDB) GetTransactionBlocks(txHash []byte) ([]*Block, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.blockTable().GetAllByIndex("transactions", txHash).Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*rethinkBlock
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	return fromRethinkBlocks(rows), nil
+}
+
 type rethinkOutputRes struct {
 	Block       *rethinkBlock       `gorethink:"block"`
 	Transaction *rethinkTransaction `gorethink:"transaction"`
@@ -354,8 +615,9 @@ func (db *RethinkBlockchainDB) GetOutputs(outputIds [][]byte) ([]*OutputRes, err
 }
 
 type rethinkInputRes struct {
-	Block *rethinkBlock `gorethink:"block"`
-	Input *rethinkInput `gorethink:"input"`
+	Block  *rethinkBlock `gorethink:"block"`
+	TxHash []byte        `gorethink:"tx_hash"`
+	Input  *rethinkInput `gorethink:"input"`
 }
 
 func (db *RethinkBlockchainDB) GetInputsByOutput(outputIds [][]byte) ([]*InputRes, error) {
@@ -373,8 +635,9 @@ func (db *RethinkBlockchainDB) GetInputsByOutput(outputIds [][]byte) ([]*InputRe
 		return block.Field("transactions").ConcatMap(func(tx r.Term) interface{} {
 			return tx.Field("inputs").Map(func(input r.Term) interface{} {
 				return map[string]interface{}{
-					"block": block.Without("transactions"),
-					"input": input,
+					"block":   block.Without("transactions"),
+					"tx_hash": tx.Field("id"),
+					"input":   input,
 				}
 			})
 		})
@@ -448,6 +711,150 @@ func (db *RethinkBlockchainDB) GetRecentVotes(pubKey []byte, limit int) ([]*Vote
 	return fromRethinkVotes(rows), nil
 }
 
+func (db *RethinkBlockchainDB) GetBlockVotes(blockId []byte) ([]*Vote, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.voteTable().GetAllByIndex("next_block", blockId).Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*rethinkVote
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	return fromRethinkVotes(rows), nil
+}
+
+func (db *RethinkBlockchainDB) WriteRejections(rejs []*Rejection) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	rethinkRejs := make([]*rethinkRejection, len(rejs))
+	for i, rej := range rejs {
+		rethinkRejs[i] = newRethinkRejection(rej)
+	}
+
+	_, err := db.rejectionTable().Insert(rethinkRejs, r.InsertOpts{
+		Conflict: "replace",
+	}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *RethinkBlockchainDB) GetRejection(txHash []byte) (*Rejection, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.rejectionTable().Get(txHash).Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+	if res.IsNil() {
+		return nil, &NotFoundError{Key: txHash}
+	}
+
+	var row rethinkRejection
+	if err := res.One(&row); err != nil {
+		return nil, err
+	}
+	return fromRethinkRejection(&row), nil
+}
+
+func (db *RethinkBlockchainDB) DeleteRejections(before int64) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	_, err := db.rejectionTable().Between(r.MinVal, int64ToBytes(before), r.BetweenOpts{
+		Index: "rejected_at",
+	}).Delete().RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *RethinkBlockchainDB) WriteVoteOffenses(offenses []*VoteOffense) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	rethinkOffenses := make([]*rethinkVoteOffense, len(offenses))
+	for i, o := range offenses {
+		rethinkOffenses[i] = newRethinkVoteOffense(o)
+	}
+
+	_, err := db.offenseTable().Insert(rethinkOffenses, r.InsertOpts{
+		Conflict: "replace",
+	}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *RethinkBlockchainDB) GetVoteOffenses(voter []byte) ([]*VoteOffense, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.offenseTable().GetAllByIndex("voter", voter).Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*rethinkVoteOffense
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	offenses := make([]*VoteOffense, len(rows))
+	for i, row := range rows {
+		offenses[i] = fromRethinkVoteOffense(row)
+	}
+	return offenses, nil
+}
+
+func (db *RethinkBlockchainDB) WriteHeartbeat(hb *Heartbeat) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	_, err := db.heartbeatTable().Insert(newRethinkHeartbeat(hb), r.InsertOpts{
+		Conflict: "replace",
+	}).RunWrite(db.session)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *RethinkBlockchainDB) GetHeartbeats() ([]*Heartbeat, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	res, err := db.heartbeatTable().Run(db.session)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*rethinkHeartbeat
+	if err := res.All(&rows); err != nil {
+		return nil, err
+	}
+
+	hbs := make([]*Heartbeat, len(rows))
+	for i, row := range rows {
+		hbs[i] = fromRethinkHeartbeat(row)
+	}
+	return hbs, nil
+}
+
 // ----------------
 // Changefeed stuff
 // ----------------
@@ -481,6 +888,10 @@ func (cf *RethinkTransactionChangefeed) Next(res *TransactionChangefeedRes) bool
 	return changed
 }
 
+func (cf *RethinkTransactionChangefeed) Close() error {
+	return cf.cursor.Close()
+}
+
 func (db *RethinkBlockchainDB) GetAssignedTransactionChangefeed(
 	pubKey []byte) (TransactionChangefeed, error) {
 
@@ -523,6 +934,10 @@ func (cf *RethinkBlockChangefeed) Next(res *BlockChangefeedRes) bool {
 	return changed
 }
 
+func (cf *RethinkBlockChangefeed) Close() error {
+	return cf.cursor.Close()
+}
+
 func (db *RethinkBlockchainDB) GetBlockChangefeed() (BlockChangefeed, error) {
 	res, err := db.blockTable().Changes().Run(db.session)
 	if err != nil {
@@ -561,6 +976,10 @@ func (cf *RethinkVoteChangefeed) Next(res *VoteChangefeedRes) bool {
 	return changed
 }
 
+func (cf *RethinkVoteChangefeed) Close() error {
+	return cf.cursor.Close()
+}
+
 func (db *RethinkBlockchainDB) GetVoteChangefeed() (VoteChangefeed, error) {
 	res, err := db.voteTable().Changes().Run(db.session)
 	if err != nil {
@@ -586,6 +1005,18 @@ func (db *RethinkBlockchainDB) voteTable() r.Term {
 	return r.DB(db.database).Table(rethinkVoteName)
 }
 
+func (db *RethinkBlockchainDB) rejectionTable() r.Term {
+	return r.DB(db.database).Table(rethinkRejectName)
+}
+
+func (db *RethinkBlockchainDB) offenseTable() r.Term {
+	return r.DB(db.database).Table(rethinkOffenseName)
+}
+
+func (db *RethinkBlockchainDB) heartbeatTable() r.Term {
+	return r.DB(db.database).Table(rethinkBeatName)
+}
+
 func newRethinkPartialCell(cell *Cell) *rethinkPartialCell {
 	var verId []byte = nil
 	if cell.VerId != nil {
@@ -608,6 +1039,47 @@ func fromRethinkPartialCell(cell *rethinkPartialCell) *Cell {
 	}
 }
 
+// Reads back the transactions of a batch insert that failed for some of them, since the response
+// only has the number of failures. Returns BatchWriteError with an error for every transaction
+// that is not in backlog table as it was written. If reading fails, the whole batch is treated as
+// failed. Assumes that the db lock is held.
+func (db *RethinkBlockchainDB) getFailedTransactionWrites(rethinkTxs []*rethinkTransaction,
+	firstError string) error {
+
+	batchErr := errors.New(fmt.Sprintf("Failed to write %d transactions: %s\n", len(rethinkTxs),
+		firstError))
+
+	ids := make([]interface{}, len(rethinkTxs))
+	for i, rethinkTx := range rethinkTxs {
+		ids[i] = rethinkTx.Hash
+	}
+	res, err := db.backlogTable().GetAll(ids...).Run(db.session)
+	if err != nil {
+		return batchErr
+	}
+	var rows []*rethinkTransaction
+	if err := res.All(&rows); err != nil {
+		return batchErr
+	}
+
+	written := make(map[string]*rethinkTransaction)
+	for _, row := range rows {
+		written[string(row.Hash)] = row
+	}
+
+	errs := make([]error, len(rethinkTxs))
+	for i, rethinkTx := range rethinkTxs {
+		row, ok := written[string(rethinkTx.Hash)]
+		if !ok || !bytes.Equal(row.AssignedTo, rethinkTx.AssignedTo) ||
+			!bytes.Equal(row.AssignedAt, rethinkTx.AssignedAt) {
+
+			errs[i] = errors.New(fmt.Sprintf("Failed to write transaction %x: %s\n",
+				rethinkTx.Hash, firstError))
+		}
+	}
+	return &BatchWriteError{Errors: errs}
+}
+
 func newRethinkTransaction(tx *Transaction) *rethinkTransaction {
 	var (
 		assignedAt []byte                         = nil
@@ -742,7 +1214,13 @@ func fromRethinkInput(in *rethinkInput) *Input {
 }
 
 func newRethinkBlock(b *Block) *rethinkBlock {
-	var createdAt []byte = nil
+	var (
+		height    []byte = nil
+		createdAt []byte = nil
+	)
+	if b.Height != nil {
+		height = int64ToBytes(b.Height.Int64())
+	}
 	if b.CreatedAt != nil {
 		createdAt = int64ToBytes(b.CreatedAt.Int64())
 	}
@@ -758,6 +1236,7 @@ func newRethinkBlock(b *Block) *rethinkBlock {
 	return &rethinkBlock{
 		Hash:         b.Hash,
 		Transactions: txs,
+		Height:       height,
 		CreatedAt:    createdAt,
 		Creator:      b.Creator,
 		Sig:          b.Sig,
@@ -767,7 +1246,13 @@ func newRethinkBlock(b *Block) *rethinkBlock {
 }
 
 func fromRethinkBlock(b *rethinkBlock) *Block {
-	var createdAt *big.Int = nil
+	var (
+		height    *big.Int = nil
+		createdAt *big.Int = nil
+	)
+	if b.Height != nil && len(b.Height) == 8 {
+		height = big.NewInt(bytesToInt64(b.Height))
+	}
 	if b.CreatedAt != nil && len(b.CreatedAt) == 8 {
 		createdAt = big.NewInt(bytesToInt64(b.CreatedAt))
 	}
@@ -783,6 +1268,7 @@ func fromRethinkBlock(b *rethinkBlock) *Block {
 	return &Block{
 		Hash:         b.Hash,
 		Transactions: txs,
+		Height:       height,
 		CreatedAt:    createdAt,
 		Creator:      b.Creator,
 		Sig:          b.Sig,
@@ -841,6 +1327,94 @@ func fromRethinkVotes(rethinkVs []*rethinkVote) []*Vote {
 	return vs
 }
 
+func newRethinkRejection(rej *Rejection) *rethinkRejection {
+	var rejectedAt []byte = nil
+	if rej.RejectedAt != nil {
+		rejectedAt = int64ToBytes(rej.RejectedAt.Int64())
+	}
+
+	return &rethinkRejection{
+		TxHash:     rej.TxHash,
+		ErrorType:  rej.ErrorType,
+		Message:    rej.Message,
+		Node:       rej.Node,
+		RejectedAt: rejectedAt,
+	}
+}
+
+func fromRethinkRejection(rej *rethinkRejection) *Rejection {
+	var rejectedAt *big.Int = nil
+	if rej.RejectedAt != nil && len(rej.RejectedAt) == 8 {
+		rejectedAt = big.NewInt(bytesToInt64(rej.RejectedAt))
+	}
+
+	return &Rejection{
+		TxHash:     rej.TxHash,
+		ErrorType:  rej.ErrorType,
+		Message:    rej.Message,
+		Node:       rej.Node,
+		RejectedAt: rejectedAt,
+	}
+}
+
+func newRethinkVoteOffense(o *VoteOffense) *rethinkVoteOffense {
+	var recordedAt []byte = nil
+	if o.RecordedAt != nil {
+		recordedAt = int64ToBytes(o.RecordedAt.Int64())
+	}
+
+	return &rethinkVoteOffense{
+		VoteHash:   o.VoteHash,
+		Voter:      o.Voter,
+		BlockId:    o.BlockId,
+		ErrorType:  o.ErrorType,
+		Message:    o.Message,
+		Node:       o.Node,
+		RecordedAt: recordedAt,
+	}
+}
+
+func fromRethinkVoteOffense(o *rethinkVoteOffense) *VoteOffense {
+	var recordedAt *big.Int = nil
+	if o.RecordedAt != nil && len(o.RecordedAt) == 8 {
+		recordedAt = big.NewInt(bytesToInt64(o.RecordedAt))
+	}
+
+	return &VoteOffense{
+		VoteHash:   o.VoteHash,
+		Voter:      o.Voter,
+		BlockId:    o.BlockId,
+		ErrorType:  o.ErrorType,
+		Message:    o.Message,
+		Node:       o.Node,
+		RecordedAt: recordedAt,
+	}
+}
+
+func newRethinkHeartbeat(hb *Heartbeat) *rethinkHeartbeat {
+	var beatAt []byte = nil
+	if hb.BeatAt != nil {
+		beatAt = int64ToBytes(hb.BeatAt.Int64())
+	}
+
+	return &rethinkHeartbeat{
+		Node:   hb.Node,
+		BeatAt: beatAt,
+	}
+}
+
+func fromRethinkHeartbeat(hb *rethinkHeartbeat) *Heartbeat {
+	var beatAt *big.Int = nil
+	if hb.BeatAt != nil && len(hb.BeatAt) == 8 {
+		beatAt = big.NewInt(bytesToInt64(hb.BeatAt))
+	}
+
+	return &Heartbeat{
+		Node:   hb.Node,
+		BeatAt: beatAt,
+	}
+}
+
 func fromRethinkOutputRes(rows []*rethinkOutputRes) []*OutputRes {
 	newRows := make([]*OutputRes, len(rows))
 	for i, row := range rows {
@@ -857,8 +1431,9 @@ func fromRethinkInputRes(rows []*rethinkInputRes) []*InputRes {
 	newRows := make([]*InputRes, len(rows))
 	for i, row := range rows {
 		newRows[i] = &InputRes{
-			Block: fromRethinkBlock(row.Block),
-			Input: fromRethinkInput(row.Input),
+			Block:  fromRethinkBlock(row.Block),
+			TxHash: row.TxHash,
+			Input:  fromRethinkInput(row.Input),
 		}
 	}
 	return newRows
@@ -44,6 +44,28 @@ func TestRethinkWriteTransaction(t *testing.T) {
 	assert.Equal(t, tx, txs[0])
 }
 
+func TestRethinkWriteTransactions(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBacklog(db)
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+
+	err := db.WriteTransactions([]*Transaction{tx, otherTx})
+	assert.Nil(t, err)
+
+	// Writing again replaces the transactions
+	tx.AssignedTo = []byte{69}
+	err = db.WriteTransactions([]*Transaction{tx})
+	assert.Nil(t, err)
+
+	txs := rethinkGetBacklog(t, db)
+	assert.Equal(t, 2, len(txs))
+	expected := []*Transaction{tx, otherTx}
+	assert.Subset(t, expected, txs)
+	assert.Subset(t, txs, expected)
+}
+
 func TestRethinkGetAssignedTransactions(t *testing.T) {
 	db := getRethinkDB(t)
 	defer rethinkDeleteBacklog(db)
@@ -62,6 +84,24 @@ func TestRethinkGetAssignedTransactions(t *testing.T) {
 	assert.Equal(t, otherTx, txs[0])
 }
 
+func TestRethinkCountAssignedTransactions(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBacklog(db)
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+	thirdTx := getTestTransaction()
+	thirdTx.Hash = []byte{23}
+	thirdTx.AssignedTo = []byte{69}
+
+	err := db.WriteTransactions([]*Transaction{tx, otherTx, thirdTx})
+	assert.Nil(t, err)
+
+	counts, err := db.CountAssignedTransactions()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{string(tx.AssignedTo): 2, string([]byte{69}): 1}, counts)
+}
+
 func TestRethinkGetStaleTransactions(t *testing.T) {
 	db := getRethinkDB(t)
 	defer rethinkDeleteBacklog(db)
@@ -113,6 +153,27 @@ func TestRethinkDeleteTransactions(t *testing.T) {
 	assert.Equal(t, otherTx, txs[0])
 }
 
+func TestRethinkGetBacklogTransaction(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBacklog(db)
+	tx := getTestTransaction()
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+
+	rethinkWriteToBacklog(t, db, []*Transaction{tx, otherTx})
+
+	res, err := db.GetBacklogTransaction(otherTx.Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, otherTx, res)
+}
+
+func TestRethinkGetBacklogTransactionNotFound(t *testing.T) {
+	db := getRethinkDB(t)
+
+	_, err := db.GetBacklogTransaction([]byte{22})
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
 func TestRethinkWriteBlock(t *testing.T) {
 	db := getRethinkDB(t)
 	defer rethinkDeleteBlocks(db)
@@ -161,6 +222,29 @@ func TestRethinkGetBlocksNotFound(t *testing.T) {
 	assert.IsType(t, errors.New(""), err)
 }
 
+func TestRethinkUpdateBlockState(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBlocks(db)
+	b := getTestBlock()
+
+	rethinkWriteToBlock(t, db, []*Block{b})
+
+	err := db.UpdateBlockState(b.Hash, 2)
+	assert.Nil(t, err)
+
+	bs := rethinkGetBlocks(t, db)
+
+	assert.Equal(t, 1, len(bs))
+	assert.Equal(t, 2, bs[0].State)
+}
+
+func TestRethinkUpdateBlockStateNotFound(t *testing.T) {
+	db := getRethinkDB(t)
+
+	err := db.UpdateBlockState([]byte("first"), 2)
+	assert.IsType(t, errors.New(""), err)
+}
+
 func TestRethinkGetOldestBlocks(t *testing.T) {
 	db := getRethinkDB(t)
 	defer rethinkDeleteBlocks(db)
@@ -198,6 +282,115 @@ func TestRethinkGetOldestBlocksEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(res))
 }
 
+func TestRethinkGetBlocksAfter(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBlocks(db)
+	first := getTestBlock()
+	second := getTestBlock()
+	third := getTestBlock()
+	fourth := getTestBlock()
+
+	first.Height = big.NewInt(0)
+	second.Height = big.NewInt(1)
+	third.Height = big.NewInt(1)
+	fourth.Height = big.NewInt(2)
+
+	first.Hash = []byte("a")
+	second.Hash = []byte("c")
+	third.Hash = []byte("b")
+	fourth.Hash = []byte("d")
+
+	rethinkWriteToBlock(t, db, []*Block{first, second, third, fourth})
+
+	res, err := db.GetBlocksAfter(-1, nil, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Block{first, third, second, fourth}, res)
+
+	res, err = db.GetBlocksAfter(1, nil, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Block{third, second}, res)
+
+	res, err = db.GetBlocksAfter(1, []byte("b"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Block{second, fourth}, res)
+}
+
+func TestRethinkGetMaxBlockHeight(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBlocks(db)
+
+	height, err := db.GetMaxBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), height)
+
+	first := getTestBlock()
+	second := getTestBlock()
+	first.Height = big.NewInt(3)
+	second.Height = big.NewInt(7)
+	first.Hash = []byte("first")
+	second.Hash = []byte("second")
+	rethinkWriteToBlock(t, db, []*Block{first, second})
+
+	height, err = db.GetMaxBlockHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), height)
+}
+
+func TestRethinkGetMaxBlockHeightNotInState(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBlocks(db)
+
+	height, err := db.GetMaxBlockHeightNotInState(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), height)
+
+	first := getTestBlock()
+	second := getTestBlock()
+	first.Height = big.NewInt(3)
+	second.Height = big.NewInt(7)
+	second.State = 0
+	first.Hash = []byte("first")
+	second.Hash = []byte("second")
+	rethinkWriteToBlock(t, db, []*Block{first, second})
+
+	height, err = db.GetMaxBlockHeightNotInState(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), height)
+
+	height, err = db.GetMaxBlockHeightNotInState(1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), height)
+}
+
+func TestRethinkGetTransactionBlocks(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteBlocks(db)
+	first := getTestBlock()
+	second := getTestBlock()
+	third := getTestBlock()
+
+	otherTx := getTestTransaction()
+	otherTx.Hash = []byte{22}
+	first.Hash = []byte("first")
+	second.Hash = []byte("second")
+	second.Transactions = append(second.Transactions, otherTx)
+	third.Hash = []byte("third")
+	third.Transactions = []*Transaction{otherTx}
+
+	rethinkWriteToBlock(t, db, []*Block{first, second, third})
+
+	res, err := db.GetTransactionBlocks(getTestTransaction().Hash)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	expected := []*Block{first, second}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+
+	res, err = db.GetTransactionBlocks([]byte{23})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
 func TestRethinkGetOutputs(t *testing.T) {
 	db := getRethinkDB(t)
 	defer rethinkDeleteBlocks(db)
@@ -228,8 +421,9 @@ func TestRethinkGetInputsByOutput(t *testing.T) {
 	bCopy := b.Clone()
 	bCopy.Transactions = nil
 	expected := []*InputRes{&InputRes{
-		Block: bCopy,
-		Input: b.Transactions[0].Inputs[0].Clone(),
+		Block:  bCopy,
+		TxHash: b.Transactions[0].Hash,
+		Input:  b.Transactions[0].Inputs[0].Clone(),
 	}}
 	actual, err := db.GetInputsByOutput([][]byte{[]byte("output1")})
 	assert.Nil(t, err)
@@ -311,10 +505,170 @@ func TestRethinkGetRecentVotesEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(res))
 }
 
+func TestRethinkGetBlockVotes(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteVotes(db)
+	first := getTestVote()
+	second := getTestVote()
+	third := getTestVote()
+
+	second.Voter = []byte{43}
+	third.NextBlock = []byte{243}
+
+	first.Hash = []byte("first")
+	second.Hash = []byte("second")
+	third.Hash = []byte("third")
+
+	rethinkWriteToVote(t, db, []*Vote{first, second, third})
+
+	res, err := db.GetBlockVotes([]byte{242})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	expected := []*Vote{first, second}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+}
+
 // ------------
 // Test Helpers
 // ------------
 
+func TestRethinkWriteRejections(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteRejections(db)
+	rej := getTestRejection()
+	otherRej := getTestRejection()
+	otherRej.TxHash = []byte{22}
+
+	err := db.WriteRejections([]*Rejection{rej, otherRej})
+	assert.Nil(t, err)
+
+	// Newer rejection of the same transaction replaces the old one
+	newRej := getTestRejection()
+	newRej.Message = "still bad"
+	err = db.WriteRejections([]*Rejection{newRej})
+	assert.Nil(t, err)
+
+	rejs := rethinkGetRejections(t, db)
+	assert.Equal(t, 2, len(rejs))
+	expected := []*Rejection{newRej, otherRej}
+	assert.Subset(t, expected, rejs)
+	assert.Subset(t, rejs, expected)
+}
+
+func TestRethinkGetRejection(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteRejections(db)
+	rej := getTestRejection()
+
+	err := db.WriteRejections([]*Rejection{rej})
+	assert.Nil(t, err)
+
+	res, err := db.GetRejection(rej.TxHash)
+	assert.Nil(t, err)
+	assert.Equal(t, rej, res)
+}
+
+func TestRethinkGetRejectionNotFound(t *testing.T) {
+	db := getRethinkDB(t)
+
+	_, err := db.GetRejection([]byte{22})
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestRethinkDeleteRejections(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteRejections(db)
+	first := getTestRejection()
+	second := getTestRejection()
+	third := getTestRejection()
+
+	first.TxHash = []byte("first")
+	second.TxHash = []byte("second")
+	third.TxHash = []byte("third")
+	first.RejectedAt = big.NewInt(69)
+	second.RejectedAt = big.NewInt(70)
+	third.RejectedAt = big.NewInt(71)
+
+	err := db.WriteRejections([]*Rejection{first, second, third})
+	assert.Nil(t, err)
+
+	err = db.DeleteRejections(70)
+	assert.Nil(t, err)
+
+	rejs := rethinkGetRejections(t, db)
+	assert.Equal(t, 2, len(rejs))
+	expected := []*Rejection{second, third}
+	assert.Subset(t, expected, rejs)
+	assert.Subset(t, rejs, expected)
+}
+
+func TestRethinkWriteVoteOffenses(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteOffenses(db)
+	o := getTestVoteOffense()
+	otherO := getTestVoteOffense()
+	otherO.VoteHash = []byte{22}
+
+	err := db.WriteVoteOffenses([]*VoteOffense{o, otherO})
+	assert.Nil(t, err)
+
+	// Newer offense of the same vote replaces the old one
+	newO := getTestVoteOffense()
+	newO.Message = "still forged"
+	err = db.WriteVoteOffenses([]*VoteOffense{newO})
+	assert.Nil(t, err)
+
+	res, err := db.GetVoteOffenses(o.Voter)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	expected := []*VoteOffense{newO, otherO}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+}
+
+func TestRethinkGetVoteOffenses(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteOffenses(db)
+	o := getTestVoteOffense()
+	otherO := getTestVoteOffense()
+	otherO.VoteHash = []byte{22}
+	otherO.Voter = []byte{23}
+
+	err := db.WriteVoteOffenses([]*VoteOffense{o, otherO})
+	assert.Nil(t, err)
+
+	res, err := db.GetVoteOffenses(o.Voter)
+	assert.Nil(t, err)
+	assert.Equal(t, []*VoteOffense{o}, res)
+
+	res, err = db.GetVoteOffenses([]byte{24})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+}
+
+func TestRethinkHeartbeats(t *testing.T) {
+	db := getRethinkDB(t)
+	defer rethinkDeleteHeartbeats(db)
+	hb := getTestHeartbeat()
+	otherHb := getTestHeartbeat()
+	otherHb.Node = []byte{23}
+
+	assert.Nil(t, db.WriteHeartbeat(hb))
+	assert.Nil(t, db.WriteHeartbeat(otherHb))
+
+	// Newer heartbeat of the same node replaces the old one
+	newHb := getTestHeartbeat()
+	newHb.BeatAt = big.NewInt(73)
+	assert.Nil(t, db.WriteHeartbeat(newHb))
+
+	res, err := db.GetHeartbeats()
+	assert.Nil(t, err)
+	expected := []*Heartbeat{newHb, otherHb}
+	assert.Subset(t, expected, res)
+	assert.Subset(t, res, expected)
+}
+
 func TestRethinkTransactionMapper(t *testing.T) {
 	tx := getTestTransaction()
 	assert.Equal(t, tx, fromRethinkTransaction(newRethinkTransaction(tx)))
@@ -330,6 +684,21 @@ func TestRethinkVoteMapper(t *testing.T) {
 	assert.Equal(t, v, fromRethinkVote(newRethinkVote(v)))
 }
 
+func TestRethinkRejectionMapper(t *testing.T) {
+	rej := getTestRejection()
+	assert.Equal(t, rej, fromRethinkRejection(newRethinkRejection(rej)))
+}
+
+func TestRethinkVoteOffenseMapper(t *testing.T) {
+	o := getTestVoteOffense()
+	assert.Equal(t, o, fromRethinkVoteOffense(newRethinkVoteOffense(o)))
+}
+
+func TestRethinkHeartbeatMapper(t *testing.T) {
+	hb := getTestHeartbeat()
+	assert.Equal(t, hb, fromRethinkHeartbeat(newRethinkHeartbeat(hb)))
+}
+
 // -------
 // Helpers
 // -------
@@ -406,6 +775,21 @@ func rethinkGetVotes(t *testing.T, db *RethinkBlockchainDB) []*Vote {
 	return vs
 }
 
+func rethinkGetRejections(t *testing.T, db *RethinkBlockchainDB) []*Rejection {
+	cur, err := db.rejectionTable().Run(db.session)
+	assert.Nil(t, err)
+
+	var res []*rethinkRejection
+	err = cur.All(&res)
+	assert.Nil(t, err)
+
+	rejs := make([]*Rejection, len(res))
+	for i, rej := range res {
+		rejs[i] = fromRethinkRejection(rej)
+	}
+	return rejs
+}
+
 func rethinkDeleteBacklog(db *RethinkBlockchainDB) {
 	db.backlogTable().Delete().RunWrite(db.session)
 }
@@ -417,3 +801,15 @@ func rethinkDeleteBlocks(db *RethinkBlockchainDB) {
 func rethinkDeleteVotes(db *RethinkBlockchainDB) {
 	db.voteTable().Delete().RunWrite(db.session)
 }
+
+func rethinkDeleteRejections(db *RethinkBlockchainDB) {
+	db.rejectionTable().Delete().RunWrite(db.session)
+}
+
+func rethinkDeleteOffenses(db *RethinkBlockchainDB) {
+	db.offenseTable().Delete().RunWrite(db.session)
+}
+
+func rethinkDeleteHeartbeats(db *RethinkBlockchainDB) {
+	db.heartbeatTable().Delete().RunWrite(db.session)
+}
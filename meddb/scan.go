@@ -0,0 +1,171 @@
+package meddb
+
+import (
+	"bytes"
+	"math/big"
+)
+
+// Number of rows in a page of a scan, unless the op asks for another page size
+const DEFAULT_SCAN_PAGE_SIZE = 100
+
+type ScanOp struct {
+	startRow, endRow []byte
+	prefix           []byte
+	colIds           [][]byte // nil selects all columns
+	limit            uint32
+	pageSize         uint32
+	pageToken        []byte
+	history          bool // Also return tombstones and the cells they delete
+}
+
+// Row read by a scan
+type ScanRow struct {
+	RowId []byte
+	Cols  map[string][]*Cell
+}
+
+// Page of rows read by a scan
+type ScanRes struct {
+	Rows          []*ScanRow // Sorted by row id
+	NextPageToken []byte     // nil on the last page
+}
+
+// Scans all rows of the table, reading all versions of the given columns. nil colIds reads all
+// columns of every row.
+func NewScanOp(colIds [][]byte) *ScanOp {
+	return &ScanOp{colIds: colIds, pageSize: DEFAULT_SCAN_PAGE_SIZE}
+}
+
+// Only scans the rows from startRow up to, but not including, endRow. nil leaves a side open.
+func (op *ScanOp) WithRange(startRow, endRow []byte) *ScanOp {
+	op.startRow = startRow
+	op.endRow = endRow
+	return op
+}
+
+// Only scans the rows that start with the prefix.
+func (op *ScanOp) WithPrefix(prefix []byte) *ScanOp {
+	op.prefix = prefix
+	return op
+}
+
+// Reads at most this many of the newest versions of each column, 0 reads all.
+func (op *ScanOp) WithLimit(limit uint32) *ScanOp {
+	op.limit = limit
+	return op
+}
+
+// Reads at most this many rows in a page.
+func (op *ScanOp) WithPageSize(pageSize uint32) *ScanOp {
+	op.pageSize = pageSize
+	return op
+}
+
+// Continues the scan at the page that the token of the previous page points to.
+func (op *ScanOp) WithPageToken(pageToken []byte) *ScanOp {
+	op.pageToken = pageToken
+	return op
+}
+
+// Makes the scan return deleted cells and the tombstones that deleted them as well.
+func (op *ScanOp) WithHistory() *ScanOp {
+	op.history = true
+	return op
+}
+
+// -------
+// Helpers
+// -------
+
+// Returns the row id that the page starts at and the row id that the scan ends before, nil if the
+// scan has no end. Both the range and the prefix narrow down the rows.
+func (op *ScanOp) rowRange() ([]byte, []byte) {
+	start, end := op.startRow, op.endRow
+	if op.prefix != nil && bytes.Compare(op.prefix, start) > 0 {
+		start = op.prefix
+	}
+	if op.pageToken != nil && bytes.Compare(op.pageToken, start) > 0 {
+		start = op.pageToken
+	}
+	if prefixEnd := prefixEnd(op.prefix); prefixEnd != nil {
+		if end == nil || bytes.Compare(prefixEnd, end) < 0 {
+			end = prefixEnd
+		}
+	}
+	return start, end
+}
+
+// Adds the row to the page unless the scan returns none of its cells. Rows that are left out do
+// not take up room in the page, so every page but the last is full. Returns false once the page is
+// full, after pointing NextPageToken at the row that the next page starts at.
+func (res *ScanRes) addRow(op *ScanOp, rowId []byte, cols map[string][]*Cell) bool {
+	cols = op.selectCells(cols)
+	if len(cols) == 0 {
+		return true
+	}
+	if uint32(len(res.Rows)) >= op.pageSize {
+		res.NextPageToken = rowId
+		return false
+	}
+	res.Rows = append(res.Rows, &ScanRow{RowId: rowId, Cols: cols})
+	return true
+}
+
+// Returns whether the row range of the scan is empty.
+func isEmptyRange(start, end []byte) bool {
+	return end != nil && bytes.Compare(start, end) >= 0
+}
+
+// Returns the smallest row id that is larger than all row ids that start with the prefix, nil if
+// there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+func (op *ScanOp) selectsCol(colId string) bool {
+	if op.colIds == nil {
+		return true
+	}
+	for _, selected := range op.colIds {
+		if string(selected) == colId {
+			return true
+		}
+	}
+	return false
+}
+
+// Picks the cells that the scan returns out of all cells of a row. Cells of every column have to be
+// sorted by decreasing verId. Returns the same cells that a GetOpLimit on the row would.
+func (op *ScanOp) selectCells(cols map[string][]*Cell) map[string][]*Cell {
+	res := make(map[string][]*Cell)
+	deletedAt := make(map[string]*big.Int)
+	for colId, col := range cols {
+		if !op.selectsCol(colId) || len(col) == 0 {
+			continue
+		}
+
+		selected := make([]*Cell, 0, len(col))
+		for _, cell := range col {
+			if _, ok := deletedAt[colId]; !ok && cell.Tombstone {
+				deletedAt[colId] = cell.VerId
+			}
+			if op.limit == 0 || uint32(len(selected)) < op.limit {
+				selected = append(selected, cell.Clone())
+			}
+		}
+		res[colId] = selected
+	}
+
+	if op.history {
+		return res
+	}
+	return hideDeleted(res, deletedAt)
+}
@@ -0,0 +1,53 @@
+package meddb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("ac"), prefixEnd([]byte("ab")))
+	assert.Equal(t, []byte{1, 3}, prefixEnd([]byte{1, 2, 0xff, 0xff}))
+	assert.Nil(t, prefixEnd([]byte{0xff}))
+	assert.Nil(t, prefixEnd(nil))
+}
+
+func TestScanOpRowRange(t *testing.T) {
+	start, end := NewScanOp(nil).rowRange()
+	assert.Nil(t, start)
+	assert.Nil(t, end)
+
+	op := NewScanOp(nil).WithRange([]byte("a"), []byte("z")).WithPrefix([]byte("b"))
+	start, end = op.rowRange()
+	assert.Equal(t, []byte("b"), start)
+	assert.Equal(t, []byte("c"), end)
+
+	op = NewScanOp(nil).WithRange([]byte("c"), []byte("d")).WithPrefix([]byte("c"))
+	start, end = op.rowRange()
+	assert.Equal(t, []byte("c"), start)
+	assert.Equal(t, []byte("d"), end)
+
+	op = NewScanOp(nil).WithRange([]byte("a"), nil).WithPageToken([]byte("m"))
+	start, end = op.rowRange()
+	assert.Equal(t, []byte("m"), start)
+	assert.Nil(t, end)
+}
+
+func TestScanResAddRow(t *testing.T) {
+	op := NewScanOp(nil).WithPageSize(1)
+	res := &ScanRes{Rows: make([]*ScanRow, 0)}
+	deleted := map[string][]*Cell{"wheels": {NewTombstoneVer(2), NewCellVer(1, []byte("4"))}}
+	cols := map[string][]*Cell{"wheels": {NewCellVer(1, []byte("4"))}}
+
+	// Deleted rows do not take up room in the page
+	assert.True(t, res.addRow(op, []byte("a"), deleted))
+	assert.True(t, res.addRow(op, []byte("b"), nil))
+	assert.True(t, res.addRow(op, []byte("c"), cols))
+	assert.True(t, res.addRow(op, []byte("d"), deleted))
+	assert.Nil(t, res.NextPageToken)
+
+	assert.False(t, res.addRow(op, []byte("e"), cols))
+	assert.Equal(t, []*ScanRow{&ScanRow{RowId: []byte("c"), Cols: cols}}, res.Rows)
+	assert.Equal(t, []byte("e"), res.NextPageToken)
+}
@@ -0,0 +1,185 @@
+package sim
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+var CrashedError = errors.New("Node crashed")
+
+// Wraps the BlockchainDB shared by all nodes to inject faults for a single node.
+// A crashed node cannot write anything and its changefeeds stop, so that the rest of the
+// federation sees it as gone. A byzantine node flips the value of every vote it casts.
+type faultyDB struct {
+	meddb.BlockchainDB
+	crashed   int32
+	byzantine int32
+	// Signs the flipped votes of a byzantine node. Writes to the shared db directly.
+	liar *core.Blockchain
+}
+
+func newFaultyDB(db meddb.BlockchainDB) *faultyDB {
+	return &faultyDB{BlockchainDB: db}
+}
+
+func (db *faultyDB) crash() {
+	atomic.StoreInt32(&db.crashed, 1)
+}
+
+func (db *faultyDB) isCrashed() bool {
+	return atomic.LoadInt32(&db.crashed) == 1
+}
+
+func (db *faultyDB) setByzantine(byzantine bool) {
+	var value int32 = 0
+	if byzantine {
+		value = 1
+	}
+	atomic.StoreInt32(&db.byzantine, value)
+}
+
+func (db *faultyDB) isByzantine() bool {
+	return atomic.LoadInt32(&db.byzantine) == 1
+}
+
+// ------
+// Writes
+// ------
+
+func (db *faultyDB) WriteTransaction(tx *meddb.Transaction) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.WriteTransaction(tx)
+}
+
+func (db *faultyDB) WriteTransactions(txs []*meddb.Transaction) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.WriteTransactions(txs)
+}
+
+func (db *faultyDB) DeleteTransactions(txs []*meddb.Transaction) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.DeleteTransactions(txs)
+}
+
+func (db *faultyDB) WriteBlock(b *meddb.Block) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.WriteBlock(b)
+}
+
+func (db *faultyDB) UpdateBlockState(blockId []byte, state int) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.UpdateBlockState(blockId, state)
+}
+
+func (db *faultyDB) WriteVote(v *meddb.Vote) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	if db.isByzantine() {
+		lie, err := db.liar.BuildVote(
+			core.BytesToHash(v.NextBlock), core.BytesToHash(v.PrevBlock), !v.Value)
+		if err != nil {
+			return err
+		}
+		return db.liar.WriteVote(lie)
+	}
+	return db.BlockchainDB.WriteVote(v)
+}
+
+func (db *faultyDB) WriteRejections(rejs []*meddb.Rejection) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.WriteRejections(rejs)
+}
+
+func (db *faultyDB) DeleteRejections(before int64) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.DeleteRejections(before)
+}
+
+func (db *faultyDB) WriteVoteOffenses(offenses []*meddb.VoteOffense) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.WriteVoteOffenses(offenses)
+}
+
+func (db *faultyDB) WriteHeartbeat(hb *meddb.Heartbeat) error {
+	if db.isCrashed() {
+		return CrashedError
+	}
+	return db.BlockchainDB.WriteHeartbeat(hb)
+}
+
+// -----------
+// Changefeeds
+// -----------
+
+type faultyTransactionChangefeed struct {
+	meddb.TransactionChangefeed
+	db *faultyDB
+}
+
+func (cf *faultyTransactionChangefeed) Next(res *meddb.TransactionChangefeedRes) bool {
+	return !cf.db.isCrashed() && cf.TransactionChangefeed.Next(res) && !cf.db.isCrashed()
+}
+
+func (db *faultyDB) GetAssignedTransactionChangefeed(
+	pubKey []byte) (meddb.TransactionChangefeed, error) {
+
+	cf, err := db.BlockchainDB.GetAssignedTransactionChangefeed(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyTransactionChangefeed{TransactionChangefeed: cf, db: db}, nil
+}
+
+type faultyBlockChangefeed struct {
+	meddb.BlockChangefeed
+	db *faultyDB
+}
+
+func (cf *faultyBlockChangefeed) Next(res *meddb.BlockChangefeedRes) bool {
+	return !cf.db.isCrashed() && cf.BlockChangefeed.Next(res) && !cf.db.isCrashed()
+}
+
+func (db *faultyDB) GetBlockChangefeed() (meddb.BlockChangefeed, error) {
+	cf, err := db.BlockchainDB.GetBlockChangefeed()
+	if err != nil {
+		return nil, err
+	}
+	return &faultyBlockChangefeed{BlockChangefeed: cf, db: db}, nil
+}
+
+type faultyVoteChangefeed struct {
+	meddb.VoteChangefeed
+	db *faultyDB
+}
+
+func (cf *faultyVoteChangefeed) Next(res *meddb.VoteChangefeedRes) bool {
+	return !cf.db.isCrashed() && cf.VoteChangefeed.Next(res) && !cf.db.isCrashed()
+}
+
+func (db *faultyDB) GetVoteChangefeed() (meddb.VoteChangefeed, error) {
+	cf, err := db.BlockchainDB.GetVoteChangefeed()
+	if err != nil {
+		return nil, err
+	}
+	return &faultyVoteChangefeed{VoteChangefeed: cf, db: db}, nil
+}
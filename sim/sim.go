@@ -0,0 +1,290 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/wojtechnology/glacier/common"
+	"github.com/wojtechnology/glacier/core"
+	"github.com/wojtechnology/glacier/crypto"
+	"github.com/wojtechnology/glacier/logging"
+	"github.com/wojtechnology/glacier/loop"
+	"github.com/wojtechnology/glacier/meddb"
+)
+
+const pollWaitMS = 100 // Wait time between checks for block states
+
+var initLoggersOnce sync.Once
+
+// Runs a federation of nodes inside a single process. All nodes share the same memory
+// BlockchainDB, but every node has its own Bigtable that accepted blocks are applied to.
+type Simulator struct {
+	db    *meddb.MemoryBlockchainDB
+	nodes []*SimNode
+}
+
+// A single node of the simulated federation.
+type SimNode struct {
+	Node       *core.Node
+	Blockchain *core.Blockchain
+	Bigtable   *meddb.MemoryBigtable
+	db         *faultyDB
+	errs       []error
+	errsLock   sync.RWMutex
+	started    bool
+	stop       context.CancelFunc
+	stopped    chan error // Receives the result of the server once it stopped
+}
+
+// Creates a federation of n nodes where every node is a voter.
+// Logs of all nodes are discarded, use logging.InitLoggers before to keep them.
+func NewSimulator(n int) (*Simulator, error) {
+	initLoggersOnce.Do(func() {
+		logging.InitLoggers(ioutil.Discard, ioutil.Discard)
+	})
+
+	db, err := meddb.NewMemoryBlockchainDB()
+	if err != nil {
+		return nil, err
+	}
+
+	federation := make([]*core.Node, n)
+	for i := 0; i < n; i++ {
+		priv, err := crypto.NewPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		federation[i] = core.NewNode(priv)
+	}
+
+	s := &Simulator{db: db, nodes: make([]*SimNode, n)}
+	for i, me := range federation {
+		bt, err := meddb.NewMemoryBigtable()
+		if err != nil {
+			return nil, err
+		}
+
+		nodeDB := newFaultyDB(db)
+		nodeDB.liar = core.NewBlockchain(db, bt, me, federation)
+		bc := core.NewBlockchain(nodeDB, bt, me, federation)
+		if err := bc.SetupState(); err != nil {
+			return nil, err
+		}
+
+		s.nodes[i] = &SimNode{Node: me, Blockchain: bc, Bigtable: bt, db: nodeDB}
+	}
+
+	return s, nil
+}
+
+// Returns all nodes of the federation.
+func (s *Simulator) Nodes() []*SimNode {
+	return s.nodes
+}
+
+// Returns the node with the given index.
+func (s *Simulator) NodeAt(i int) *SimNode {
+	return s.nodes[i]
+}
+
+// Starts the loops of all nodes that have not been started yet.
+func (s *Simulator) Start() {
+	for _, n := range s.nodes {
+		n.Start()
+	}
+}
+
+// Stops the loops of all nodes. Returns the first error of the nodes that did not stop in time.
+func (s *Simulator) Stop() error {
+	var firstErr error = nil
+	for _, n := range s.nodes {
+		if err := n.Stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Adds the transaction to the backlog through the first node that has not crashed.
+func (s *Simulator) AddTransaction(tx *core.Transaction) error {
+	for _, n := range s.nodes {
+		if !n.Crashed() {
+			return n.Blockchain.AddTransaction(tx)
+		}
+	}
+	return errors.New("All nodes crashed\n")
+}
+
+// Returns all blocks in the order of the blockchain.
+func (s *Simulator) Blocks() ([]*core.Block, error) {
+	dbBs, err := s.db.GetBlocksAfter(-1, nil, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nodes share the db so any of them can map the blocks
+	bc := core.NewBlockchain(s.db, nil, nil, nil)
+	ids := make([]core.Hash, len(dbBs))
+	for i, dbB := range dbBs {
+		ids[i] = core.BytesToHash(dbB.Hash)
+	}
+	return bc.GetBlocks(ids)
+}
+
+// Returns the errors of all nodes that have not crashed.
+func (s *Simulator) Errors() []error {
+	errs := make([]error, 0)
+	for _, n := range s.nodes {
+		if !n.Crashed() {
+			errs = append(errs, n.Errors()...)
+		}
+	}
+	return errs
+}
+
+// Polls the blocks until cond holds or the timeout in ms passes.
+// Returns the blocks that cond held for.
+func (s *Simulator) WaitForBlocks(timeoutMS int64, cond func([]*core.Block) bool) (
+	[]*core.Block, error) {
+
+	deadline := common.Now() + timeoutMS
+	for {
+		bs, err := s.Blocks()
+		if err != nil {
+			return nil, err
+		}
+		if cond(bs) {
+			return bs, nil
+		}
+		if common.Now() > deadline {
+			return bs, errors.New(fmt.Sprintf("Timed out after %d ms waiting for blocks\n",
+				timeoutMS))
+		}
+		time.Sleep(time.Millisecond * pollWaitMS)
+	}
+}
+
+// Waits until there are at least minBlocks blocks and all blocks are decided.
+// Returns the decided blocks.
+func (s *Simulator) WaitForDecidedBlocks(timeoutMS int64, minBlocks int) ([]*core.Block, error) {
+	return s.WaitForBlocks(timeoutMS, func(bs []*core.Block) bool {
+		if len(bs) < minBlocks {
+			return false
+		}
+		for _, b := range bs {
+			if b.State == core.BLOCK_STATE_UNDECIDED {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Waits until every given transaction is in an ACCEPTED block.
+// Returns the blocks at the time all transactions were accepted.
+func (s *Simulator) WaitForAcceptedTransactions(timeoutMS int64, txs []*core.Transaction) (
+	[]*core.Block, error) {
+
+	return s.WaitForBlocks(timeoutMS, func(bs []*core.Block) bool {
+		accepted := make(map[string]bool)
+		for _, b := range bs {
+			if b.State != core.BLOCK_STATE_ACCEPTED {
+				continue
+			}
+			for _, tx := range b.Transactions {
+				accepted[tx.Hash().String()] = true
+			}
+		}
+		for _, tx := range txs {
+			if !accepted[tx.Hash().String()] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// ---------
+// Node API
+// ---------
+
+// Starts the heartbeat, block, vote, tally, reassign and apply loops of the node, unless they are
+// already running.
+func (n *SimNode) Start() {
+	if n.started {
+		return
+	}
+	n.started = true
+
+	server := loop.NewServer(n.Blockchain)
+	server.AddLoop("heartbeat", loop.HeartbeatLoop)
+	server.AddLoop("reassign", loop.ReassignTransactionsLoop)
+	server.AddLoop("block", loop.AddBlockLoop)
+	server.AddLoop("vote", loop.VoteOnBlocksLoop)
+	server.AddLoop("tally", loop.TallyVotesLoop)
+	server.AddLoop("apply", loop.ApplyBlocksLoop)
+	server.SetErrorHandler(func(err error) {
+		n.errsLock.Lock()
+		n.errs = append(n.errs, err)
+		n.errsLock.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.stop = cancel
+	n.stopped = make(chan error, 1)
+	go func() {
+		n.stopped <- server.Run(ctx)
+	}()
+}
+
+// Stops the loops of the node and waits for them to finish. Unlike a crash, the node finishes
+// what it is doing first.
+func (n *SimNode) Stop() error {
+	if !n.started {
+		return nil
+	}
+	n.started = false
+
+	n.stop()
+	return <-n.stopped
+}
+
+// Crashes the node. It stops writing to the db and stops receiving changes, the loops of the node
+// keep running but cannot affect the rest of the federation anymore.
+func (n *SimNode) Crash() {
+	n.db.crash()
+}
+
+func (n *SimNode) Crashed() bool {
+	return n.db.isCrashed()
+}
+
+// Makes the node vote the opposite of what its validation says. The flipped votes are properly
+// signed, so they cannot be told apart from honest votes.
+func (n *SimNode) SetByzantine(byzantine bool) {
+	n.db.setByzantine(byzantine)
+}
+
+// Shifts all timestamps created by the node by skewMS. Must be called before Start.
+func (n *SimNode) SetClockSkew(skewMS int64) {
+	clock := func() int64 {
+		return common.Now() + skewMS
+	}
+	n.Blockchain.SetClock(clock)
+	n.db.liar.SetClock(clock)
+}
+
+// Returns the errors reported by the loops of the node.
+func (n *SimNode) Errors() []error {
+	n.errsLock.RLock()
+	defer n.errsLock.RUnlock()
+
+	errs := make([]error, len(n.errs))
+	copy(errs, n.errs)
+	return errs
+}
@@ -0,0 +1,159 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wojtechnology/glacier/core"
+)
+
+const (
+	simNodes     = 4
+	simTimeoutMS = 20000
+)
+
+func getSimulator(t *testing.T) *Simulator {
+	if testing.Short() {
+		t.Skip("Skipping federation simulation in short mode")
+	}
+	t.Parallel()
+
+	s, err := NewSimulator(simNodes)
+	assert.Nil(t, err)
+	return s
+}
+
+// Adds n CREATE_TABLE transactions on different tables and returns them.
+func addCreateTables(t *testing.T, s *Simulator, n int) []*core.Transaction {
+	txs := make([]*core.Transaction, n)
+	for i := 0; i < n; i++ {
+		tableName := []byte(fmt.Sprintf("table%d", i))
+		tx := &core.Transaction{
+			Type:      core.TRANSACTION_TYPE_CREATE_TABLE,
+			TableName: tableName,
+			Outputs: []core.Output{&core.TableExistsOutput{
+				TableNameMixin: &core.TableNameMixin{Table: tableName},
+			}},
+		}
+		assert.Nil(t, s.AddTransaction(tx))
+		txs[i] = tx
+	}
+	return txs
+}
+
+// Waits until there are at least minBlocks blocks and all blocks are decided, then asserts that
+// all of them are in the expected state and that none of the running nodes reported errors.
+func assertBlockStates(t *testing.T, s *Simulator, minBlocks int, expected core.BlockState) {
+	bs, err := s.WaitForDecidedBlocks(simTimeoutMS, minBlocks)
+	assert.Nil(t, err)
+	for _, b := range bs {
+		assert.Equal(t, expected, b.State, "Block %x", b.Hash().Bytes())
+	}
+	assert.Empty(t, s.Errors())
+}
+
+// Asserts that every transaction ends up in an ACCEPTED block, that no block is rejected and that
+// none of the running nodes reported errors.
+func assertAccepted(t *testing.T, s *Simulator, txs []*core.Transaction) {
+	_, err := s.WaitForAcceptedTransactions(simTimeoutMS, txs)
+	assert.Nil(t, err)
+	assertBlockStates(t, s, 1, core.BLOCK_STATE_ACCEPTED)
+}
+
+func TestSimHonest(t *testing.T) {
+	s := getSimulator(t)
+	s.Start()
+	txs := addCreateTables(t, s, 10)
+
+	assertAccepted(t, s, txs)
+}
+
+func TestSimStop(t *testing.T) {
+	s := getSimulator(t)
+	s.Start()
+	assertAccepted(t, s, addCreateTables(t, s, 10))
+
+	// Loops finish and close their changefeeds without reporting errors
+	assert.Nil(t, s.Stop())
+	assert.Empty(t, s.Errors())
+}
+
+func TestSimCrashedNode(t *testing.T) {
+	s := getSimulator(t)
+	s.NodeAt(3).Crash()
+	s.Start()
+	txs := addCreateTables(t, s, 10)
+
+	// Transactions assigned to the crashed node are not put into blocks, but the remaining nodes
+	// can still reach the quorum.
+	assertAccepted(t, s, txs)
+}
+
+func TestSimByzantineVoter(t *testing.T) {
+	s := getSimulator(t)
+	s.NodeAt(0).SetByzantine(true)
+	s.Start()
+	txs := addCreateTables(t, s, 10)
+
+	assertAccepted(t, s, txs)
+}
+
+func TestSimByzantineMajority(t *testing.T) {
+	s := getSimulator(t)
+	s.NodeAt(0).SetByzantine(true)
+	s.NodeAt(1).SetByzantine(true)
+	s.Start()
+	addCreateTables(t, s, 10)
+
+	// Two invalid votes out of four mean that valid blocks can no longer reach 2/3
+	assertBlockStates(t, s, 1, core.BLOCK_STATE_REJECTED)
+}
+
+func TestSimClockSkew(t *testing.T) {
+	s := getSimulator(t)
+	s.NodeAt(1).SetClockSkew(-3600000)
+	s.NodeAt(2).SetClockSkew(3600000)
+	s.Start()
+	txs := addCreateTables(t, s, 10)
+
+	assertAccepted(t, s, txs)
+}
+
+func TestSimLateNode(t *testing.T) {
+	s := getSimulator(t)
+	s.NodeAt(0).Start()
+	s.NodeAt(1).Start()
+	txs := addCreateTables(t, s, 10)
+
+	// Two voters out of four cannot decide blocks, so the blocks wait for the late node
+	_, err := s.WaitForBlocks(simTimeoutMS, func(bs []*core.Block) bool { return len(bs) > 0 })
+	assert.Nil(t, err)
+	late := s.NodeAt(2)
+	late.Start()
+
+	assertAccepted(t, s, txs)
+
+	// Late node voted exactly once on every block, chained in order
+	bs, err := s.Blocks()
+	assert.Nil(t, err)
+	var prevBlockId *core.Hash = nil
+	for _, b := range bs {
+		vs, err := late.Blockchain.GetBlockVotes(b.Hash())
+		assert.Nil(t, err)
+
+		lateVs := make([]*core.Vote, 0)
+		for _, v := range vs {
+			if bytes.Equal(v.Voter, late.Node.PubKey) {
+				lateVs = append(lateVs, v)
+			}
+		}
+		if assert.Equal(t, 1, len(lateVs), "Block %x", b.Hash().Bytes()) && prevBlockId != nil {
+			assert.Equal(t, *prevBlockId, lateVs[0].PrevBlock)
+		}
+		blockId := b.Hash()
+		prevBlockId = &blockId
+	}
+}